@@ -2,5 +2,5 @@
 # Build seaflog command-line tool for 64-bit MacOS and Linux
 
 VERSION=$(git describe --dirty --tags)
-GOOS=darwin GOARCH=amd64 go build -o "seaflog-${VERSION}-darwin-amd64" cmd/seaflog/main.go || exit 1
-GOOS=linux GOARCH=amd64 go build -o "seaflog-${VERSION}-linux-amd64" cmd/seaflog/main.go || exit 1
+GOOS=darwin GOARCH=amd64 go build -o "seaflog-${VERSION}-darwin-amd64" ./cmd/seaflog || exit 1
+GOOS=linux GOARCH=amd64 go build -o "seaflog-${VERSION}-linux-amd64" ./cmd/seaflog || exit 1
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// archiveSep separates an archive file from the path of a log file inside
+// it, e.g. cruise.zip::logs/SFlog_740.txt
+const archiveSep = "::"
+
+// openArchived extracts member of the zip, tar, or gzipped tar archive at
+// archivePath to a temporary file, so it can be read and seeked like any other
+// log file. The temporary file is removed when closed.
+func openArchived(archivePath string, member string) (*os.File, error) {
+	f, err := os.CreateTemp("", "seaflog-archived-*")
+	if err != nil {
+		return nil, err
+	}
+	// Unlinked files stay readable until closed on Linux and MacOS
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		err = extractZip(f, archivePath, member)
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = extractTar(f, archivePath, member)
+	default:
+		err = fmt.Errorf("unknown archive type for %s, want .zip, .tar, .tar.gz, or .tgz", archivePath)
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// extractZip copies member of the zip archive at archivePath to w.
+func extractZip(w io.Writer, archivePath string, member string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		if path.Clean(zf.Name) != path.Clean(member) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(w, r)
+		return err
+	}
+	return fmt.Errorf("%s not found in %s", member, archivePath)
+}
+
+// extractTar copies member of the tar archive at archivePath, gzipped if
+// named .tar.gz or .tgz, to w.
+func extractTar(w io.Writer, archivePath string, member string) error {
+	af, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer af.Close()
+	var r io.Reader = af
+	if lower := strings.ToLower(archivePath); strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(af)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s not found in %s", member, archivePath)
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Clean(hdr.Name) == path.Clean(member) {
+			_, err = io.Copy(w, tr)
+			return err
+		}
+	}
+}
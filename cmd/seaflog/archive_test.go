@@ -0,0 +1,123 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// archiveMembers are the files in each test archive.
+var archiveMembers = map[string]string{
+	"README":                "cruise logs\n",
+	"logs/SFlog_740.txt":    "2015-03-14T00-26-52+00-00\nPMT1:1.05\n",
+	"logs/old/SFlog_10.txt": "2014-01-01T00-00-00+00-00\nPMT1:1.00\n",
+}
+
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, data := range archiveMembers {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTar(t *testing.T, path string, gzipped bool) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if gzipped {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: "logs/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range archiveMembers {
+		hdr := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenArchived(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "cruise.zip"))
+	writeTar(t, filepath.Join(dir, "cruise.tar"), false)
+	writeTar(t, filepath.Join(dir, "cruise.tar.gz"), true)
+	writeTar(t, filepath.Join(dir, "cruise.tgz"), true)
+	if err := ioutil.WriteFile(filepath.Join(dir, "cruise.rar"), []byte("rar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		archive string
+		member  string
+		want    string
+		wantErr bool
+	}{
+		{"zip", "cruise.zip", "logs/SFlog_740.txt", archiveMembers["logs/SFlog_740.txt"], false},
+		{"zip unclean member", "cruise.zip", "./logs//SFlog_740.txt", archiveMembers["logs/SFlog_740.txt"], false},
+		{"zip missing member", "cruise.zip", "logs/SFlog_741.txt", "", true},
+		{"tar", "cruise.tar", "logs/old/SFlog_10.txt", archiveMembers["logs/old/SFlog_10.txt"], false},
+		{"tar directory", "cruise.tar", "logs", "", true},
+		{"tar.gz", "cruise.tar.gz", "logs/SFlog_740.txt", archiveMembers["logs/SFlog_740.txt"], false},
+		{"tgz", "cruise.tgz", "README", archiveMembers["README"], false},
+		{"unknown type", "cruise.rar", "logs/SFlog_740.txt", "", true},
+		{"missing archive", "nope.zip", "logs/SFlog_740.txt", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := openArchived(filepath.Join(dir, tt.archive), tt.member)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openArchived() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer f.Close()
+			b, err := ioutil.ReadAll(f)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v; want nil", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("openArchived() contents %q; want %q", string(b), tt.want)
+			}
+			if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+				t.Errorf("temporary file %s still linked", f.Name())
+			}
+		})
+	}
+}
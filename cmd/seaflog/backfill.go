@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2"
+	"github.com/urfave/cli/v2"
+)
+
+var backfillCommand = &cli.Command{
+	Name:  "backfill",
+	Usage: "find SeaFlow log files under a directory, convert those without output files, and report outputs older than their logs",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "root",
+			Usage:    "directory to search for SFlog_*.txt log files",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "outdir",
+			Usage: "directory for output files, default the directory of each log file",
+		},
+		&cli.StringFlag{
+			Name:  "outfile",
+			Usage: "output file name template with {project}, {filetype}, and {basename} placeholders, default {project}_{basename}.tsv, or .csv with --format csv",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format, tsdata or csv",
+			Value: "tsdata",
+		},
+		&cli.BoolFlag{
+			Name:  "stale",
+			Usage: "also reconvert logs modified after their output file was written",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only report what would be converted",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		tmpl := c.String("outfile")
+		if tmpl == "" {
+			tmpl = "{project}_{basename}.tsv"
+			if c.String("format") == "csv" {
+				tmpl = "{project}_{basename}.csv"
+			}
+		}
+		// {date} would name a new output every day, so nothing would ever be
+		// up to date
+		if strings.Contains(tmpl, "{date}") {
+			return fmt.Errorf("--outfile can't use {date} for backfill")
+		}
+		logs := []string{}
+		err := filepath.Walk(c.String("root"), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() && logNameExpr.MatchString(info.Name()) {
+				logs = append(logs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		w := c.App.Writer
+		var upToDate, missing, stale, converted, failed int
+		for _, logfile := range logs {
+			project, err := projectFromPath(logfile)
+			if err != nil {
+				fmt.Fprintf(w, "%s: FAILED, %v\n", logfile, err)
+				failed++
+				continue
+			}
+			name, err := expandOutfile(tmpl, outfileVars(logfile, project, inferredFileType, time.Now()))
+			if err != nil {
+				return err
+			}
+			dir := c.String("outdir")
+			if dir == "" {
+				dir = filepath.Dir(logfile)
+			}
+			outfile := filepath.Join(dir, name)
+
+			status := "missing"
+			if out, err := os.Stat(outfile); err == nil {
+				status = "ok"
+				if in, err := os.Stat(logfile); err == nil && in.ModTime().After(out.ModTime()) {
+					status = "stale"
+				}
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+			switch status {
+			case "ok":
+				upToDate++
+				continue
+			case "missing":
+				missing++
+			case "stale":
+				stale++
+				if !c.Bool("stale") {
+					fmt.Fprintf(w, "%s: stale, log modified after %s\n", logfile, outfile)
+					continue
+				}
+			}
+			if c.Bool("dry-run") {
+				fmt.Fprintf(w, "%s: %s, would convert to %s\n", logfile, status, outfile)
+				continue
+			}
+			config := seaflog.ConverterConfig{Filetype: inferredFileType, Project: project, Format: c.String("format")}
+			if err := backfillLog(logfile, outfile, config); err != nil {
+				fmt.Fprintf(w, "%s: FAILED, %v\n", logfile, err)
+				failed++
+				continue
+			}
+			converted++
+			fmt.Fprintf(w, "%s: %s, converted to %s\n", logfile, status, outfile)
+		}
+		fmt.Fprintf(
+			w, "%d logs: %d up to date, %d missing, %d stale, %d converted, %d failed\n",
+			len(logs), upToDate, missing, stale, converted, failed,
+		)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d logs failed", failed, len(logs))
+		}
+		return nil
+	},
+}
+
+// backfillLog converts logfile to outfile with config. The output is written
+// to a temporary file in the same directory, then renamed, so an interrupted
+// backfill never leaves a partial output which looks up to date.
+func backfillLog(logfile string, outfile string, config seaflog.ConverterConfig) error {
+	conv, err := seaflog.NewConverter(config)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(logfile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(outfile), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(outfile), "."+filepath.Base(outfile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after rename
+	if _, err := conv.Convert(bufio.NewReader(in), tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), outfile)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// backfillTree creates logs under root for each backfill status: HOT227 has
+// no output, KM1906 an up to date output, and KM1907 a stale output.
+func backfillTree(t *testing.T, root string) {
+	t.Helper()
+	log := "2015-03-14T00-26-52+00-00\nPMT1:1.05\n"
+	old := time.Now().Add(-time.Hour)
+	files := []struct {
+		path  string
+		data  string
+		mtime time.Time
+	}{
+		{"HOT227/SFlog_740.txt", log, old},
+		{"KM1906/logs/SFlog_741.txt", log, old},
+		{"KM1906/logs/KM1906_SFlog_741.tsv", "converted\n", time.Now()},
+		{"KM1907/SFlog_742.txt", log, time.Now()},
+		{"KM1907/KM1907_SFlog_742.tsv", "converted\n", old},
+		{"KM1907/notes.txt", "not a log\n", old},
+	}
+	for _, f := range files {
+		path := filepath.Join(root, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(f.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, f.mtime, f.mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantSummary string
+		wantOutputs []string // output files which must contain converted data
+		wantErr     bool
+	}{
+		{
+			"missing only", nil,
+			"3 logs: 1 up to date, 1 missing, 1 stale, 1 converted, 0 failed",
+			[]string{"HOT227/HOT227_SFlog_740.tsv"}, false,
+		},
+		{
+			"stale", []string{"--stale"},
+			"3 logs: 1 up to date, 1 missing, 1 stale, 2 converted, 0 failed",
+			[]string{"HOT227/HOT227_SFlog_740.tsv", "KM1907/KM1907_SFlog_742.tsv"}, false,
+		},
+		{
+			"dry run", []string{"--stale", "--dry-run"},
+			"3 logs: 1 up to date, 1 missing, 1 stale, 0 converted, 0 failed",
+			nil, false,
+		},
+		{
+			"csv", []string{"--format", "csv"},
+			"3 logs: 0 up to date, 3 missing, 0 stale, 3 converted, 0 failed",
+			[]string{"HOT227/HOT227_SFlog_740.csv", "KM1906/logs/KM1906_SFlog_741.csv", "KM1907/KM1907_SFlog_742.csv"}, false,
+		},
+		{"date placeholder", []string{"--outfile", "{project}_{date}.tsv"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			backfillTree(t, root)
+			var out bytes.Buffer
+			app := &cli.App{Writer: &out, Commands: []*cli.Command{backfillCommand}}
+			err := app.Run(append([]string{"seaflog", "backfill", "--root", root}, tt.args...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("backfill error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !strings.Contains(out.String(), tt.wantSummary+"\n") {
+				t.Errorf("backfill output %q; want summary %q", out.String(), tt.wantSummary)
+			}
+			for _, name := range tt.wantOutputs {
+				b, err := ioutil.ReadFile(filepath.Join(root, name))
+				if err != nil {
+					t.Errorf("output %s error = %v; want nil", name, err)
+				} else if !strings.Contains(string(b), "1.05") {
+					t.Errorf("output %s %q; want converted PMT1 value", name, string(b))
+				}
+			}
+			if len(tt.wantOutputs) == 0 {
+				if _, err := os.Stat(filepath.Join(root, "HOT227/HOT227_SFlog_740.tsv")); !os.IsNotExist(err) {
+					t.Errorf("dry run wrote an output file")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var changesCommand = &cli.Command{
+	Name:  "changes",
+	Usage: "list every change of instrument settings in a log file with before and after values",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		changes := pipeline.NewChanges()
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			changes.Add(es.Event())
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "time\tline\tsetting\tbefore\tafter")
+		for _, ch := range changes.Changes() {
+			before := "NA"
+			if ch.From != nil {
+				before = fmt.Sprintf("%v", ch.From)
+			}
+			fmt.Fprintf(
+				tw, "%s\t%d\t%s\t%s\t%v\n",
+				writer.FormatTime(ch.Time, writer.TimeFormatRFC3339), ch.LineNumber, ch.Name, before, ch.To,
+			)
+		}
+		return tw.Flush()
+	},
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/seaflogtime"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var datafilesCommand = &cli.Command{
+	Name:  "datafiles",
+	Usage: "check every acquisition interval in a log file has EVT or OPP data files in a listing, and every data file lies in an acquisition interval",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "listing",
+			Usage:    "listing of data file paths, one per line, e.g. from find, or '-' for STDIN. Lines which aren't timestamped file names are skipped",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "tolerance",
+			Usage: "match files this long before an acquisition interval starts or after it stops",
+			Value: time.Minute,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("logfile") == "-" {
+			return fmt.Errorf("--logfile can't be STDIN, use it for --listing")
+		}
+		files, skipped, err := readListing(c.String("listing"))
+		if err != nil {
+			return err
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		pairs := pipeline.NewPairs(defs.PairDefs)
+		intervals := []pipeline.Interval{}
+		var last time.Time
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			event := es.Event()
+			if event.Error != nil {
+				continue
+			}
+			if event.Time.After(last) {
+				last = event.Time
+			}
+			intervals = append(intervals, pairs.Add(event)...)
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+		intervals = append(intervals, pairs.Flush()...)
+
+		fc := pipeline.CheckDataFiles(intervals, files, last, c.Duration("tolerance"))
+		w := c.App.Writer
+		for _, iv := range fc.Empty {
+			end := "unfinished"
+			if !iv.End.IsZero() {
+				end = writer.FormatTime(iv.End, writer.TimeFormatRFC3339)
+			}
+			fmt.Fprintf(
+				w, "no data files: acquisition %s to %s, line %d\n",
+				writer.FormatTime(iv.Start, writer.TimeFormatRFC3339), end, iv.StartLine,
+			)
+		}
+		for _, f := range fc.Orphans {
+			fmt.Fprintf(w, "outside acquisition: %s\n", f.Name)
+		}
+		fmt.Fprintf(
+			w, "%d acquisition intervals, %d data files, %d skipped listing lines\n",
+			fc.Intervals, fc.Files, skipped,
+		)
+		if !fc.OK() {
+			return fmt.Errorf("%d acquisition intervals without data files, %d data files outside acquisition", len(fc.Empty), len(fc.Orphans))
+		}
+		return nil
+	},
+}
+
+// readListing reads data file names from a listing at path, '-' for STDIN,
+// returning the number of blank or untimestamped lines skipped.
+func readListing(path string) ([]pipeline.DataFile, int, error) {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, 0, err
+		}
+		defer f.Close()
+	}
+	files := []pipeline.DataFile{}
+	skipped := 0
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		name := strings.TrimSpace(s.Text())
+		t, err := seaflogtime.ParseFileName(name)
+		if err != nil {
+			skipped++
+			continue
+		}
+		files = append(files, pipeline.DataFile{Name: name, Time: t})
+	}
+	return files, skipped, s.Err()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var defsCommand = &cli.Command{
+	Name:  "defs",
+	Usage: "inspect event definitions",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "schema",
+			Usage: "print a schema for output records generated from the event definitions",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "schema format, jsonschema or avro",
+					Value: writer.SchemaJSONSchema,
+				},
+				&cli.StringFlag{
+					Name:  "name",
+					Usage: "schema title or record name",
+					Value: "SeaFlowV1InstrumentLog",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				tsdw := writer.NewTsdataWriter(c.String("name"), "schema", "")
+				schema, err := writer.Schema(c.String("format"), c.String("name"), tsdw.Columns())
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintf(c.App.Writer, "%s\n", schema)
+				return err
+			},
+		},
+	},
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var densityCommand = &cli.Command{
+	Name:  "density",
+	Usage: "export event counts per event name and time bucket, including parsing errors, for heatmap plotting",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "bucket",
+			Usage: "length of each time bucket",
+			Value: time.Hour,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "csv for time,event,count rows, or json for a matrix of counts by event and time",
+			Value: writer.DensityCSV,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Duration("bucket") <= 0 {
+			return fmt.Errorf("--bucket must be positive")
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		density := pipeline.NewDensity(c.Duration("bucket"))
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			density.Add(es.Event())
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+
+		out, err := writer.Density(c.String("format"), density.Cells())
+		if err != nil {
+			return err
+		}
+		_, err = c.App.Writer.Write(out)
+		return err
+	},
+}
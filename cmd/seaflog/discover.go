@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+// bufferStream copies stream r, e.g. STDIN or a named pipe, to a temporary
+// file, so it can be read twice. The caller should close and remove the file.
+func bufferStream(r *os.File) (*os.File, error) {
+	f, err := os.CreateTemp("", "seaflog-stdin-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// discoverDefs finds event definitions for structured "Key: value" lines in
+// the rest of f which match no event definition, read with a scanner set up by
+// configure. f is left at the position it started from.
+func discoverDefs(f *os.File, configure func(*scanner.EventScanner)) ([]defs.EventDef, error) {
+	start, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	d := pipeline.NewDiscoverer()
+	es := scanner.NewEventScanner(bufio.NewReader(f))
+	configure(es)
+	for es.Scan() {
+		d.Add(es.Event())
+	}
+	if err := es.Err(); err != nil {
+		return nil, err
+	}
+	_, err = f.Seek(start, io.SeekStart)
+	return d.Defs(), err
+}
+
+// isDiscovered returns true if name is the name of one of discovered.
+func isDiscovered(discovered []defs.EventDef, name string) bool {
+	for _, edef := range discovered {
+		if edef.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var docsCommand = &cli.Command{
+	Name:  "docs",
+	Usage: "print a man page or examples gallery generated from the flags, event definitions, and output columns",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "man for a roff man page, examples for a Markdown gallery of event examples",
+			Value: "man",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		switch c.String("format") {
+		case "man":
+			return writeMan(c.App.Writer, c.App)
+		case "examples":
+			return writeExamples(c.App.Writer)
+		default:
+			return fmt.Errorf("unknown --format %q, want man or examples", c.String("format"))
+		}
+	},
+}
+
+// flagDoc describes a flag for documentation.
+type flagDoc struct {
+	name   string
+	arg    string // value placeholder, empty for boolean flags
+	usage  string
+	envs   []string
+	def    string // default value, empty if none
+	hidden bool
+}
+
+// describeFlag returns the documentation of one of the flag types seaflog
+// uses.
+func describeFlag(f cli.Flag) (flagDoc, bool) {
+	switch f := f.(type) {
+	case *cli.StringFlag:
+		return flagDoc{f.Name, "VALUE", f.Usage, f.EnvVars, f.Value, f.Hidden}, true
+	case *cli.StringSliceFlag:
+		return flagDoc{f.Name, "VALUE", f.Usage, f.EnvVars, "", f.Hidden}, true
+	case *cli.BoolFlag:
+		return flagDoc{f.Name, "", f.Usage, f.EnvVars, "", f.Hidden}, true
+	case *cli.IntFlag:
+		return flagDoc{f.Name, "N", f.Usage, f.EnvVars, fmt.Sprint(f.Value), f.Hidden}, true
+	case *cli.DurationFlag:
+		return flagDoc{f.Name, "DURATION", f.Usage, f.EnvVars, f.Value.String(), f.Hidden}, true
+	}
+	return flagDoc{}, false
+}
+
+// roff escapes text for a man page.
+func roff(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\e`)
+	text = strings.ReplaceAll(text, "-", `\-`)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// writeMan writes a roff man page for app, with sections listing event
+// definitions and output columns.
+func writeMan(w io.Writer, app *cli.App) error {
+	b := &strings.Builder{}
+	usage := strings.SplitN(app.Usage, "\n", 2)[0]
+	fmt.Fprintf(b, ".TH SEAFLOG 1 \"\" \"seaflog %s\" \"User Commands\"\n", roff(app.Version))
+	fmt.Fprintf(b, ".SH NAME\n%s \\- %s\n", app.Name, roff(usage))
+	b.WriteString(".SH SYNOPSIS\n")
+	for i, line := range strings.Split(app.UsageText, "\n") {
+		if i > 0 {
+			b.WriteString(".br\n")
+		}
+		fmt.Fprintf(b, "%s\n", roff(strings.TrimSpace(line)))
+	}
+
+	b.WriteString(".SH OPTIONS\n")
+	for _, f := range app.Flags {
+		doc, ok := describeFlag(f)
+		if !ok || doc.hidden {
+			continue
+		}
+		fmt.Fprintf(b, ".TP\n.B \\-\\-%s", roff(doc.name))
+		if doc.arg != "" {
+			fmt.Fprintf(b, " \\fI%s\\fR", doc.arg)
+		}
+		fmt.Fprintf(b, "\n%s", roff(doc.usage))
+		if doc.def != "" {
+			fmt.Fprintf(b, " (default %s)", roff(doc.def))
+		}
+		if len(doc.envs) > 0 {
+			fmt.Fprintf(b, " [$%s]", roff(strings.Join(doc.envs, ", $")))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(".SH COMMANDS\n")
+	for _, cmd := range app.Commands {
+		fmt.Fprintf(b, ".TP\n.B %s\n%s\n", roff(cmd.Name), roff(cmd.Usage))
+	}
+
+	b.WriteString(".SH EVENTS\nLog lines are parsed as these events, each written to the output column of the same name.\n")
+	for _, name := range sortedDefNames() {
+		edef := defs.EventDefs[name]
+		fmt.Fprintf(b, ".TP\n.B %s\n%s", roff(name), edef.Type)
+		if edef.Unit != "" {
+			fmt.Fprintf(b, ", %s", roff(edef.Unit))
+		}
+		if len(edef.Values) > 0 {
+			fmt.Fprintf(b, ", one of %s", roff(strings.Join(edef.Values, ", ")))
+		}
+		prefixes := []string{}
+		for _, eform := range edef.EventForms {
+			prefixes = append(prefixes, fmt.Sprintf("%q", eform.StartsWith))
+		}
+		if len(prefixes) > 0 {
+			fmt.Fprintf(b, ", from lines starting %s", roff(strings.Join(prefixes, " or ")))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(".SH OUTPUT COLUMNS\nColumns of TSDATA and CSV output without optional columns, in order.\n")
+	tsdw := writer.NewTsdataWriter("docs", "docs", "")
+	for _, col := range tsdw.Columns() {
+		fmt.Fprintf(b, ".TP\n.B %s\n%s", roff(col.Name), col.Type)
+		if col.Unit != "" {
+			fmt.Fprintf(b, ", %s", roff(col.Unit))
+		}
+		if col.Comment != "" {
+			fmt.Fprintf(b, ", %s", roff(col.Comment))
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeExamples writes a Markdown gallery of the examples in the event
+// definitions, with the value each is written as.
+func writeExamples(w io.Writer) error {
+	b := &strings.Builder{}
+	b.WriteString("# seaflog event examples\n\n")
+	b.WriteString("Generated by `seaflog docs --format examples` from the event definitions.\n")
+	tsdw := writer.NewTsdataWriter("docs", "docs", "")
+	headers := tsdw.Metadata().Headers
+	for _, name := range sortedDefNames() {
+		edef := defs.EventDefs[name]
+		rows := []string{}
+		for _, eform := range edef.EventForms {
+			for _, ex := range eform.Examples {
+				fields, err := tsdw.EventFields(ex.Parsed)
+				if err != nil {
+					return fmt.Errorf("example of %s: %v", name, err)
+				}
+				value := ""
+				for i, h := range headers {
+					if h == ex.Parsed.Name && i < len(fields) {
+						value = fields[i]
+					}
+				}
+				rows = append(rows, fmt.Sprintf("| `%s` | %s | %s |", mdEscape(ex.Parsed.Line), ex.Parsed.Name, mdEscape(value)))
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\n## %s\n\n%s", name, edef.Type)
+		if edef.Unit != "" {
+			fmt.Fprintf(b, ", %s", edef.Unit)
+		}
+		b.WriteString("\n\n| Log line | Column | Value |\n| --- | --- | --- |\n")
+		b.WriteString(strings.Join(rows, "\n"))
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdEscape escapes text for a Markdown table cell.
+func mdEscape(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+func sortedDefNames() []string {
+	names := make([]string, 0, len(defs.EventDefs))
+	for name := range defs.EventDefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDocs(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			"man",
+			[]string{
+				".TH SEAFLOG 1 ",
+				".SH NAME\nseaflog \\- convert a SeaFlow v1 log file to TSDATA format\n",
+				// Flags with their argument, default, and variable
+				".B \\-\\-tsdata\\-out \\fIVALUE\\fR\nadditional output file in tsdata format, " +
+					"with the same placeholders as \\-\\-outfile [$SEAFLOG_TSDATA_OUT]\n",
+				"(default tsdata) [$SEAFLOG_FORMAT]\n",
+				".B \\-\\-reopen\n",
+				// Commands, events, and output columns
+				".SH COMMANDS\n",
+				".B grep\nprint event lines matching a pattern with their resolved time, event name, and value\n",
+				".SH EVENTS\n",
+				".B PMT1\nfloat, V, from lines starting \"PMT1:\"\n",
+				".SH OUTPUT COLUMNS\n",
+				".B time\ntime, ISO8601 timestamp\n",
+			},
+		},
+		{
+			"examples",
+			[]string{
+				"# seaflog event examples\n",
+				"\n## PMT1\n\nfloat, V\n\n| Log line | Column | Value |\n| --- | --- | --- |\n| `PMT1:1.05` | PMT1 | 1.05 |\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			app := newApp()
+			app.Writer = &out
+			if err := app.Run([]string{"seaflog", "docs", "--format", tt.format}); err != nil {
+				t.Fatalf("seaflog docs error = %v; want nil", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("seaflog docs --format %s has no %q", tt.format, want)
+				}
+			}
+		})
+	}
+
+	app := newApp()
+	app.Writer = &bytes.Buffer{}
+	if err := app.Run([]string{"seaflog", "docs", "--format", "html"}); err == nil {
+		t.Errorf("seaflog docs --format html error = nil; want an error")
+	}
+}
+
+func TestRoff(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"built-in", `built\-in`},
+		{`C:\logs`, `C:\elogs`},
+		{".hidden", `\&.hidden`},
+		{"'quoted'", `\&'quoted'`},
+	}
+	for _, tt := range tests {
+		if got := roff(tt.text); got != tt.want {
+			t.Errorf("roff(%q) = %q; want %q", tt.text, got, tt.want)
+		}
+	}
+}
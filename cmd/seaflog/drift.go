@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var driftCommand = &cli.Command{
+	Name:  "drift",
+	Usage: "fit a linear trend to laser power, or another float event, over a log file and flag drift beyond a threshold",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "event",
+			Usage: "float event to check",
+			Value: pipeline.DriftEvent,
+		},
+		&cli.StringFlag{
+			Name:  "threshold",
+			Usage: "flag drift of more than this fraction of the fitted starting value",
+			Value: "0.05",
+		},
+		&cli.BoolFlag{
+			Name:  "alerts",
+			Usage: "also list an alert each time the fitted drift first exceeds the threshold",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		threshold, err := strconv.ParseFloat(c.String("threshold"), 64)
+		if err != nil {
+			return fmt.Errorf("--threshold %q is not a number", c.String("threshold"))
+		}
+		drift, err := pipeline.NewDrift(c.String("event"), threshold)
+		if err != nil {
+			return err
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		alerts := []defs.Event{}
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			if alert, ok := drift.Add(es.Event()); ok {
+				alerts = append(alerts, alert)
+			}
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+
+		s := drift.Summary()
+		format := func(v float64) string {
+			if math.IsNaN(v) {
+				return "NA"
+			}
+			return strconv.FormatFloat(v, 'g', 6, 64)
+		}
+		unit := defs.EventDefs[s.Name].Unit
+		w := c.App.Writer
+		fmt.Fprintf(w, "event: %s\nreadings: %d\n", s.Name, s.Readings)
+		if s.Readings > 0 {
+			fmt.Fprintf(
+				w, "span: %s to %s\n",
+				writer.FormatTime(s.First, writer.TimeFormatRFC3339), writer.FormatTime(s.Last, writer.TimeFormatRFC3339),
+			)
+		}
+		drifted := "NA"
+		if !math.IsNaN(s.Drift) {
+			drifted = fmt.Sprintf("%+.2f%%", s.Drift*100)
+		}
+		fmt.Fprintf(
+			w, "fitted start: %s %s\nfitted end: %s %s\nslope: %s %s/day\ndrift: %s\nexceeded %.2f%% threshold: %v\n",
+			format(s.Start), unit, format(s.End), unit, format(s.Slope), unit, drifted, threshold*100, s.Exceeded,
+		)
+		if c.Bool("alerts") {
+			for _, alert := range alerts {
+				fmt.Fprintf(w, "%s line %d: %v\n", writer.FormatTime(alert.Time, writer.TimeFormatRFC3339), alert.LineNumber, alert.Value)
+			}
+		}
+		return nil
+	},
+}
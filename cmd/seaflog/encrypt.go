@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/internal/proc"
+)
+
+// encryptedFile encrypts everything written to it to an output file, through
+// the age or gpg program.
+type encryptedFile struct {
+	f   *os.File
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+// encryptCommand returns the command encrypting STDIN to STDOUT for
+// recipients: age for age recipients, e.g. age1..., or SSH public keys, and
+// gpg for PGP key IDs, fingerprints, or email addresses.
+func encryptCommand(recipients []string) (*exec.Cmd, error) {
+	age := 0
+	for _, r := range recipients {
+		if strings.HasPrefix(r, "age1") || strings.HasPrefix(r, "ssh-") {
+			age++
+		}
+	}
+	switch age {
+	case len(recipients):
+		args := []string{"--encrypt"}
+		for _, r := range recipients {
+			args = append(args, "--recipient", r)
+		}
+		return exec.Command("age", args...), nil
+	case 0:
+		args := []string{"--batch", "--encrypt", "--output", "-"}
+		for _, r := range recipients {
+			args = append(args, "--recipient", r)
+		}
+		return exec.Command("gpg", args...), nil
+	default:
+		return nil, fmt.Errorf("--encrypt-recipient can't mix age and PGP recipients")
+	}
+}
+
+// createEncrypted creates path and any missing parent directories, '-' for
+// STDOUT, and starts encrypting to it for recipients. The encrypted output is
+// also written to sum, if not nil, e.g. to checksum the file as written.
+func createEncrypted(path string, recipients []string, sum io.Writer) (*encryptedFile, error) {
+	cmd, err := encryptCommand(recipients)
+	if err != nil {
+		return nil, err
+	}
+	f, err := createOutfile(path)
+	if err != nil {
+		return nil, err
+	}
+	ef := &encryptedFile{f: f, cmd: cmd}
+	cmd.Stdout = f
+	if sum != nil {
+		cmd.Stdout = io.MultiWriter(f, sum)
+	}
+	cmd.Stderr = os.Stderr
+	proc.OwnProcessGroup(cmd)
+	if ef.in, err = cmd.StdinPipe(); err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		if f != os.Stdout {
+			f.Close()
+		}
+		return nil, fmt.Errorf("encrypting %s, %v", path, err)
+	}
+	return ef, nil
+}
+
+// Write writes plain text to be encrypted.
+func (ef *encryptedFile) Write(p []byte) (int, error) {
+	return ef.in.Write(p)
+}
+
+// Close ends the plain text, waits for encryption to finish, and closes the
+// output file.
+func (ef *encryptedFile) Close() error {
+	err := ef.in.Close()
+	if werr := ef.cmd.Wait(); err == nil && werr != nil {
+		err = fmt.Errorf("encrypting %s, %v", ef.f.Name(), werr)
+	}
+	if ef.f == os.Stdout {
+		return err
+	}
+	if cerr := ef.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
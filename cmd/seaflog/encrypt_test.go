@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEncryptCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		recipients []string
+		want       []string // command line
+		wantErr    bool
+	}{
+		{
+			"age",
+			[]string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"},
+			[]string{"age", "--encrypt", "--recipient", "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"},
+			false,
+		},
+		{
+			"age and ssh",
+			[]string{"age1abc", "ssh-ed25519 AAAAC3Nza user@ship"},
+			[]string{"age", "--encrypt", "--recipient", "age1abc", "--recipient", "ssh-ed25519 AAAAC3Nza user@ship"},
+			false,
+		},
+		{
+			"pgp",
+			[]string{"data@example.org", "0xDEADBEEF"},
+			[]string{"gpg", "--batch", "--encrypt", "--output", "-", "--recipient", "data@example.org", "--recipient", "0xDEADBEEF"},
+			false,
+		},
+		{"mixed", []string{"age1abc", "data@example.org"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := encryptCommand(tt.recipients)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encryptCommand() error = %v; want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if strings.Join(cmd.Args, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("encryptCommand() = %q; want %q", cmd.Args, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateEncrypted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake age is a shell script")
+	}
+	// A fake age which "encrypts" by upper casing
+	bin := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(bin, "age"), []byte("#!/bin/sh\nexec tr a-z A-Z\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+	defer os.Setenv("PATH", path)
+
+	out := filepath.Join(t.TempDir(), "sub", "out.tsdata.age")
+	var sum bytes.Buffer
+	ef, err := createEncrypted(out, []string{"age1abc"}, &sum)
+	if err != nil {
+		t.Fatalf("createEncrypted() error = %v; want nil", err)
+	}
+	if _, err := ef.Write([]byte("pmt1\t1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ef.Close(); err != nil {
+		t.Fatalf("Close() error = %v; want nil", err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "PMT1\t1\n" || sum.String() != string(b) {
+		t.Errorf("encrypted file %q, checksummed %q; want %q", b, sum.String(), "PMT1\t1\n")
+	}
+
+	if _, err := createEncrypted(out, []string{"age1abc", "data@example.org"}, nil); err == nil {
+		t.Errorf("createEncrypted(mixed recipients) error = nil; want an error")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvVars(t *testing.T) {
+	// Every global flag has a SEAFLOG_ variable
+	for _, f := range newApp().Flags {
+		name := f.Names()[0]
+		want := "SEAFLOG_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		// Every flag type has an EnvVars field
+		envVars, _ := reflect.ValueOf(f).Elem().FieldByName("EnvVars").Interface().([]string)
+		if len(envVars) != 1 || envVars[0] != want {
+			t.Errorf("--%s environment variables %v; want [%s]", name, envVars, want)
+		}
+	}
+
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "SFlog_740.txt")
+	if err := ioutil.WriteFile(logfile, []byte("2015-03-14T01-00-00+00-00\nPMT1:1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"SEAFLOG_LOGFILE":  logfile,
+		"SEAFLOG_FILETYPE": "envtype",
+		"SEAFLOG_PROJECT":  "envproject",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string // first header lines
+	}{
+		{"environment", nil, []string{"envtype", "envproject"}},
+		{"flag overrides environment", []string{"--project", "flagproject"}, []string{"envtype", "flagproject"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outfile := filepath.Join(t.TempDir(), "out.tsdata")
+			args := append([]string{"seaflog", "--outfile", outfile}, tt.args...)
+			if err := newApp().Run(args); err != nil {
+				t.Fatalf("seaflog error = %v; want nil", err)
+			}
+			b, err := ioutil.ReadFile(outfile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(b), "\n")
+			if len(lines) < len(tt.want) || strings.Join(lines[:len(tt.want)], ",") != strings.Join(tt.want, ",") {
+				t.Errorf("header starts %q; want %q", lines, tt.want)
+			}
+		})
+	}
+}
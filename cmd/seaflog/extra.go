@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// findExtraChannels returns the names of extra channels of indexed event
+// definitions in the rest of f, e.g. PMT9 for PMT{n} with indexes 1 to 8, in
+// order of first appearance. f is left at the position it started from, so
+// outputs can add columns for extra channels before writing headers.
+func findExtraChannels(f *os.File) ([]string, error) {
+	start, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	seen := make(map[string]bool)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if edef, _, ok := defs.ExtraChannel(s.Text()); ok && !seen[edef.Name] {
+			seen[edef.Name] = true
+			names = append(names, edef.Name)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	_, err = f.Seek(start, io.SeekStart)
+	return names, err
+}
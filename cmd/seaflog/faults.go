@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var faultsCommand = &cli.Command{
+	Name:  "faults",
+	Usage: "group fault events in a log file into incidents and report mean time between faults and incident durations",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "gap",
+			Usage: "start a new incident for a fault more than this long after the previous fault",
+			Value: 10 * time.Minute,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Duration("gap") < 0 {
+			return fmt.Errorf("--gap must not be negative")
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		incidents := pipeline.NewIncidents(c.Duration("gap"))
+		found := []pipeline.Incident{}
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			if inc, ok := incidents.Add(es.Event()); ok {
+				found = append(found, inc)
+			}
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+		if inc, ok := incidents.Flush(); ok {
+			found = append(found, inc)
+		}
+
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "start\tend\tlines\tduration\tfaults\tevents")
+		for _, inc := range found {
+			names := make([]string, 0, len(inc.Counts))
+			for name, n := range inc.Counts {
+				names = append(names, fmt.Sprintf("%s=%d", name, n))
+			}
+			sort.Strings(names)
+			fmt.Fprintf(
+				tw, "%s\t%s\t%d-%d\t%v\t%d\t%s\n",
+				writer.FormatTime(inc.Start, writer.TimeFormatRFC3339), writer.FormatTime(inc.End, writer.TimeFormatRFC3339),
+				inc.StartLine, inc.EndLine, inc.Duration(), inc.Faults, strings.Join(names, ","),
+			)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		rel := pipeline.NewReliability(found)
+		mtbf := "NA"
+		if rel.Incidents > 1 {
+			mtbf = rel.MTBF.String()
+		}
+		_, err = fmt.Fprintf(
+			c.App.Writer, "\nincidents: %d\nfaults: %d\nmean time between faults: %s\nmean incident duration: %v\n",
+			rel.Incidents, rel.Faults, mtbf, rel.MeanDuration,
+		)
+		return err
+	},
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// isFIFO reports whether f is a named pipe, which can't seek and, like STDIN,
+// can only be read once.
+func isFIFO(f *os.File) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return fi.Mode()&os.ModeNamedPipe != 0, nil
+}
+
+// reopener reads a named pipe through any number of writers. The pipe is
+// opened without blocking, and while no writer has it open reads wait for the
+// next writer, so reads only end with an error or after a stop.
+type reopener struct {
+	f    *os.File
+	last byte             // last byte read, to end a final partial line at EOF
+	stop <-chan os.Signal // closed or sent a value to end reads
+}
+
+// openReopener opens the named pipe at path for reading. It doesn't wait for
+// a writer.
+func openReopener(path string) (*reopener, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	if fifo, err := isFIFO(f); err != nil || !fifo {
+		f.Close()
+		if err == nil {
+			err = fmt.Errorf("--reopen requires --logfile to be a named pipe, %s is not", path)
+		}
+		return nil, err
+	}
+	return &reopener{f: f, last: '\n'}, nil
+}
+
+// stopOn makes reads end with io.EOF once stop receives, even while waiting
+// for data or a writer.
+func (ro *reopener) stopOn(stop <-chan os.Signal) {
+	ro.stop = stop
+}
+
+// Read reads from the pipe. A writer which closes the pipe partway through a
+// line gets a newline added, so its partial line isn't joined to the next
+// writer's first line.
+func (ro *reopener) Read(p []byte) (int, error) {
+	for {
+		// Wake up now and then to check for a stop. Where the pipe can't
+		// be polled, reads return EAGAIN instead of waiting for data.
+		ro.f.SetReadDeadline(time.Now().Add(stopPoll))
+		n, err := ro.f.Read(p)
+		if n > 0 {
+			ro.last = p[n-1]
+			return n, nil
+		}
+		switch {
+		case err == io.EOF:
+			// No writer has the pipe open
+			if ro.last != '\n' && len(p) > 0 {
+				p[0] = '\n'
+				ro.last = '\n'
+				return 1, nil
+			}
+		case errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, syscall.EAGAIN):
+		default:
+			return 0, err
+		}
+		select {
+		case <-ro.stop:
+			return 0, io.EOF
+		default:
+		}
+		if err == io.EOF || errors.Is(err, syscall.EAGAIN) {
+			time.Sleep(stopPoll)
+		}
+	}
+}
+
+// Close closes the pipe.
+func (ro *reopener) Close() error {
+	return ro.f.Close()
+}
@@ -0,0 +1,133 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestReopenerStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Opening must not wait for a writer
+	ro, err := openReopener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	stop := make(chan os.Signal, 1)
+	ro.stopOn(stop)
+
+	write := func(s string) {
+		w, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Close()
+		if _, err := w.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func() (string, error) {
+		buf := make([]byte, 64)
+		n, err := ro.Read(buf)
+		return string(buf[:n]), err
+	}
+
+	// A partial last line is ended before the next writer's lines
+	write("a\nb")
+	for _, want := range []string{"a\nb", "\n"} {
+		if got, err := read(); got != want || err != nil {
+			t.Fatalf("reopener Read() = %q, %v; want %q, nil", got, err, want)
+		}
+	}
+	write("c\n")
+	if got, err := read(); got != "c\n" || err != nil {
+		t.Fatalf("reopener Read() = %q, %v; want %q, nil", got, err, "c\n")
+	}
+
+	// A read waiting for the next writer ends on stop
+	go func() {
+		time.Sleep(2 * stopPoll)
+		stop <- os.Interrupt
+	}()
+	done := make(chan error)
+	go func() {
+		_, err := read()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("reopener Read() error = %v; want io.EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reopener Read() still waiting after stop")
+	}
+}
+
+func TestReopenInterrupt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	outfile := filepath.Join(dir, "out.tsdata")
+	app := newApp()
+	app.ExitErrHandler = func(*cli.Context, error) {} // return the exit code instead
+	done := make(chan error)
+	go func() {
+		done <- app.Run([]string{
+			"seaflog", "--logfile", path, "--reopen", "--filetype", "test", "--project", "test", "--outfile", outfile, "--quiet",
+		})
+	}()
+	w, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString("2015-03-14T01-00-00+00-00\nPMT1:1\nPMT1:2\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	// Interrupt while waiting for the next writer
+	time.Sleep(4 * stopPoll)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("seaflog --reopen still running after interrupt")
+	}
+	if ec, ok := err.(cli.ExitCoder); !ok || ec.ExitCode() != exitInterrupted {
+		t.Fatalf("seaflog --reopen error = %v; want exit code %d", err, exitInterrupted)
+	}
+	b, err := ioutil.ReadFile(outfile + ".partial")
+	if err != nil {
+		t.Fatalf("no .partial file, %v", err)
+	}
+	if want := "interrupted\nlast_line\t3\nlast_time\t2015-03-14T01:00:00Z\n"; string(b) != want {
+		t.Errorf(".partial %q; want %q", b, want)
+	}
+	// Outputs are flushed
+	out, err := ioutil.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\n2015-03-14T01:00:00+00:00\t2") {
+		t.Errorf("output\n%s\nwant the last event", out)
+	}
+}
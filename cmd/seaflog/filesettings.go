@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var fileSettingsCommand = &cli.Command{
+	Name:  "filesettings",
+	Usage: "write a CSV table of the PMT, trigger, pump, and other settings in effect when each EVT file in a listing was created",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "listing",
+			Usage:    "listing of data file paths, one per line, e.g. from find, or '-' for STDIN. Lines which aren't timestamped file names are skipped",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "na",
+			Usage: "value written for settings not yet seen when a file was created",
+			Value: "NA",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.String("logfile") == "-" {
+			return fmt.Errorf("--logfile can't be STDIN, use it for --listing")
+		}
+		files, _, err := readListing(c.String("listing"))
+		if err != nil {
+			return err
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		changes := pipeline.NewChanges()
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			changes.Add(es.Event())
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+
+		rows := pipeline.SettingsAtFiles(changes.Changes(), files)
+		_, err = fmt.Fprint(c.App.Writer, writer.FileSettingsCSV(rows, pipeline.SettingNames(), c.String("na")))
+		return err
+	},
+}
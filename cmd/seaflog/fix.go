@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var fixCommand = &cli.Command{
+	Name:      "fix",
+	Usage:     "rewrite a malformed TSDATA file with common defects fixed, reporting every change",
+	ArgsUsage: "FILE",
+	Description: "Fixes missing value tokens other than NA, e.g. NaN or empty fields, times without a\n" +
+		"numeric time zone or with a space for T, lower case booleans, and text values, e.g.\n" +
+		"notes, containing tabs, which split them across columns. Lines which still don't\n" +
+		"conform to the TSDATA spec are reported and written unchanged.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "outfile",
+			Usage: "corrected output file, '-' for STDOUT, default FILE.fixed",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return fmt.Errorf("want one TSDATA file to fix")
+		}
+		path := c.Args().First()
+		outPath := c.String("outfile")
+		if outPath == "" {
+			outPath = path + ".fixed"
+		}
+		if outPath == path {
+			return fmt.Errorf("--outfile can't be the file being fixed")
+		}
+		spec, err := writer.LookupTsdataSpec(writer.DefaultTsdataVersion)
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		s := bufio.NewScanner(in)
+		s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		header := []string{}
+		for len(header) < spec.HeaderLines() && s.Scan() {
+			header = append(header, s.Text())
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+		m, err := spec.ParseHeader(header)
+		if err != nil {
+			return fmt.Errorf("can't fix %s, bad header, %v", path, err)
+		}
+
+		out, err := createOutfile(outPath)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(out)
+		if _, err := fmt.Fprintf(w, "%s\n", strings.Join(header, "\n")); err != nil {
+			return err
+		}
+		fixed, unfixed := 0, 0
+		for i := len(header) + 1; s.Scan(); i++ {
+			fields, fixes := writer.FixLine(*m, s.Text())
+			line := strings.Join(fields, tsdata.Delim)
+			for _, f := range fixes {
+				fmt.Fprintf(c.App.ErrWriter, "%s: line %d: %v\n", path, i, f)
+			}
+			if len(fixes) > 0 {
+				fixed++
+			}
+			if err := spec.ValidateLine(m, line); err != nil {
+				unfixed++
+				fmt.Fprintf(c.App.ErrWriter, "%s: line %d: not fixed, %v\n", path, i, err)
+				line = s.Text()
+			}
+			if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+				return err
+			}
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if out != os.Stdout {
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(c.App.ErrWriter, "%s: fixed %d lines, %d lines still malformed\n", path, fixed, unfixed)
+		return nil
+	},
+}
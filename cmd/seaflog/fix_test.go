@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+func TestFixCommand(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52Z")
+	pmt, err := w.EventText(defs.Event{Name: "PMT1", Value: 1.5, Time: t0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := w.HeaderText()
+	nan := strings.Replace(pmt, "\tNA", "\tNaN", 1)
+	short := "2015-03-14T00:26:52+00:00\t1.5"
+
+	tests := []struct {
+		name    string
+		input   string
+		args    []string
+		outfile string // relative to the test directory, default FILE.fixed
+		want    string
+		wantLog []string
+		wantErr bool
+	}{
+		{
+			"fixes", header + "\n" + nan + "\n" + pmt + "\n", nil, "log.tsv.fixed",
+			header + "\n" + pmt + "\n" + pmt + "\n",
+			[]string{"line 8: ", "fixed 1 lines, 0 lines still malformed"}, false,
+		},
+		{
+			"unfixable", header + "\n" + short + "\n", []string{"--outfile", "out.tsv"}, "out.tsv",
+			header + "\n" + short + "\n",
+			[]string{"line 8: not fixed", "fixed 0 lines, 1 lines still malformed"}, false,
+		},
+		{"bad header", "not a header\n", nil, "", "", nil, true},
+		{"same outfile", header + "\n", []string{"--outfile", "log.tsv"}, "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "log.tsv")
+			if err := ioutil.WriteFile(path, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			args := []string{"seaflog", "fix"}
+			for _, a := range tt.args {
+				if strings.HasSuffix(a, ".tsv") {
+					a = filepath.Join(dir, a)
+				}
+				args = append(args, a)
+			}
+			var errOut bytes.Buffer
+			app := &cli.App{ErrWriter: &errOut, Commands: []*cli.Command{fixCommand}}
+			err := app.Run(append(args, path))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fix error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			b, err := ioutil.ReadFile(filepath.Join(dir, tt.outfile))
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v; want nil", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("fixed file %q; want %q", string(b), tt.want)
+			}
+			for _, want := range tt.wantLog {
+				if !strings.Contains(errOut.String(), want) {
+					t.Errorf("fix report %q; want %q", errOut.String(), want)
+				}
+			}
+		})
+	}
+}
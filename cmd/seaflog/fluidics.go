@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var fluidicsCommand = &cli.Command{
+	Name:  "fluidics",
+	Usage: "report syringe pump injections, injected volume, pump voltage settings, and fluidics faults per time bucket, for maintenance scheduling",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.DurationFlag{
+			Name:  "bucket",
+			Usage: "length of each time bucket",
+			Value: time.Hour,
+		},
+		&cli.StringFlag{
+			Name:  "injection-volume",
+			Usage: "volume of one syringe pump injection in mL, for injected volumes, which the log doesn't record",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Duration("bucket") <= 0 {
+			return fmt.Errorf("--bucket must be positive")
+		}
+		volume := math.NaN()
+		if text := c.String("injection-volume"); text != "" {
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+				return fmt.Errorf("--injection-volume %q is not a volume", text)
+			}
+			volume = v
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		fluidics := pipeline.NewFluidics(c.Duration("bucket"), volume)
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			fluidics.Add(es.Event())
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+
+		format := func(v float64) string {
+			if math.IsNaN(v) {
+				return "NA"
+			}
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		var total pipeline.FluidicsBucket
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "time\tinjections\tvolume_ml\tpump_voltage\tpump_voltage_changes\tfaults")
+		for _, b := range fluidics.Buckets() {
+			total.Injections += b.Injections
+			total.PumpVoltageChanges += b.PumpVoltageChanges
+			total.Faults += b.Faults
+			fmt.Fprintf(
+				tw, "%s\t%s\t%s\t%s\t%d\t%d\n",
+				writer.FormatTime(b.Start, writer.TimeFormatRFC3339), format(b.Injections), format(b.Volume),
+				format(b.PumpVoltage), b.PumpVoltageChanges, b.Faults,
+			)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(
+			c.App.Writer, "\ninjections: %s\nvolume: %s mL\npump voltage changes: %d\nfaults: %d\n",
+			format(total.Injections), format(total.Injections*volume), total.PumpVoltageChanges, total.Faults,
+		)
+		return err
+	},
+}
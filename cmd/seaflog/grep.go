@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var grepCommand = &cli.Command{
+	Name:  "grep",
+	Usage: "print event lines matching a pattern with their resolved time, event name, and value",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "pattern",
+			Usage:    "Go regular expression to match against raw event lines",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "ignore-case",
+			Usage: "match case-insensitively",
+		},
+		&cli.StringFlag{
+			Name:  "earliest",
+			Usage: "RFC3339 timestamp of earliest event to search",
+		},
+		&cli.StringFlag{
+			Name:  "latest",
+			Usage: "RFC3339 timestamp of latest event to search",
+		},
+		&cli.StringFlag{
+			Name:  "time-format",
+			Usage: "time format: rfc3339, rfc3339nano, epoch, epochms, or a custom Go time layout",
+			Value: writer.TimeFormatRFC3339,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		pattern := c.String("pattern")
+		if c.Bool("ignore-case") {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		if err := writer.ValidateTimeFormat(c.String("time-format")); err != nil {
+			return err
+		}
+		var earliest, latest time.Time
+		if c.String("earliest") != "" {
+			if earliest, err = time.Parse(time.RFC3339, c.String("earliest")); err != nil {
+				return fmt.Errorf("error parsing timestamp for --earliest: %v", err)
+			}
+		}
+		if c.String("latest") != "" {
+			if latest, err = time.Parse(time.RFC3339, c.String("latest")); err != nil {
+				return fmt.Errorf("error parsing timestamp for --latest: %v", err)
+			}
+		}
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		skipped := 0
+		var header []string
+		if !earliest.IsZero() && r != os.Stdin {
+			if skipped, header, err = seekEarliest(r, earliest); err != nil {
+				return err
+			}
+		}
+
+		w := bufio.NewWriter(c.App.Writer)
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		es.LineOffset(skipped)
+		es.Header(header)
+		for es.Scan() {
+			event := es.Event()
+			if !re.MatchString(event.Line) || !pipeline.TimeFilter(event, earliest, latest) {
+				continue
+			}
+			t, name, value := "NA", "NA", "NA"
+			if !event.Time.IsZero() {
+				t = writer.FormatTime(event.Time, c.String("time-format"))
+			}
+			if event.Name != "" {
+				name = event.Name
+			}
+			if event.Value != nil {
+				value = fmt.Sprintf("%v", event.Value)
+			}
+			if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", event.LineNumber, t, name, value, event.Line); err != nil {
+				return err
+			}
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+		return w.Flush()
+	},
+}
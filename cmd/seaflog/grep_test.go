@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestGrep(t *testing.T) {
+	logfile := "../../seaflogtest/testdata/corpus/example_tsdata/input.log"
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			"ignore case",
+			[]string{"--pattern", "PUMP", "--ignore-case"},
+			"14\t2015-03-14T01:30:00+00:00\tsyringe_pump_injection\t1\tSyringe pump injection:1\n" +
+				"15\t2015-03-14T01:30:00+00:00\tpump_fault\tPump over 25 psi, check setting or nozzle clog, 01:30:00,03142015\t" +
+				"Pump over 25 psi, check setting or nozzle clog, 01:30:00,03142015\n",
+		},
+		{
+			"untimed and time format",
+			[]string{"--pattern", "Serial|evt", "--time-format", "epoch"},
+			"1\tNA\tNA\tNA\tInstrument Serial: 740\n" +
+				"9\t1426293000\twrite_evt\t1\twrite evt: 1\n" +
+				"16\t1426296600\twrite_evt\t0\twrite evt: 0\n",
+		},
+		{
+			"earliest",
+			[]string{"--pattern", "evt", "--earliest", "2015-03-14T01:00:00Z"},
+			"16\t2015-03-14T01:30:00+00:00\twrite_evt\t0\twrite evt: 0\n",
+		},
+		{"no match", []string{"--pattern", "PUMP"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			app := &cli.App{Writer: &out, Commands: []*cli.Command{grepCommand}}
+			args := append([]string{"seaflog", "grep", "--logfile", logfile}, tt.args...)
+			if err := app.Run(args); err != nil {
+				t.Fatalf("seaflog grep error = %v; want nil", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("seaflog grep wrote\n%q\nwant\n%q", out.String(), tt.want)
+			}
+		})
+	}
+
+	app := &cli.App{Writer: &bytes.Buffer{}, Commands: []*cli.Command{grepCommand}}
+	if err := app.Run([]string{"seaflog", "grep", "--logfile", logfile, "--pattern", "("}); err == nil {
+		t.Errorf("seaflog grep with an invalid pattern error = nil; want an error")
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/urfave/cli/v2"
+)
+
+var indexCommand = &cli.Command{
+	Name:  "index",
+	Usage: "write a sidecar index of timestamp positions, used to seek to --earliest",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file",
+			Required: true,
+		},
+		&cli.Int64Flag{
+			Name:  "spacing",
+			Usage: "approximate bytes between index checkpoints",
+			Value: 1 << 20,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		r, err := os.Open(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		idx, err := scanner.BuildIndex(bufio.NewReader(r), c.Int64("spacing"))
+		if err != nil {
+			return err
+		}
+
+		w, err := os.Create(indexPath(c.String("logfile")))
+		if err != nil {
+			return err
+		}
+		if _, err := idx.WriteTo(w); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	},
+}
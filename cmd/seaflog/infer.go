@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// inferredFileType is the file type used when inferring metadata from paths.
+const inferredFileType = "SeaFlowV1InstrumentLog"
+
+// logNameExpr matches SeaFlow log file names, e.g. SFlog_740.txt
+var logNameExpr = regexp.MustCompile(`^SFlog_(\d+)\.txt$`)
+
+// genericDirs are directory names which hold logs but don't name a cruise.
+var genericDirs = map[string]bool{"log": true, "logs": true, "sflog": true, "sflogs": true}
+
+// projectFromPath infers a cruise ID from a SeaFlow log file path, following
+// the convention of keeping logs in a directory named for the cruise, e.g.
+// HOT227/SFlog_740.txt or KM1906_740/logs/SFlog_740.txt. A trailing
+// instrument serial matching the log file name is removed.
+func projectFromPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(abs)
+	for genericDirs[strings.ToLower(filepath.Base(dir))] {
+		dir = filepath.Dir(dir)
+	}
+	project := filepath.Base(dir)
+	if m := logNameExpr.FindStringSubmatch(filepath.Base(abs)); m != nil {
+		project = strings.TrimSuffix(project, "_"+m[1])
+	}
+	if project == "" || project == "." || project == string(filepath.Separator) {
+		return "", fmt.Errorf("can't infer project from path %q, set --project", path)
+	}
+	return strings.ReplaceAll(project, " ", "_"), nil
+}
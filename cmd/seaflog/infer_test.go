@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectFromPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"/data/HOT227/SFlog_740.txt", "HOT227", false},
+		{"/data/KM1906_740/logs/SFlog_740.txt", "KM1906", false},
+		{"/data/KM1906_751/SFlog_740.txt", "KM1906_751", false},
+		{"/data/KM1906/Logs/sflog/SFlog_740.txt", "KM1906", false},
+		{"/data/Thompson 2019/SFlog_740.txt", "Thompson_2019", false},
+		{"/data/HOT227/notes.txt", "HOT227", false},
+		{"/SFlog_740.txt", "", true},
+		{"/logs/SFlog_740.txt", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := projectFromPath(filepath.FromSlash(tt.path))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("projectFromPath() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("projectFromPath() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
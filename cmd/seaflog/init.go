@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var initCommand = &cli.Command{
+	Name:  "init",
+	Usage: "interactively choose conversion options, then print the equivalent command line and environment file",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "save",
+			Usage: "also save the options as an environment file of SEAFLOG_ variables, e.g. for docker run --env-file",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		r := c.App.Reader
+		if r == nil {
+			r = os.Stdin
+		}
+		opts, err := runWizard(r, c.App.Writer)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.App.Writer, "\nCommand line:\n\n%s\n\nEnvironment file:\n\n%s", commandLine(opts), envFile(opts))
+		if path := c.String("save"); path != "" {
+			if err := ioutil.WriteFile(path, []byte(envFile(opts)), 0644); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.App.Writer, "\nSaved to %s, convert with\n\n  docker run --env-file %s ... seaflog\n", path, path)
+		}
+		return nil
+	},
+}
+
+// wizardOption is one flag chosen in seaflog init.
+type wizardOption struct {
+	flag    string
+	value   string
+	boolean bool
+}
+
+// prompter asks questions on w and reads answers from r.
+type prompter struct {
+	s *bufio.Scanner
+	w io.Writer
+}
+
+// ask prints question and returns the answer, or def for an empty answer.
+// The answer is asked for again until check, if not nil, returns nil.
+func (p prompter) ask(question string, def string, check func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.w, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.w, "%s: ", question)
+		}
+		if !p.s.Scan() {
+			if err := p.s.Err(); err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("input ended before all questions were answered")
+		}
+		answer := strings.TrimSpace(p.s.Text())
+		if answer == "" {
+			answer = def
+		}
+		if check == nil {
+			return answer, nil
+		}
+		err := check(answer)
+		if err == nil {
+			return answer, nil
+		}
+		fmt.Fprintf(p.w, "  %v\n", err)
+	}
+}
+
+// yes asks a yes or no question, defaulting to no.
+func (p prompter) yes(question string) (bool, error) {
+	answer, err := p.ask(question+" (y/n)", "n", func(a string) error {
+		switch strings.ToLower(a) {
+		case "y", "yes", "n", "no":
+			return nil
+		}
+		return fmt.Errorf("answer y or n")
+	})
+	return strings.HasPrefix(strings.ToLower(answer), "y"), err
+}
+
+// identifierExpr matches TSDATA identifiers, which must not be empty or
+// contain spaces.
+var identifierExpr = regexp.MustCompile(`^\S+$`)
+
+func checkIdentifier(a string) error {
+	if !identifierExpr.MatchString(a) {
+		return fmt.Errorf("must not be empty or contain spaces")
+	}
+	return nil
+}
+
+func checkRequired(a string) error {
+	if a == "" {
+		return fmt.Errorf("required")
+	}
+	return nil
+}
+
+// runWizard asks for the common conversion options and returns the flags
+// chosen, in order.
+func runWizard(r io.Reader, w io.Writer) ([]wizardOption, error) {
+	p := prompter{s: bufio.NewScanner(r), w: w}
+	fmt.Fprintln(w, "Answer each question, or press enter for the default in brackets.")
+	opts := []wizardOption{}
+	add := func(flag, value string) {
+		if value != "" {
+			opts = append(opts, wizardOption{flag: flag, value: value})
+		}
+	}
+
+	logfile, err := p.ask("SeaFlow log file, or ARCHIVE::PATH inside an archive", "", checkRequired)
+	if err != nil {
+		return nil, err
+	}
+	filetype, err := p.ask("File type", inferredFileType, checkIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	path := logfile
+	if i := strings.Index(logfile, archiveSep); i >= 0 {
+		path = logfile[i+len(archiveSep):]
+	}
+	project, _ := projectFromPath(path)
+	if project, err = p.ask("Project, usually the cruise ID", project, checkIdentifier); err != nil {
+		return nil, err
+	}
+	description, err := p.ask("Description (optional)", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	format, err := p.ask("Output format, tsdata or csv", "tsdata", func(a string) error {
+		if a != "tsdata" && a != "csv" {
+			return fmt.Errorf("answer tsdata or csv")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ext := ".tsv"
+	if format == "csv" {
+		ext = ".csv"
+	}
+	outfile, err := p.ask("Output file, may use {project} and {basename}", "{project}_{basename}"+ext, checkRequired)
+	if err != nil {
+		return nil, err
+	}
+	question, validate := "Time format: rfc3339 or rfc3339nano", writer.ValidateTsdataTimeFormat
+	if format == "csv" {
+		question, validate = "Time format: rfc3339, rfc3339nano, epoch, epochms, or a Go layout", writer.ValidateTimeFormat
+	}
+	timeFormat, err := p.ask(question, writer.TimeFormatRFC3339, validate)
+	if err != nil {
+		return nil, err
+	}
+
+	add("filetype", filetype)
+	add("project", project)
+	add("description", description)
+	add("logfile", logfile)
+	add("outfile", outfile)
+	if format != "tsdata" {
+		add("format", format)
+	}
+	if timeFormat != writer.TimeFormatRFC3339 {
+		add("time-format", timeFormat)
+	}
+
+	for _, q := range []struct{ question, flag string }{
+		{"Add delta and cumulative columns for counters like syringe pump injections?", "counters"},
+		{"Add source file and line number columns?", "provenance"},
+	} {
+		ok, err := p.yes(q.question)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			opts = append(opts, wizardOption{flag: q.flag, value: "true", boolean: true})
+		}
+	}
+	summary, err := p.ask("JSON run summary file (optional)", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	add("summary", summary)
+	return opts, nil
+}
+
+// shellQuote quotes s for a POSIX shell if needed.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,/:+=@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// commandLine returns the seaflog command line for opts.
+func commandLine(opts []wizardOption) string {
+	parts := []string{cmdname}
+	for _, o := range opts {
+		if o.boolean {
+			parts = append(parts, "--"+o.flag)
+		} else {
+			parts = append(parts, "--"+o.flag+" "+shellQuote(o.value))
+		}
+	}
+	return strings.Join(parts, " \\\n    ")
+}
+
+// envFile returns an environment file setting the SEAFLOG_ variable of each
+// option.
+func envFile(opts []wizardOption) string {
+	b := &strings.Builder{}
+	for _, o := range opts {
+		fmt.Fprintf(b, "SEAFLOG_%s=%s\n", strings.ToUpper(strings.ReplaceAll(o.flag, "-", "_")), o.value)
+	}
+	return b.String()
+}
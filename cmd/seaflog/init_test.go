@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunWizard(t *testing.T) {
+	tests := []struct {
+		name     string
+		answers  []string
+		wantCmd  string
+		wantEnv  string
+		wantAsks int // answers rejected and asked for again
+		wantErr  bool
+	}{
+		{
+			"defaults",
+			[]string{"HOT227/SFlog_740.txt", "", "", "", "", "", "", "", "", ""},
+			"seaflog \\\n    --filetype SeaFlowV1InstrumentLog \\\n    --project HOT227 \\\n" +
+				"    --logfile HOT227/SFlog_740.txt \\\n    --outfile '{project}_{basename}.tsv'",
+			"SEAFLOG_FILETYPE=SeaFlowV1InstrumentLog\nSEAFLOG_PROJECT=HOT227\n" +
+				"SEAFLOG_LOGFILE=HOT227/SFlog_740.txt\nSEAFLOG_OUTFILE={project}_{basename}.tsv\n",
+			0, false,
+		},
+		{
+			"csv archive",
+			[]string{
+				"cruise.zip::KM1906/logs/SFlog_740.txt", "", "", "Kilo Moana's cruise", "csv", "", "epochms",
+				"y", "no", "summary.json",
+			},
+			"seaflog \\\n    --filetype SeaFlowV1InstrumentLog \\\n    --project KM1906 \\\n" +
+				"    --description 'Kilo Moana'\\''s cruise' \\\n    --logfile cruise.zip::KM1906/logs/SFlog_740.txt \\\n" +
+				"    --outfile '{project}_{basename}.csv' \\\n    --format csv \\\n    --time-format epochms \\\n" +
+				"    --counters \\\n    --summary summary.json",
+			"SEAFLOG_FILETYPE=SeaFlowV1InstrumentLog\nSEAFLOG_PROJECT=KM1906\nSEAFLOG_DESCRIPTION=Kilo Moana's cruise\n" +
+				"SEAFLOG_LOGFILE=cruise.zip::KM1906/logs/SFlog_740.txt\nSEAFLOG_OUTFILE={project}_{basename}.csv\n" +
+				"SEAFLOG_FORMAT=csv\nSEAFLOG_TIME_FORMAT=epochms\nSEAFLOG_COUNTERS=true\nSEAFLOG_SUMMARY=summary.json\n",
+			0, false,
+		},
+		{
+			"retries",
+			[]string{
+				"", "SFlog_740.txt", "bad type", "", "HOT 227", "HOT227", "", "xml", "", "", "epoch", "",
+				"maybe", "", "", "",
+			},
+			"seaflog \\\n    --filetype SeaFlowV1InstrumentLog \\\n    --project HOT227 \\\n" +
+				"    --logfile SFlog_740.txt \\\n    --outfile '{project}_{basename}.tsv'",
+			"SEAFLOG_FILETYPE=SeaFlowV1InstrumentLog\nSEAFLOG_PROJECT=HOT227\n" +
+				"SEAFLOG_LOGFILE=SFlog_740.txt\nSEAFLOG_OUTFILE={project}_{basename}.tsv\n",
+			6, false,
+		},
+		{"input ends", []string{"HOT227/SFlog_740.txt", ""}, "", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := runWizard(strings.NewReader(strings.Join(tt.answers, "\n")+"\n"), &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runWizard() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := commandLine(opts); got != tt.wantCmd {
+				t.Errorf("commandLine() = %q; want %q", got, tt.wantCmd)
+			}
+			if got := envFile(opts); got != tt.wantEnv {
+				t.Errorf("envFile() = %q; want %q", got, tt.wantEnv)
+			}
+			if got := strings.Count(out.String(), ":   "); got != tt.wantAsks {
+				t.Errorf("%d answers asked again; want %d, output %q", got, tt.wantAsks, out.String())
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"HOT227", "HOT227"},
+		{"/data/SFlog_740.txt", "/data/SFlog_740.txt"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"{project}.tsv", "'{project}.tsv'"},
+		{"it's", `'it'\''s'`},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.s); got != tt.want {
+			t.Errorf("shellQuote(%q) = %q; want %q", tt.s, got, tt.want)
+		}
+	}
+}
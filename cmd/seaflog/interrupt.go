@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// exitInterrupted is the exit status after an interrupted conversion,
+// following the shell convention of 128 + SIGINT.
+const exitInterrupted = 130
+
+// stopPoll is how often inputs waiting for data check for a stop.
+const stopPoll = 200 * time.Millisecond
+
+// stopper is an input which can be stopped while a read waits for data, e.g.
+// a named pipe waiting for its next writer or a quiet serial console.
+type stopper interface {
+	// stopOn makes reads end with io.EOF once stop receives.
+	stopOn(stop <-chan os.Signal)
+}
+
+// interrupts returns a channel which receives the first SIGINT or SIGTERM and
+// is then closed, so any number of receivers see it. Later signals get the
+// default handling, so a second interrupt terminates immediately, e.g. when
+// blocked reading STDIN.
+func interrupts() <-chan os.Signal {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	first := make(chan os.Signal, 1)
+	go func() {
+		s := <-sig
+		signal.Stop(sig)
+		first <- s
+		close(first)
+	}()
+	return first
+}
+
+// writePartial records the point at which conversion was interrupted in a
+// path.partial file next to output path. Nothing is written for STDOUT.
+func writePartial(path string, line int, t time.Time) error {
+	if path == "-" {
+		return nil
+	}
+	f, err := os.Create(path + ".partial")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "interrupted\nlast_line\t%d\nlast_time\t%s\n", line, t.Format(time.RFC3339Nano))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// clearPartial removes any path.partial file left by an earlier interrupted
+// run.
+func clearPartial(path string) error {
+	if path == "-" {
+		return nil
+	}
+	if err := os.Remove(path + ".partial"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
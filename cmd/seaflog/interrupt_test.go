@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPartial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.tsdata")
+	when := time.Date(2015, 3, 14, 1, 2, 3, 500000000, time.UTC)
+	if err := writePartial(path, 42, when); err != nil {
+		t.Fatalf("writePartial() error = %v; want nil", err)
+	}
+	b, err := ioutil.ReadFile(path + ".partial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "interrupted\nlast_line\t42\nlast_time\t2015-03-14T01:02:03.5Z\n"
+	if string(b) != want {
+		t.Errorf(".partial %q; want %q", b, want)
+	}
+
+	if err := clearPartial(path); err != nil {
+		t.Fatalf("clearPartial() error = %v; want nil", err)
+	}
+	if _, err := os.Stat(path + ".partial"); !os.IsNotExist(err) {
+		t.Errorf(".partial left after clearPartial(), %v", err)
+	}
+	// Nothing to clear is fine
+	if err := clearPartial(path); err != nil {
+		t.Errorf("clearPartial() again error = %v; want nil", err)
+	}
+
+	// Nothing is written next to STDOUT
+	if err := writePartial("-", 42, when); err != nil {
+		t.Errorf("writePartial(\"-\") error = %v; want nil", err)
+	}
+	if _, err := os.Stat("-.partial"); !os.IsNotExist(err) {
+		t.Errorf("writePartial(\"-\") wrote -.partial")
+	}
+}
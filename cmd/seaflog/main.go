@@ -2,64 +2,451 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/seaflow-uw/seaflog"
+	"github.com/seaflow-uw/seaflog/v2"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
 	"github.com/urfave/cli/v2"
 )
 
 var cmdname string = "seaflog"
 
-func main() {
-	app := &cli.App{
+// newApp returns the seaflog command line app.
+func newApp() *cli.App {
+	return &cli.App{
 		Name:      cmdname,
 		Version:   seaflog.Version,
 		Usage:     "convert a SeaFlow v1 log file to TSDATA format\n              https://github.com/armbrustlab/tsdataformat",
-		UsageText: "seaflog [global options]",
+		UsageText: "seaflog [global options]\n   seaflog command [command options] [arguments...]",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "filetype",
-				Usage:    "identifier for this file type, no spaces (required)",
-				Required: true,
+				Name:    "profile",
+				EnvVars: []string{"SEAFLOG_PROFILE"},
+				Usage:   "named bundle of conversion flags: realtime, archive, qc, or one from --profiles. Flags given explicitly take precedence",
+			},
+			&cli.StringFlag{
+				Name:    "profiles",
+				EnvVars: []string{"SEAFLOG_PROFILES"},
+				Usage:   "JSON file of named profiles, each an object of flag values by flag name, adding to or replacing the built-in profiles",
+			},
+			&cli.StringFlag{
+				Name:    "filetype",
+				EnvVars: []string{"SEAFLOG_FILETYPE"},
+				Usage:   "identifier for this file type, no spaces (required)",
+			},
+			&cli.StringFlag{
+				Name:    "project",
+				EnvVars: []string{"SEAFLOG_PROJECT"},
+				Usage:   "identifier for this project, no spaces (required)",
+			},
+			&cli.StringFlag{
+				Name:    "description",
+				EnvVars: []string{"SEAFLOG_DESCRIPTION"},
+				Usage:   "long form file description",
+			},
+			&cli.BoolFlag{
+				Name:    "infer",
+				EnvVars: []string{"SEAFLOG_INFER"},
+				Usage:   "infer --filetype and --project (cruise ID) from the logfile path when they aren't set",
 			},
 			&cli.StringFlag{
-				Name:     "project",
-				Usage:    "identifier for this project, no spaces (required)",
-				Required: true,
+				Name:    "earliest",
+				EnvVars: []string{"SEAFLOG_EARLIEST"},
+				Usage:   "RFC3339 timestamp of earliest event to output",
 			},
 			&cli.StringFlag{
-				Name:  "description",
-				Usage: "long form file description",
+				Name:    "latest",
+				EnvVars: []string{"SEAFLOG_LATEST"},
+				Usage:   "RFC3339 timestamp of latest event to output",
 			},
 			&cli.StringFlag{
-				Name:  "earliest",
-				Usage: "RFC3339 timestamp of earliest event to output",
+				Name:    "logfile",
+				EnvVars: []string{"SEAFLOG_LOGFILE"},
+				Usage:   "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside a zip, tar, or tar.gz archive, or sftp://[USER@]HOST[:PORT]/PATH for a remote file copied with ssh (required)",
 			},
 			&cli.StringFlag{
-				Name:  "latest",
-				Usage: "RFC3339 timestamp of latest event to output",
+				Name:    "serial",
+				EnvVars: []string{"SEAFLOG_SERIAL"},
+				Usage:   "read the instrument console from a serial port, DEVICE:BAUD, e.g. /dev/ttyUSB0:9600, appending everything read to --logfile as a raw capture",
+			},
+			&cli.BoolFlag{
+				Name:    "reopen",
+				EnvVars: []string{"SEAFLOG_REOPEN"},
+				Usage:   "with a named pipe --logfile, wait at EOF for the next writer instead of stopping, e.g. for mkfifo pipelines",
 			},
 			&cli.StringFlag{
-				Name:     "logfile",
-				Usage:    "SeaFLow v1 instrument log file, '-' for STDIN (required)",
-				Required: true,
+				Name:    "outfile",
+				EnvVars: []string{"SEAFLOG_OUTFILE"},
+				Usage:   "output text file for logfile events in --format, '-' for STDOUT, may contain {project}, {filetype}, {basename}, and {date} placeholders (required unless another output is set)",
 			},
 			&cli.StringFlag{
-				Name:     "outfile",
-				Usage:    "output text file for logfile events in TSDATA format, '-' for STDOUT (required)",
-				Required: true,
+				Name:    "tsdata-out",
+				EnvVars: []string{"SEAFLOG_TSDATA_OUT"},
+				Usage:   "additional output file in tsdata format, with the same placeholders as --outfile",
+			},
+			&cli.StringFlag{
+				Name:    "csv-out",
+				EnvVars: []string{"SEAFLOG_CSV_OUT"},
+				Usage:   "additional output file in csv format, with the same placeholders as --outfile",
+			},
+			&cli.StringFlag{
+				Name:    "intervals-out",
+				EnvVars: []string{"SEAFLOG_INTERVALS_OUT"},
+				Usage:   "additional output file in intervals format, with the same placeholders as --outfile",
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				EnvVars: []string{"SEAFLOG_FORMAT"},
+				Usage:   "output format: tsdata for events, csv for events without TSDATA metadata, intervals for paired start/stop event intervals",
+				Value:   "tsdata",
+			},
+			&cli.StringSliceFlag{
+				Name:    "note",
+				EnvVars: []string{"SEAFLOG_NOTE"},
+				Usage:   "add a note event with this text to event outputs, e.g. to record processing context, may be repeated",
+			},
+			&cli.StringSliceFlag{
+				Name:    "note-time",
+				EnvVars: []string{"SEAFLOG_NOTE_TIME"},
+				Usage:   "RFC3339 timestamp of the --note at the same position, notes without one get the time of the first event",
+			},
+			&cli.StringFlag{
+				Name:    "raw-out",
+				EnvVars: []string{"SEAFLOG_RAW_OUT"},
+				Usage:   "also write the original log lines, with their timestamp lines, of events passing --earliest and --latest",
+			},
+			&cli.StringFlag{
+				Name:    "tsdata-version",
+				EnvVars: []string{"SEAFLOG_TSDATA_VERSION"},
+				Usage:   "TSDATA spec version to write tsdata and intervals outputs for",
+				Value:   writer.DefaultTsdataVersion,
+			},
+			&cli.StringFlag{
+				Name:    "na",
+				EnvVars: []string{"SEAFLOG_NA"},
+				Usage:   "missing value token for csv output, e.g. NaN or NULL",
+			},
+			&cli.StringFlag{
+				Name:    "time-format",
+				EnvVars: []string{"SEAFLOG_TIME_FORMAT"},
+				Usage:   "output time format: rfc3339, rfc3339nano, or for csv output only, epoch, epochms, or a custom Go time layout",
+				Value:   writer.TimeFormatRFC3339,
+			},
+			&cli.StringSliceFlag{
+				Name:    "float-format",
+				EnvVars: []string{"SEAFLOG_FLOAT_FORMAT"},
+				Usage:   "fmt format for float values, e.g. %.4g, or EVENT=FORMAT for one event, may be repeated",
+			},
+			&cli.StringFlag{
+				Name:    "units",
+				EnvVars: []string{"SEAFLOG_UNITS"},
+				Usage:   "convert float values to a unit system, si, us, or a comma-separated list of units, e.g. mV,degF,uL/min",
+			},
+			&cli.BoolFlag{
+				Name:    "counters",
+				EnvVars: []string{"SEAFLOG_COUNTERS"},
+				Usage:   "add delta and cumulative columns for counter events, correcting for rollovers and resets",
+			},
+			&cli.StringSliceFlag{
+				Name:    "computed",
+				EnvVars: []string{"SEAFLOG_COMPUTED"},
+				Usage:   "add a column computed from the latest float event values, NAME=EXPR, e.g. pmt_ratio=PMT1/PMT2 or pmt1_mean=mean(PMT1,10m), may be repeated",
 			},
 			&cli.BoolFlag{
-				Name:  "quiet",
-				Usage: "don't report parsing errors",
+				Name:    "drop-empty-columns",
+				EnvVars: []string{"SEAFLOG_DROP_EMPTY_COLUMNS"},
+				Usage:   "leave out columns of events which never occur between --earliest and --latest, by reading the log file twice, for readable outputs of short time ranges",
+			},
+			&cli.BoolFlag{
+				Name:    "qc",
+				EnvVars: []string{"SEAFLOG_QC"},
+				Usage:   "add <event>_qc columns of QARTOD flags for events with quality control rules in their definitions",
+			},
+			&cli.BoolFlag{
+				Name:    "seq",
+				EnvVars: []string{"SEAFLOG_SEQ"},
+				Usage:   "add a seq column of event sequence numbers in log order",
+			},
+			&cli.StringFlag{
+				Name:    "instrument",
+				EnvVars: []string{"SEAFLOG_INSTRUMENT"},
+				Usage:   "add an instrument column with this instrument ID, e.g. SN740, or 'auto' to use the logged instrument serial",
+			},
+			&cli.BoolFlag{
+				Name:    "provenance",
+				EnvVars: []string{"SEAFLOG_PROVENANCE"},
+				Usage:   "add source and line columns with the log file and line number of each event",
+			},
+			&cli.BoolFlag{
+				Name:    "syslog",
+				EnvVars: []string{"SEAFLOG_SYSLOG"},
+				Usage:   "read the log as syslog output, RFC 3164 or RFC 5424, removing the priority, timestamp, host, and tag from each line",
+			},
+			&cli.BoolFlag{
+				Name:    "syslog-time",
+				EnvVars: []string{"SEAFLOG_SYSLOG_TIME"},
+				Usage:   "with --syslog, give events before the first SeaFlow timestamp line the time of their syslog line",
+			},
+			&cli.IntFlag{
+				Name:    "syslog-year",
+				EnvVars: []string{"SEAFLOG_SYSLOG_YEAR"},
+				Usage:   "year of RFC 3164 syslog timestamps, which have none, 0 for the current year. Their times are read as UTC",
+			},
+			&cli.StringSliceFlag{
+				Name:    "strip-prefix",
+				EnvVars: []string{"SEAFLOG_STRIP_PREFIX"},
+				Usage:   "Go regular expression for a prefix to remove from log lines before parsing, or syslog for the prefix added by syslog forwarding, may be repeated",
+			},
+			&cli.StringSliceFlag{
+				Name:    "ignore-pattern",
+				EnvVars: []string{"SEAFLOG_IGNORE_PATTERN"},
+				Usage:   "Go regular expression for log lines to drop silently before parsing, may be repeated",
+			},
+			&cli.BoolFlag{
+				Name:    "discover",
+				EnvVars: []string{"SEAFLOG_DISCOVER"},
+				Usage:   "add columns for unrecognized \"Key: value\" lines, named from their keys, by reading the log file twice, for logs with an unknown schema",
+			},
+			&cli.StringFlag{
+				Name:    "rules",
+				EnvVars: []string{"SEAFLOG_RULES"},
+				Usage:   "JSON file of correlation rules which emit alert events, e.g. when flow stops during acquisition",
+			},
+			&cli.StringSliceFlag{
+				Name:    "plugin",
+				EnvVars: []string{"SEAFLOG_PLUGIN"},
+				Usage:   "external filter or sink program, with space separated arguments, to pass events through before output, may be repeated to chain plugins",
+			},
+			&cli.BoolFlag{
+				Name:    "normalize-time",
+				EnvVars: []string{"SEAFLOG_NORMALIZE_TIME"},
+				Usage:   "shift event times to correct backward timestamp jumps and mislabeled UTC offsets",
+			},
+			&cli.IntFlag{
+				Name:    "max-stale-lines",
+				EnvVars: []string{"SEAFLOG_MAX_STALE_LINES"},
+				Usage:   "report runs of events more than this many lines after the last timestamp line, 0 to turn off",
+			},
+			&cli.StringFlag{
+				Name:    "interpolate-time",
+				EnvVars: []string{"SEAFLOG_INTERPOLATE_TIME"},
+				Usage:   "spread events between timestamp lines: off, even, or a Go duration step to add per line, e.g. 1ms",
+				Value:   scanner.InterpolateOff,
+			},
+			&cli.StringFlag{
+				Name:    "untimed",
+				EnvVars: []string{"SEAFLOG_UNTIMED"},
+				Usage:   "policy for events before the first timestamp: error, drop, backdate to the first timestamp, or an RFC3339 timestamp to use as their time",
+				Value:   scanner.UntimedError,
+			},
+			&cli.BoolFlag{
+				Name:    "file-markers",
+				EnvVars: []string{"SEAFLOG_FILE_MARKERS"},
+				Usage:   "read \"#FILE: NAME\" lines as the start of another log file NAME, for concatenated logs on STDIN, e.g. from a tar pipeline. Events get the source and line number within their own file",
+			},
+			&cli.StringFlag{
+				Name:    "special-floats",
+				EnvVars: []string{"SEAFLOG_SPECIAL_FLOATS"},
+				Usage:   "policy for infinite and NaN float values, e.g. \"inf\" or \"nan\": pass, drop, or error",
+				Value:   scanner.SpecialFloatPass,
+			},
+			&cli.StringSliceFlag{
+				Name:    "encrypt-recipient",
+				EnvVars: []string{"SEAFLOG_ENCRYPT_RECIPIENT"},
+				Usage:   "encrypt output files for this recipient with age, for an age or SSH public key, or gpg, for a PGP key ID or email address, may be repeated",
+			},
+			&cli.StringFlag{
+				Name:    "summary",
+				EnvVars: []string{"SEAFLOG_SUMMARY"},
+				Usage:   "write a JSON run summary of rows written, errors, time range, and duration to this file, '-' for STDOUT, or fd:N for an open file descriptor",
+			},
+			&cli.StringFlag{
+				Name:    "column-stats",
+				EnvVars: []string{"SEAFLOG_COLUMN_STATS"},
+				Usage:   "write JSON count, min, max, and last value of each event column to this sidecar file, '-' for STDOUT, or fd:N for an open file descriptor",
+			},
+			&cli.BoolFlag{
+				Name:    "checksum",
+				EnvVars: []string{"SEAFLOG_CHECKSUM"},
+				Usage:   "record the SHA-256 of each output in the --summary file, to check later with seaflog verify",
+			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				EnvVars: []string{"SEAFLOG_LOG_FORMAT"},
+				Usage:   "format of messages on STDERR: text, or json for one JSON object per line with level, file, line, error_kind, message, and text fields",
+				Value:   seaflog.LogFormatText,
+			},
+			&cli.StringFlag{
+				Name:    "verbosity",
+				EnvVars: []string{"SEAFLOG_VERBOSITY"},
+				Usage:   "least severe messages to report: error, warning, info, or quiet for none",
+				Value:   seaflog.LevelWarning,
+			},
+			&cli.StringSliceFlag{
+				Name:    "suppress",
+				EnvVars: []string{"SEAFLOG_SUPPRESS"},
+				Usage:   "don't report messages of this kind, e.g. unrecognized, bad_float, no_timestamp, time_anomaly, or counter_reset, may be repeated",
+			},
+			&cli.IntFlag{
+				Name:    "max-repeats",
+				EnvVars: []string{"SEAFLOG_MAX_REPEATS"},
+				Usage:   "report each identical message at most this many times, then summarize the rest as a count, 0 for no limit",
+			},
+			&cli.DurationFlag{
+				Name:    "repeats-interval",
+				EnvVars: []string{"SEAFLOG_REPEATS_INTERVAL"},
+				Usage:   "how often to report counts of messages suppressed by --max-repeats, in addition to at exit, 0 for only at exit",
+				Value:   time.Minute,
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				EnvVars: []string{"SEAFLOG_QUIET"},
+				Usage:   "don't report parsing errors, same as --verbosity quiet",
 			},
 		},
+		Commands: []*cli.Command{
+			defsCommand,
+			statsCommand,
+			changesCommand,
+			timelineCommand,
+			densityCommand,
+			faultsCommand,
+			sleepCommand,
+			fluidicsCommand,
+			driftCommand,
+			datafilesCommand,
+			fileSettingsCommand,
+			backfillCommand,
+			syncCommand,
+			grepCommand,
+			indexCommand,
+			verifyCommand,
+			validateCommand,
+			fixCommand,
+			reproCommand,
+			docsCommand,
+			initCommand,
+		},
 		Action: func(c *cli.Context) error {
 			var err error
+			start := time.Now()
+
+			if name := c.String("profile"); name != "" {
+				if err := applyProfile(c, name, c.String("profiles")); err != nil {
+					return err
+				}
+			}
+
+			if c.Bool("infer") && c.String("logfile") != "" && c.String("logfile") != "-" {
+				if c.String("filetype") == "" {
+					if err := c.Set("filetype", inferredFileType); err != nil {
+						return err
+					}
+				}
+				if c.String("project") == "" {
+					project, err := projectFromPath(c.String("logfile"))
+					if err != nil {
+						return err
+					}
+					if err := c.Set("project", project); err != nil {
+						return err
+					}
+				}
+			}
+
+			// Global flags are only required when converting, not for commands
+			for _, name := range []string{"filetype", "project", "logfile"} {
+				if c.String(name) == "" {
+					return fmt.Errorf("Required flag %q not set", name)
+				}
+			}
+
+			if c.String("serial") != "" {
+				if c.String("logfile") == "-" || strings.Contains(c.String("logfile"), archiveSep) {
+					return fmt.Errorf("--serial requires a --logfile capture file path")
+				}
+				if c.Bool("discover") || c.Bool("drop-empty-columns") {
+					return fmt.Errorf("--discover and --drop-empty-columns can't be used with --serial")
+				}
+			}
+			if c.Bool("drop-empty-columns") && len(c.StringSlice("plugin")) > 0 {
+				return fmt.Errorf("--drop-empty-columns can't be used with --plugin, which may add events")
+			}
+			if c.Bool("reopen") {
+				if c.String("serial") != "" || c.String("logfile") == "-" || strings.Contains(c.String("logfile"), archiveSep) {
+					return fmt.Errorf("--reopen requires --logfile to be a named pipe")
+				}
+				if c.Bool("discover") || c.Bool("drop-empty-columns") {
+					return fmt.Errorf("--discover and --drop-empty-columns can't be used with --reopen")
+				}
+			}
+
+			// Collect output files by format
+			switch c.String("format") {
+			case "tsdata", "csv", "intervals":
+			default:
+				return fmt.Errorf("unknown output format %q", c.String("format"))
+			}
+			outfiles := [][2]string{} // format, path template
+			if c.String("outfile") != "" {
+				outfiles = append(outfiles, [2]string{c.String("format"), c.String("outfile")})
+			}
+			for _, format := range formats {
+				if c.String(format+"-out") != "" {
+					outfiles = append(outfiles, [2]string{format, c.String(format + "-out")})
+				}
+			}
+			if len(outfiles) == 0 {
+				return fmt.Errorf("Required flag %q not set", "outfile")
+			}
+			if c.String("raw-out") != "" {
+				outfiles = append(outfiles, [2]string{"raw", c.String("raw-out")})
+			}
+			vars := outfileVars(c.String("logfile"), c.String("project"), c.String("filetype"), start)
+			stdout := false
+			for i := range outfiles {
+				if outfiles[i][1], err = expandOutfile(outfiles[i][1], vars); err != nil {
+					return err
+				}
+				if outfiles[i][1] == "-" {
+					if stdout {
+						return fmt.Errorf("only one output may be STDOUT")
+					}
+					stdout = true
+				}
+			}
+			summaryPath := c.String("summary")
+			if c.Bool("checksum") && summaryPath == "" {
+				return fmt.Errorf("--checksum requires --summary")
+			}
+			if summaryPath != "" {
+				if summaryPath, err = expandOutfile(summaryPath, vars); err != nil {
+					return err
+				}
+				if summaryPath == "-" && stdout {
+					return fmt.Errorf("only one output may be STDOUT")
+				}
+			}
+			colStatsPath := c.String("column-stats")
+			if colStatsPath != "" {
+				if colStatsPath, err = expandOutfile(colStatsPath, vars); err != nil {
+					return err
+				}
+				if colStatsPath == "-" && (stdout || summaryPath == "-") {
+					return fmt.Errorf("only one output may be STDOUT")
+				}
+			}
 
 			// Parse any timestamps
 			earliest := time.Time{}
@@ -79,94 +466,487 @@ func main() {
 				}
 			}
 
-			seaflog.Quiet(c.Bool("quiet"))
+			if err := writer.ValidateTimeFormat(c.String("time-format")); err != nil {
+				return err
+			}
+			for _, of := range outfiles {
+				if of[0] != "tsdata" && of[0] != "intervals" {
+					continue
+				}
+				if err := writer.ValidateTsdataTimeFormat(c.String("time-format")); err != nil {
+					return fmt.Errorf("invalid --time-format for %s output, %v, use --format csv for other formats", of[0], err)
+				}
+			}
+			untimed := c.String("untimed")
+			var untimedTime time.Time
+			switch untimed {
+			case scanner.UntimedError, scanner.UntimedDrop, scanner.UntimedBackdate:
+			default:
+				untimedTime, err = time.Parse(time.RFC3339, untimed)
+				if err != nil {
+					return fmt.Errorf("invalid --untimed %q, want error, drop, backdate, or an RFC3339 timestamp", untimed)
+				}
+				untimed = scanner.UntimedDefault
+			}
+			interp := c.String("interpolate-time")
+			var epsilon time.Duration
+			switch interp {
+			case scanner.InterpolateOff, scanner.InterpolateEven:
+			default:
+				epsilon, err = time.ParseDuration(interp)
+				if err != nil {
+					return fmt.Errorf("invalid --interpolate-time %q, want off, even, or a duration", interp)
+				}
+				interp = scanner.InterpolateEpsilon
+			}
+			if len(c.StringSlice("note-time")) > len(c.StringSlice("note")) {
+				return fmt.Errorf("more --note-time than --note flags")
+			}
+			notes := []defs.Event{}
+			for i, text := range c.StringSlice("note") {
+				var t time.Time
+				if i < len(c.StringSlice("note-time")) {
+					if t, err = time.Parse(time.RFC3339, c.StringSlice("note-time")[i]); err != nil {
+						return fmt.Errorf("invalid --note-time %q, want an RFC3339 timestamp", c.StringSlice("note-time")[i])
+					}
+				}
+				notes = append(notes, pipeline.NewNote(text, t))
+			}
+			var rules *pipeline.Rules
+			if path := c.String("rules"); path != "" {
+				b, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				parsed, err := pipeline.ParseRules(b)
+				if err != nil {
+					return fmt.Errorf("invalid --rules %s, %v", path, err)
+				}
+				rules = pipeline.NewRules(parsed)
+			}
+			syslogYear := c.Int("syslog-year")
+			if syslogYear == 0 {
+				syslogYear = time.Now().UTC().Year()
+			}
+			pre := []scanner.LinePreprocessor{}
+			for _, expr := range c.StringSlice("strip-prefix") {
+				if expr == "syslog" {
+					pre = append(pre, scanner.StripPrefix(scanner.SyslogPrefix))
+					continue
+				}
+				re, err := regexp.Compile(expr)
+				if err != nil {
+					return fmt.Errorf("invalid --strip-prefix %q, %v", expr, err)
+				}
+				pre = append(pre, scanner.StripPrefix(re))
+			}
+			// Input options shared by discovery and conversion
+			configureInput := func(es *scanner.EventScanner) {
+				if c.Bool("syslog") {
+					es.SyslogInput(c.Bool("syslog-time"), syslogYear)
+				}
+				es.Preprocess(pre...)
+			}
+			ignore := []*regexp.Regexp{}
+			for _, expr := range c.StringSlice("ignore-pattern") {
+				re, err := regexp.Compile(expr)
+				if err != nil {
+					return fmt.Errorf("invalid --ignore-pattern %q, %v", expr, err)
+				}
+				ignore = append(ignore, re)
+			}
+			// Definitions found by --discover, kept out of
+			// defs.EventDefs
+			var discovered []defs.EventDef
+			// Event parsing options shared by the first pass of
+			// --drop-empty-columns and conversion
+			configureEvents := func(es *scanner.EventScanner) error {
+				es.AddDefs(discovered...)
+				es.NormalizeTime(c.Bool("normalize-time"))
+				es.FileMarkers(c.Bool("file-markers"))
+				configureInput(es)
+				es.IgnoreLines(ignore...)
+				if err := es.UntimedEvents(untimed, untimedTime); err != nil {
+					return err
+				}
+				if err := es.InterpolateTime(interp, epsilon); err != nil {
+					return err
+				}
+				return es.SpecialFloats(c.String("special-floats"))
+			}
+			var units *pipeline.UnitConverter
+			if c.String("units") != "" {
+				if units, err = pipeline.NewUnitConverter(c.String("units")); err != nil {
+					return err
+				}
+			}
+
+			verbosity := c.String("verbosity")
+			if c.Bool("quiet") {
+				verbosity = seaflog.VerbosityQuiet
+			}
+			if err := seaflog.SetVerbosity(verbosity); err != nil {
+				return err
+			}
+			for _, kind := range c.StringSlice("suppress") {
+				seaflog.Suppress(kind, true)
+			}
+			seaflog.LimitRepeats(c.Int("max-repeats"), c.Duration("repeats-interval"))
+			defer seaflog.FlushRepeats()
+			if err := seaflog.SetLogFormat(c.String("log-format")); err != nil {
+				return err
+			}
 
-			// Open files
+			// Open files. Streams, STDIN, named pipes, and serial ports, can
+			// only be read once, from the start.
 			var r *os.File
-			var w *os.File
-			var bufr *bufio.Reader
-			var bufw *bufio.Writer
-			if c.String("logfile") == "-" {
+			var in io.ReadCloser
+			stream := true
+			if c.String("serial") != "" {
+				if in, err = openSerialCapture(c.String("serial"), c.String("logfile")); err != nil {
+					return err
+				}
+			} else if c.Bool("reopen") {
+				if in, err = openReopener(c.String("logfile")); err != nil {
+					return err
+				}
+			} else if c.String("logfile") == "-" {
 				r = os.Stdin
 			} else {
-				r, err = os.Open(c.String("logfile"))
+				r, err = openLogfile(c.String("logfile"))
 				if err != nil {
 					return err
 				}
+				in = r
+				if stream, err = isFIFO(r); err != nil {
+					return err
+				}
+			}
+			if in != nil {
 				defer func() {
-					err := r.Close()
+					err := in.Close()
 					if err != nil {
 						log.Fatal(err)
 					}
 				}()
-				bufr = bufio.NewReader(r)
 			}
-			if c.String("outfile") == "-" {
-				w = os.Stdout
-			} else {
-				if err = os.MkdirAll(filepath.Dir(c.String("outfile")), os.ModePerm); err != nil {
+			// Discovery and dropping empty columns read the input twice,
+			// buffer streams in a file
+			if (c.Bool("discover") || c.Bool("drop-empty-columns")) && stream {
+				if r, err = bufferStream(r); err != nil {
 					return err
 				}
-				w, err = os.Create(c.String("outfile"))
-				if err != nil {
+				defer func() {
+					r.Close()
+					os.Remove(r.Name())
+				}()
+				stream = false
+			}
+			// Skip to the start of the time range in large files, unless
+			// the instrument serial or versions from banner lines
+			// anywhere in the file are needed, or time corrections and
+			// file markers from lines before the time range
+			skipped := 0
+			var header []string
+			if !earliest.IsZero() && !stream && c.String("instrument") != "auto" && summaryPath == "" &&
+				!c.Bool("normalize-time") && !c.Bool("file-markers") {
+				if skipped, header, err = seekEarliest(r, earliest); err != nil {
 					return err
 				}
-				defer func() {
-					if err := bufw.Flush(); err != nil {
+			}
+			if c.Bool("discover") {
+				if discovered, err = discoverDefs(r, configureInput); err != nil {
+					return err
+				}
+				for _, edef := range discovered {
+					seaflog.Report(seaflog.Message{
+						Level:     seaflog.LevelInfo,
+						ErrorKind: "discovered",
+						Message: fmt.Sprintf(
+							"Discovered %s event %s from lines starting %q", edef.Type, edef.Name, edef.EventForms[0].StartsWith,
+						),
+					})
+				}
+			}
+			// Find extra indexed channels, e.g. PMT9, to add output columns
+			var extra []string
+			if !stream {
+				if extra, err = findExtraChannels(r); err != nil {
+					return err
+				}
+			}
+			// Find events with values, to leave out columns which would only
+			// hold NA
+			var used map[string]bool
+			if c.Bool("drop-empty-columns") {
+				if used, err = usedEvents(r, configureEvents, earliest, latest); err != nil {
+					return err
+				}
+				for _, note := range notes {
+					used[note.Name] = true
+				}
+				if rules != nil {
+					used[defs.AlertEvent] = true
+				}
+			}
+			var input io.Reader = r
+			if r == nil {
+				input = in
+			}
+			bufr := bufio.NewReader(input)
+
+			// Create writers and write headers
+			outputs := []*output{}
+			defer func() {
+				for _, o := range outputs {
+					if err := o.close(); err != nil {
 						log.Fatal(err)
 					}
-					if err := w.Close(); err != nil {
-						log.Fatal(err)
+				}
+			}()
+			var raw *rawWriter
+			for _, of := range outfiles {
+				if err := clearPartial(of[1]); err != nil {
+					return err
+				}
+				if of[0] == "raw" {
+					if raw, err = newRawWriter(of[1], c.StringSlice("encrypt-recipient")); err != nil {
+						return err
 					}
-				}()
-				bufw = bufio.NewWriter(w)
+					defer func() {
+						if err := raw.close(); err != nil {
+							log.Fatal(err)
+						}
+					}()
+					continue
+				}
+				o, err := newOutput(c, of[0], of[1], units, extra, discovered, used)
+				if err != nil {
+					return err
+				}
+				if err := o.open(); err != nil {
+					return err
+				}
+				outputs = append(outputs, o)
 			}
 
-			// Create writer
-			tsdw := seaflog.NewTsdataWriter(
-				c.String("filetype"), c.String("project"), c.String("description"),
-			)
-			// Write header
-			if _, err := fmt.Fprintf(bufw, "%s\n", tsdw.HeaderText()); err != nil {
-				return err
+			// Start plugins
+			plugins := pipeline.Plugins{}
+			defer func() { plugins.Close() }()
+			for _, command := range c.StringSlice("plugin") {
+				args := strings.Fields(command)
+				if len(args) == 0 {
+					return fmt.Errorf("empty --plugin")
+				}
+				p, err := pipeline.StartPlugin(args[0], args[1:]...)
+				if err != nil {
+					return err
+				}
+				plugins = append(plugins, p)
 			}
+
 			// Start parsing and write events
-			scanner := seaflog.NewEventScanner(bufr)
-			for scanner.Scan() {
-				event := scanner.Event()
-				if !seaflog.TimeFilter(event, earliest, latest) {
+			var tagger *pipeline.InstrumentTagger
+			switch c.String("instrument") {
+			case "":
+			case "auto":
+				tagger = pipeline.NewInstrumentTagger("")
+			default:
+				tagger = pipeline.NewInstrumentTagger(c.String("instrument"))
+			}
+			es := scanner.NewEventScanner(bufr)
+			es.LineOffset(skipped)
+			es.Header(header)
+			if c.String("logfile") != "-" {
+				es.SourceName(c.String("logfile"))
+			}
+			if err := configureEvents(es); err != nil {
+				return err
+			}
+			if tagger != nil {
+				es.Preprocess(tagger.Detect)
+			}
+			es.MaxStaleLines(c.Int("max-stale-lines"))
+			// On interrupt finish the current event, then stop. Deferred
+			// closes flush all outputs.
+			sig := interrupts()
+			if s, ok := in.(stopper); ok {
+				s.stopOn(sig)
+			}
+			var last defs.Event
+			interrupted := false
+			summary := runSummary{Logfile: c.String("logfile"), ErrorKinds: map[string]int{}, Start: start}
+			var colStats *pipeline.ColumnStats
+			if colStatsPath != "" {
+				colStats = pipeline.NewColumnStats()
+			}
+			writeEvent := func(event defs.Event) error {
+				events := []defs.Event{event}
+				if len(plugins) > 0 {
+					if events, err = plugins.Process(event); err != nil {
+						return err
+					}
+				}
+				for _, event := range events {
+					summary.addTime(event.Time)
+					if colStats != nil {
+						colStats.Add(event)
+					}
+					for _, o := range outputs {
+						if err := o.write(event); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			}
+			injector := pipeline.NewInjector(notes)
+			warned := make(map[string]bool) // extra channels
+			for !interrupted && es.Scan() {
+				select {
+				case <-sig:
+					interrupted = true
+				default:
+				}
+				event := es.Event()
+				last = event
+				if tagger != nil {
+					event = tagger.Tag(event)
+				}
+				if !pipeline.TimeFilter(event, earliest, latest) {
 					continue
 				}
-				if event.Name == "unhandled" {
-					event = seaflog.UnhandledToNote(event)
-					seaflog.Log.Printf(
-						"Line %d, unrecognized event, treating as a \"note\".\n  %s\n", event.LineNumber, event.Line,
-					)
+				summary.Events++
+				if raw != nil {
+					if err := raw.write(es.RawLine(), es.RawTimestampLine()); err != nil {
+						return err
+					}
+				}
+				if errors.Is(event.Error, defs.ErrUnrecognized) {
+					summary.Unrecognized++
+					event = pipeline.UnhandledToNote(event)
+					seaflog.Report(eventWarning(event, "unrecognized", `unrecognized event, treating as a "note"`))
 				}
 				if event.Error != nil {
-					seaflog.Log.Printf("Line %d, %v.\n  %s\n", event.LineNumber, event.Error, event.Line)
+					summary.addError(event.Error)
+					seaflog.Report(errorWarning(event))
 				} else {
-					eventLine, err := tsdw.EventText(event)
-					if err != nil {
-						seaflog.Log.Printf(
-							"Line %d, error serializing, %v.\n  %s\n", event.LineNumber, err, event.Line,
-						)
-					} else {
-						if _, err = fmt.Fprintf(bufw, "%s\n", eventLine); err != nil {
+					if event.Name == defs.RestartEvent {
+						summary.Restarts++
+						seaflog.Report(eventWarning(
+							event, "restart", "instrument software restarted, counter and stale time tracking start over",
+						))
+					}
+					if _, ok := defs.EventDefs[event.Name]; !ok && !isDiscovered(discovered, event.Name) && !warned[event.Name] {
+						warned[event.Name] = true
+						seaflog.Report(eventWarning(
+							event, "extra_channel", fmt.Sprintf("extra channel %s outside the defined indexes", event.Name),
+						))
+					}
+					for _, note := range injector.Before(event) {
+						if err := writeEvent(note); err != nil {
 							return err
 						}
 					}
+					if err := writeEvent(event); err != nil {
+						return err
+					}
+					if rules != nil {
+						for _, alert := range rules.Add(event) {
+							summary.Alerts++
+							seaflog.Report(eventWarning(alert, "alert", fmt.Sprintf("%v", alert.Value)))
+							if err := writeEvent(alert); err != nil {
+								return err
+							}
+						}
+					}
 				}
 			}
-			if err := scanner.Err(); err != nil {
+			if err := es.Err(); err != nil {
 				return err
 			}
+			// An interrupt while waiting on a named pipe or serial port
+			// ends the input instead of an event
+			select {
+			case <-sig:
+				interrupted = true
+			default:
+			}
+			for _, note := range injector.Rest() {
+				if err := writeEvent(note); err != nil {
+					return err
+				}
+			}
+			if err := plugins.Close(); err != nil {
+				return err
+			}
+			if interrupted {
+				seaflog.Report(warning("interrupted", fmt.Sprintf("Interrupted after line %d, output is partial", last.LineNumber)))
+				for _, of := range outfiles {
+					if err := writePartial(of[1], last.LineNumber, last.Time); err != nil {
+						return err
+					}
+				}
+			}
+			for _, a := range es.TimeAnomalies() {
+				seaflog.Report(warning("time_anomaly", a.String()))
+			}
+			// Every output with counters sees the same resets, report them once
+			for _, o := range outputs {
+				if o.counters != nil {
+					for _, r := range o.counters.Resets() {
+						seaflog.Report(warning("counter_reset", r.String()))
+					}
+					break
+				}
+			}
+			// Likewise QC flags
+			for _, o := range outputs {
+				if o.qc != nil {
+					for _, f := range o.qc.Flagged() {
+						seaflog.Report(warning("qc", f.String()))
+					}
+					break
+				}
+			}
 
+			if colStats != nil {
+				if err := writeJSON(colStatsPath, struct {
+					Columns []pipeline.ColumnStat `json:"columns"`
+				}{colStats.Stats()}); err != nil {
+					return err
+				}
+			}
+			if summaryPath != "" {
+				// Close outputs first so row counts include intervals still
+				// open at the end of the log
+				for _, o := range outputs {
+					if err := o.close(); err != nil {
+						return err
+					}
+					summary.Outputs = append(summary.Outputs, outputSummary{
+						Format: o.format, Path: o.path, Rows: o.rows, SHA256: o.checksum(),
+					})
+				}
+				summary.LastLine = last.LineNumber
+				summary.TimeAnomalies = len(es.TimeAnomalies())
+				summary.SoftwareVersion, summary.FirmwareVersion = es.Versions()
+				summary.Interrupted = interrupted
+				summary.DurationSeconds = time.Since(start).Seconds()
+				if err := writeSummary(summaryPath, summary); err != nil {
+					return err
+				}
+			}
+			if interrupted {
+				return cli.Exit("", exitInterrupted)
+			}
 			return nil
 		},
 	}
+}
 
-	err := app.Run(os.Args)
+func main() {
+	err := newApp().Run(os.Args)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// placeholderExpr matches outfile template placeholders, e.g. {project}
+var placeholderExpr = regexp.MustCompile(`\{[^{}]*\}`)
+
+// outfileVars returns values for outfile template placeholders for one input
+// logfile. {date} is the UTC date of the run.
+func outfileVars(logfile, project, filetype string, now time.Time) map[string]string {
+	base := "stdin"
+	if logfile != "-" {
+		base = strings.TrimSuffix(filepath.Base(logfile), filepath.Ext(logfile))
+	}
+	return map[string]string{
+		"project":  project,
+		"filetype": filetype,
+		"basename": base,
+		"date":     now.UTC().Format("2006-01-02"),
+	}
+}
+
+// expandOutfile replaces placeholders like {project} in an outfile template
+// with values from vars.
+func expandOutfile(tmpl string, vars map[string]string) (string, error) {
+	var err error
+	out := placeholderExpr.ReplaceAllStringFunc(tmpl, func(p string) string {
+		v, ok := vars[p[1:len(p)-1]]
+		if !ok && err == nil {
+			err = fmt.Errorf("unknown placeholder %s in --outfile %q", p, tmpl)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
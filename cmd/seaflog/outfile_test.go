@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandOutfile(t *testing.T) {
+	now := time.Date(2019, 6, 30, 23, 30, 0, 0, time.FixedZone("HST", -10*3600))
+	tests := []struct {
+		name    string
+		tmpl    string
+		logfile string
+		want    string
+		wantErr bool
+	}{
+		{"all", "{project}/{filetype}_{basename}_{date}.tsv", "/data/KM1906/SFlog_740.txt", "KM1906/SeaFlowV1InstrumentLog_SFlog_740_2019-07-01.tsv", false},
+		{"no placeholders", "out.tsv", "SFlog_740.txt", "out.tsv", false},
+		{"repeated", "{basename}.{basename}", "SFlog_740.log.txt", "SFlog_740.log.SFlog_740.log", false},
+		{"stdin", "{project}_{basename}.csv", "-", "KM1906_stdin.csv", false},
+		{"unknown", "{project}_{cruise}.tsv", "SFlog_740.txt", "", true},
+		{"unclosed", "{project.tsv", "SFlog_740.txt", "{project.tsv", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandOutfile(tt.tmpl, outfileVars(tt.logfile, "KM1906", inferredFileType, now))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandOutfile() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandOutfile() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
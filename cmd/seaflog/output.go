@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+// Output formats
+var formats = []string{"tsdata", "csv", "intervals"}
+
+// eventWriter serializes events, one line per event.
+type eventWriter interface {
+	HeaderText() string
+	EventText(event defs.Event) (string, error)
+}
+
+// output is one output file in one format. All outputs are fed from a single
+// pass over the log file.
+type output struct {
+	format   string
+	path     string
+	evw      eventWriter // tsdata and csv
+	ivw      writer.IntervalsWriter
+	pairs    *pipeline.Pairs
+	counters *pipeline.Counters
+	qc       *pipeline.QC
+	f        *os.File
+	enc      *encryptedFile // instead of f when encrypting
+	w        *bufio.Writer
+	rows     int       // rows written, not counting the header
+	hash     hash.Hash // of all bytes written to the file, if checksumming
+
+	recipients []string // to encrypt for, if any
+}
+
+// newOutput creates an output for format at path, configured from the global
+// flags, with columns for any extra channels of indexed events and for events
+// of discovered definitions. If used isn't
+// nil only event columns of used events are written.
+func newOutput(c *cli.Context, format string, path string, units *pipeline.UnitConverter, extra []string, discovered []defs.EventDef, used map[string]bool) (*output, error) {
+	o := &output{format: format, path: path, recipients: c.StringSlice("encrypt-recipient")}
+	if c.Bool("checksum") {
+		o.hash = sha256.New()
+	}
+	spec, err := writer.LookupTsdataSpec(c.String("tsdata-version"))
+	if err != nil {
+		return nil, err
+	}
+	if format == "intervals" {
+		o.ivw = writer.NewIntervalsWriter(
+			c.String("filetype"), c.String("project"), c.String("description"),
+		)
+		o.ivw.SetSpec(spec)
+		if err := o.ivw.SetTimeFormat(c.String("time-format")); err != nil {
+			return nil, err
+		}
+		o.pairs = pipeline.NewPairs(defs.PairDefs)
+		return o, nil
+	}
+
+	var tsdw writer.TsdataWriter
+	var csvw writer.CSVWriter
+	tw := &tsdw
+	switch format {
+	case "csv":
+		csvw = writer.NewCSVWriter(
+			c.String("filetype"), c.String("project"), c.String("description"),
+		)
+		csvw.SetNA(c.String("na"))
+		if err := csvw.SetTimeFormat(c.String("time-format")); err != nil {
+			return nil, err
+		}
+		tw = &csvw.TsdataWriter
+	case "tsdata":
+		tsdw = writer.NewTsdataWriter(
+			c.String("filetype"), c.String("project"), c.String("description"),
+		)
+		tsdw.SetSpec(spec)
+		if err := tsdw.SetTimeFormat(c.String("time-format")); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	if units != nil {
+		tw.SetUnits(units)
+	}
+	for _, name := range extra {
+		if err := tw.AddEventColumn(name); err != nil {
+			return nil, err
+		}
+	}
+	for _, edef := range discovered {
+		if err := tw.AddEventDefColumn(edef); err != nil {
+			return nil, err
+		}
+	}
+	for _, ff := range c.StringSlice("float-format") {
+		var err error
+		if parts := strings.SplitN(ff, "=", 2); len(parts) == 2 {
+			err = tw.SetEventFloatFormat(parts[0], parts[1])
+		} else {
+			err = tw.SetFloatFormat(ff)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.Bool("seq") {
+		tw.AddSeqColumn()
+	}
+	if c.String("instrument") != "" {
+		tw.AddInstrumentColumn()
+	}
+	if c.Bool("provenance") {
+		tw.AddProvenanceColumns()
+	}
+	if c.Bool("counters") {
+		o.counters = pipeline.NewCounters()
+		tw.SetCounters(o.counters)
+	}
+	if specs := joinComputed(c.StringSlice("computed")); len(specs) > 0 {
+		// Parse for each output, columns hold their own window state
+		columns := make([]pipeline.Computed, len(specs))
+		for i, spec := range specs {
+			col, err := pipeline.ParseComputed(spec)
+			if err != nil {
+				return nil, err
+			}
+			columns[i] = col
+		}
+		if err := tw.SetComputed(pipeline.NewComputer(columns)); err != nil {
+			return nil, err
+		}
+	}
+	if c.Bool("qc") {
+		o.qc = pipeline.NewQC()
+		tw.SetQC(o.qc)
+	}
+	if used != nil {
+		tw.KeepEventColumns(used)
+	}
+	if format == "csv" {
+		o.evw = csvw
+	} else {
+		o.evw = tsdw
+	}
+	return o, nil
+}
+
+// open creates the output file, '-' for STDOUT, and writes the header.
+func (o *output) open() error {
+	var err error
+	if len(o.recipients) > 0 {
+		// Checksum the encrypted file. A nil hash must stay a nil io.Writer
+		var sum io.Writer
+		if o.hash != nil {
+			sum = o.hash
+		}
+		if o.enc, err = createEncrypted(o.path, o.recipients, sum); err != nil {
+			return err
+		}
+		o.w = bufio.NewWriter(o.enc)
+	} else {
+		if o.f, err = createOutfile(o.path); err != nil {
+			return err
+		}
+		if o.hash != nil {
+			o.w = bufio.NewWriter(io.MultiWriter(o.f, o.hash))
+		} else {
+			o.w = bufio.NewWriter(o.f)
+		}
+	}
+
+	var header string
+	if o.pairs != nil {
+		header = o.ivw.HeaderText()
+	} else {
+		header = o.evw.HeaderText()
+	}
+	_, err = fmt.Fprintf(o.w, "%s\n", header)
+	return err
+}
+
+// createOutfile creates path and any missing parent directories, or returns
+// STDOUT for '-'.
+func createOutfile(path string) (*os.File, error) {
+	if path == "-" {
+		return os.Stdout, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// openLogfile opens path for reading, or returns STDIN for '-'. A path like
+// cruise.zip::logs/SFlog_740.txt opens a log file inside an archive, and a
+// path like sftp://user@ship-pc/path/SFlog_740.txt copies a remote log file.
+func openLogfile(path string) (*os.File, error) {
+	if path == "-" {
+		return os.Stdin, nil
+	}
+	if strings.HasPrefix(path, sftpScheme) {
+		return openRemote(path)
+	}
+	if parts := strings.SplitN(path, archiveSep, 2); len(parts) == 2 {
+		return openArchived(parts[0], parts[1])
+	}
+	return os.Open(path)
+}
+
+// write writes one event. Serialization errors are logged, only write errors
+// are returned.
+func (o *output) write(event defs.Event) error {
+	if o.pairs != nil {
+		for _, iv := range o.pairs.Add(event) {
+			if _, err := fmt.Fprintf(o.w, "%s\n", o.ivw.IntervalText(iv)); err != nil {
+				return err
+			}
+			o.rows++
+		}
+		return nil
+	}
+	eventLine, err := o.evw.EventText(event)
+	if err != nil {
+		seaflog.Report(eventWarning(event, "serialize", fmt.Sprintf("error serializing, %v", err)))
+		return nil
+	}
+	if _, err = fmt.Fprintf(o.w, "%s\n", eventLine); err != nil {
+		return err
+	}
+	o.rows++
+	return nil
+}
+
+// checksum returns the hex encoded SHA-256 of everything written so far, or
+// "" if not checksumming.
+func (o *output) checksum() string {
+	if o.hash == nil {
+		return ""
+	}
+	return hex.EncodeToString(o.hash.Sum(nil))
+}
+
+// close writes any open intervals, then flushes and closes the output file.
+// Closing more than once has no effect.
+func (o *output) close() error {
+	if o.w == nil {
+		return nil
+	}
+	if o.pairs != nil {
+		for _, iv := range o.pairs.Flush() {
+			if _, err := fmt.Fprintf(o.w, "%s\n", o.ivw.IntervalText(iv)); err != nil {
+				return err
+			}
+			o.rows++
+		}
+	}
+	if err := o.w.Flush(); err != nil {
+		return err
+	}
+	o.w = nil
+	if o.enc != nil {
+		return o.enc.Close()
+	}
+	if o.f == os.Stdout {
+		return nil
+	}
+	return o.f.Close()
+}
+
+// joinComputed rejoins computed column specs split at the commas of window
+// function arguments, as string slice flags are split at commas.
+func joinComputed(specs []string) []string {
+	joined := []string{}
+	open := false
+	for _, spec := range specs {
+		if open {
+			joined[len(joined)-1] += "," + spec
+		} else {
+			joined = append(joined, spec)
+		}
+		last := joined[len(joined)-1]
+		open = strings.Count(last, "(") > strings.Count(last, ")")
+	}
+	return joined
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMultipleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	tsdata := filepath.Join(dir, "out.tsdata")
+	csv := filepath.Join(dir, "out.csv")
+	intervals := filepath.Join(dir, "out.intervals")
+	log := "2015-03-14T00-00-00+00-00\nPMT1:1\nwrite evt: 1\n2015-03-14T00-10-00+00-00\nPMT1:2\n" +
+		"2015-03-14T00-20-00+00-00\nwrite evt: 0\nPMT1:3\n"
+	// STDIN can only be read once, so all outputs come from a single pass
+	err := convertPiped(t, log, []string{
+		"--filetype", "test", "--project", "test",
+		"--tsdata-out", tsdata, "--csv-out", csv, "--intervals-out", intervals,
+	})
+	if err != nil {
+		t.Fatalf("seaflog error = %v; want nil", err)
+	}
+	read := func(path string) []string {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	}
+
+	// The same columns and events in TSDATA and CSV, TSDATA from the column
+	// names in the last of its 7 header lines
+	tsRows := read(tsdata)[6:]
+	csvRows := read(csv)
+	if len(tsRows) != 6 {
+		t.Errorf("TSDATA rows %q; want a header and 5 events", tsRows)
+	}
+	if len(tsRows) != len(csvRows) {
+		t.Fatalf("TSDATA rows %q; CSV rows %q", tsRows, csvRows)
+	}
+	for i := range tsRows {
+		fields := strings.Split(tsRows[i], "\t")
+		for j := range fields {
+			if fields[j] == "NA" {
+				fields[j] = ""
+			}
+		}
+		if got := strings.Join(fields, ","); got != csvRows[i] {
+			t.Errorf("TSDATA row %q; CSV row %q", tsRows[i], csvRows[i])
+		}
+	}
+
+	ivRows := read(intervals)[7:]
+	want := "2015-03-14T00:00:00+00:00\tacquisition\t2015-03-14T00:20:00+00:00\t1200"
+	if len(ivRows) != 1 || ivRows[0] != want {
+		t.Errorf("intervals rows %q; want [%q]", ivRows, want)
+	}
+}
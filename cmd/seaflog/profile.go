@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// builtinProfiles bundle conversion flags for common uses. A profiles file
+// may add profiles or replace these.
+var builtinProfiles = map[string]map[string]interface{}{
+	// Streaming to shipboard dashboards
+	"realtime": {"format": "csv", "time-format": "epochms", "seq": true, "max-repeats": 10},
+	// End of cruise archives
+	"archive": {"format": "tsdata", "provenance": true, "instrument": "auto", "counters": true, "normalize-time": true},
+	// Quality control review
+	"qc": {"qc": true, "counters": true, "verbosity": "info"},
+}
+
+// parseProfiles parses a JSON profiles file, an object of profiles by name,
+// each an object of flag values by flag name, e.g.
+//
+//	{"nightly": {"format": "csv", "counters": true, "ignore-pattern": ["^DEBUG "]}}
+//
+// Values may be strings, numbers, booleans, or arrays of strings for flags
+// which may be repeated.
+func parseProfiles(data []byte) (map[string]map[string]interface{}, error) {
+	profiles := make(map[string]map[string]interface{})
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+	for name, flags := range profiles {
+		for flag, v := range flags {
+			if _, err := profileValues(v); err != nil {
+				return nil, fmt.Errorf("profile %q flag %q: %v", name, flag, err)
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// profileValues returns the text of a profile flag value, one per repeat.
+func profileValues(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case bool, float64, int:
+		return []string{fmt.Sprint(v)}, nil
+	case []interface{}:
+		values := []string{}
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("array values must be strings")
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("value must be a string, number, boolean, or array of strings")
+}
+
+// applyProfile sets the flags of the profile name, from the profiles file at
+// path if not empty or the built-in profiles, except flags set on the command
+// line or in the environment, which take precedence.
+func applyProfile(c *cli.Context, name string, path string) error {
+	profiles := make(map[string]map[string]interface{})
+	for pname, flags := range builtinProfiles {
+		profiles[pname] = flags
+	}
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fromFile, err := parseProfiles(b)
+		if err != nil {
+			return fmt.Errorf("invalid profiles file %s, %v", path, err)
+		}
+		for pname, flags := range fromFile {
+			profiles[pname] = flags
+		}
+	}
+	flags, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for pname := range profiles {
+			names = append(names, pname)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q, want one of %s", name, strings.Join(names, ", "))
+	}
+	// Sorted for deterministic errors
+	flagNames := make([]string, 0, len(flags))
+	for flag := range flags {
+		flagNames = append(flagNames, flag)
+	}
+	sort.Strings(flagNames)
+	for _, flag := range flagNames {
+		if flag == "profile" || flag == "profiles" {
+			return fmt.Errorf("profile %q can't set --%s", name, flag)
+		}
+		if c.IsSet(flag) {
+			continue
+		}
+		values, err := profileValues(flags[flag])
+		if err != nil {
+			return fmt.Errorf("profile %q flag %q: %v", name, flag, err)
+		}
+		for _, v := range values {
+			if err := c.Set(flag, v); err != nil {
+				return fmt.Errorf("profile %q flag %q: %v", name, flag, err)
+			}
+		}
+	}
+	return nil
+}
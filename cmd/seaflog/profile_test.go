@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestParseProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"values", `{"nightly": {"format": "csv", "seq": true, "max-repeats": 5, "ignore-pattern": ["^DEBUG ", "^TRACE "]}}`, false},
+		{"empty", `{}`, false},
+		{"not json", `nightly`, true},
+		{"object value", `{"nightly": {"format": {"csv": true}}}`, true},
+		{"non-string array", `{"nightly": {"ignore-pattern": [1, 2]}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProfiles([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseProfiles() error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	profilesPath := filepath.Join(t.TempDir(), "profiles.json")
+	profiles := `{
+		"nightly": {"format": "csv", "ignore-pattern": ["^DEBUG ", "^TRACE "]},
+		"qc": {"verbosity": "debug"},
+		"loop": {"profile": "qc"}
+	}`
+	if err := ioutil.WriteFile(profilesPath, []byte(profiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		profile     string
+		path        string
+		wantFormat  string
+		wantIgnore  string
+		wantVerbose string
+		wantErr     string
+	}{
+		{"builtin", nil, "realtime", "", "csv", "", "warn", ""},
+		{"command line wins", []string{"--format", "tsdata"}, "realtime", "", "tsdata", "", "warn", ""},
+		{"file", nil, "nightly", profilesPath, "csv", "^DEBUG ,^TRACE ", "warn", ""},
+		{"file replaces builtin", nil, "qc", profilesPath, "tsdata", "", "debug", ""},
+		{"builtin with file", nil, "archive", profilesPath, "tsdata", "", "warn", ""},
+		{"unknown", nil, "nope", "", "", "", "", `unknown profile "nope", want one of archive, qc, realtime`},
+		{"sets profile", nil, "loop", profilesPath, "", "", "", `profile "loop" can't set --profile`},
+		{"missing file", nil, "nightly", profilesPath + ".missing", "", "", "", "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var format, ignore, verbosity string
+			app := &cli.App{
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "format", Value: "tsdata"},
+					&cli.StringFlag{Name: "time-format"},
+					&cli.StringFlag{Name: "verbosity", Value: "warn"},
+					&cli.StringFlag{Name: "instrument"},
+					&cli.StringSliceFlag{Name: "ignore-pattern"},
+					&cli.BoolFlag{Name: "seq"},
+					&cli.BoolFlag{Name: "provenance"},
+					&cli.BoolFlag{Name: "counters"},
+					&cli.BoolFlag{Name: "normalize-time"},
+					&cli.BoolFlag{Name: "qc"},
+					&cli.IntFlag{Name: "max-repeats"},
+				},
+				Action: func(c *cli.Context) error {
+					if err := applyProfile(c, tt.profile, tt.path); err != nil {
+						return err
+					}
+					format, verbosity = c.String("format"), c.String("verbosity")
+					ignore = strings.Join(c.StringSlice("ignore-pattern"), ",")
+					return nil
+				},
+			}
+			err := app.Run(append([]string{"seaflog"}, tt.args...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("applyProfile() error = %v; want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyProfile() error = %v; want nil", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("--format %q; want %q", format, tt.wantFormat)
+			}
+			if ignore != tt.wantIgnore {
+				t.Errorf("--ignore-pattern %q; want %q", ignore, tt.wantIgnore)
+			}
+			if verbosity != tt.wantVerbose {
+				t.Errorf("--verbosity %q; want %q", verbosity, tt.wantVerbose)
+			}
+		})
+	}
+}
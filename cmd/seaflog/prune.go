@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+// usedEvents returns the names of events in the rest of f between earliest
+// and latest which would be written, read with a scanner set up by configure.
+// Unrecognized lines are written as notes. f is left at the position it
+// started from, so outputs can leave out columns which would only hold NA
+// before writing headers.
+func usedEvents(f *os.File, configure func(*scanner.EventScanner) error, earliest, latest time.Time) (map[string]bool, error) {
+	start, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	es := scanner.NewEventScanner(bufio.NewReader(f))
+	if err := configure(es); err != nil {
+		return nil, err
+	}
+	for es.Scan() {
+		event := es.Event()
+		if !pipeline.TimeFilter(event, earliest, latest) {
+			continue
+		}
+		if errors.Is(event.Error, defs.ErrUnrecognized) {
+			event = pipeline.UnhandledToNote(event)
+		}
+		if event.Error == nil && event.Value != nil {
+			used[event.Name] = true
+		}
+	}
+	if err := es.Err(); err != nil {
+		return nil, err
+	}
+	_, err = f.Seek(start, io.SeekStart)
+	return used, err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// rawWriter writes the log lines of events exactly as read, each preceded by
+// the timestamp line it followed in the log. Blank lines, lines the scanner
+// skips, and events with no line of their own, like restart, are not written.
+type rawWriter struct {
+	f      *os.File
+	enc    *encryptedFile // instead of f when encrypting
+	w      *bufio.Writer
+	tsLine string // last timestamp line written
+}
+
+// newRawWriter creates a rawWriter for path, '-' for STDOUT, encrypted for
+// recipients if any.
+func newRawWriter(path string, recipients []string) (*rawWriter, error) {
+	if len(recipients) > 0 {
+		enc, err := createEncrypted(path, recipients, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &rawWriter{enc: enc, w: bufio.NewWriter(enc)}, nil
+	}
+	f, err := createOutfile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &rawWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// write writes the raw line of an event, preceded by the raw timestamp line
+// rawTs if it differs from the last timestamp line written.
+func (rw *rawWriter) write(raw string, rawTs string) error {
+	if raw == "" {
+		return nil
+	}
+	if rawTs != "" && rawTs != rw.tsLine {
+		if _, err := fmt.Fprintf(rw.w, "%s\n", rawTs); err != nil {
+			return err
+		}
+		rw.tsLine = rawTs
+	}
+	_, err := fmt.Fprintf(rw.w, "%s\n", raw)
+	return err
+}
+
+// close flushes and closes the output file.
+func (rw *rawWriter) close() error {
+	if err := rw.w.Flush(); err != nil {
+		return err
+	}
+	if rw.enc != nil {
+		return rw.enc.Close()
+	}
+	if rw.f == os.Stdout {
+		return nil
+	}
+	return rw.f.Close()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRawOutBackdate(t *testing.T) {
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "SFlog_740.txt")
+	log := "Instrument Serial: 740\nPMT1:1\n2015-03-14T01-00-00+00-00\nPMT1:2\n" +
+		"2015-03-14T02-00-00+00-00\nPMT1:3\n"
+	if err := ioutil.WriteFile(logfile, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	common := []string{"seaflog", "--filetype", "test", "--project", "test", "--untimed", "backdate"}
+
+	rawPath := filepath.Join(dir, "raw.txt")
+	first := filepath.Join(dir, "first.tsv")
+	args := append(append([]string{}, common...), "--logfile", logfile, "--outfile", first, "--raw-out", rawPath)
+	if err := newApp().Run(args); err != nil {
+		t.Fatalf("seaflog --raw-out error = %v; want nil", err)
+	}
+	raw, err := ioutil.ReadFile(rawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Backdated lines are written after the timestamp line which timed them
+	want := "2015-03-14T01-00-00+00-00\nInstrument Serial: 740\nPMT1:1\nPMT1:2\n" +
+		"2015-03-14T02-00-00+00-00\nPMT1:3\n"
+	if string(raw) != want {
+		t.Fatalf("--raw-out\n%s\nwant\n%s", raw, want)
+	}
+
+	// The raw log converts to the same events without backdating
+	again := filepath.Join(dir, "again.tsv")
+	args = []string{"seaflog", "--filetype", "test", "--project", "test", "--logfile", rawPath, "--outfile", again}
+	if err := newApp().Run(args); err != nil {
+		t.Fatalf("seaflog on --raw-out output error = %v; want nil", err)
+	}
+	got, err := ioutil.ReadFile(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(b) {
+		t.Errorf("conversion of --raw-out output\n%s\nwant\n%s", got, b)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// sftpScheme prefixes remote log files, e.g.
+// sftp://user@ship-pc/path/SFlog_740.txt
+const sftpScheme = "sftp://"
+
+// openRemote copies the log file at an sftp:// URL to a temporary file with
+// the system ssh client, so it can be read and seeked like any other log file,
+// and so ssh configuration, keys, and agents work as they do for ssh. A path
+// starting with /~/ is relative to the remote home directory. The temporary
+// file is removed when closed.
+func openRemote(rawurl string) (*os.File, error) {
+	args, err := remoteArgs(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp("", "seaflog-remote-*")
+	if err != nil {
+		return nil, err
+	}
+	// Unlinked files stay readable until closed on Linux and MacOS
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	cmd := exec.Command("ssh", args...)
+	cmd.Stdout = f
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("copying %s, %v", rawurl, err)
+	} else {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// remoteArgs returns the ssh arguments which write the log file at an sftp://
+// URL to STDOUT.
+func remoteArgs(rawurl string) ([]string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "sftp" || u.Hostname() == "" || u.Path == "" || u.Path == "/" || u.Path == "/~/" {
+		return nil, fmt.Errorf("invalid remote log file %q, want sftp://[USER@]HOST[:PORT]/PATH", rawurl)
+	}
+	host := u.Hostname()
+	if u.User != nil {
+		host = u.User.Username() + "@" + host
+	}
+	args := []string{}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	remotePath := strings.TrimPrefix(u.Path, "/~/")
+	// ssh runs the command through the remote user's shell
+	return append(args, "--", host, "cat -- "+shellQuote(remotePath)), nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemoteArgs(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    []string
+		wantErr bool
+	}{
+		{"sftp://seaflow@ship-pc/data/SFlog_740.txt", []string{"--", "seaflow@ship-pc", "cat -- /data/SFlog_740.txt"}, false},
+		{"sftp://ship-pc/data/SFlog_740.txt", []string{"--", "ship-pc", "cat -- /data/SFlog_740.txt"}, false},
+		{"sftp://seaflow@ship-pc:2222/data/SFlog_740.txt", []string{"-p", "2222", "--", "seaflow@ship-pc", "cat -- /data/SFlog_740.txt"}, false},
+		{"sftp://ship-pc/~/logs/SFlog_740.txt", []string{"--", "ship-pc", "cat -- logs/SFlog_740.txt"}, false},
+		{"sftp://ship-pc/data/SFlog 740's.txt", []string{"--", "ship-pc", `cat -- '/data/SFlog 740'\''s.txt'`}, false},
+		{"sftp://seaflow@/data/SFlog_740.txt", nil, true},
+		{"sftp://ship-pc", nil, true},
+		{"sftp://ship-pc/", nil, true},
+		{"sftp://ship-pc/~/", nil, true},
+		{"sftp://ship-pc:port/data/SFlog_740.txt", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := remoteArgs(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("remoteArgs(%q) error = %v; want error %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("remoteArgs(%q) = %q; want %q", tt.url, got, tt.want)
+		}
+	}
+}
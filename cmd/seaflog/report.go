@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/seaflow-uw/seaflog/v2"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// errorKind returns a short identifier for the kind of event error err, for
+// structured log messages.
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, defs.ErrNoTimestamp):
+		return "no_timestamp"
+	case errors.Is(err, defs.ErrBadFloat):
+		return "bad_float"
+	case errors.Is(err, defs.ErrMissingSeparator):
+		return "missing_separator"
+	case errors.Is(err, defs.ErrBadEnum):
+		return "bad_enum"
+	case errors.Is(err, defs.ErrOutOfRange):
+		return "out_of_range"
+	case errors.Is(err, defs.ErrSpecialFloat):
+		return "special_float"
+	case errors.Is(err, defs.ErrUnrecognized):
+		return "unrecognized"
+	default:
+		return "parse"
+	}
+}
+
+// eventWarning returns a warning message about event.
+func eventWarning(event defs.Event, kind string, msg string) seaflog.Message {
+	return seaflog.Message{
+		Level:     seaflog.LevelWarning,
+		File:      event.Source,
+		Line:      event.LineNumber,
+		ErrorKind: kind,
+		Message:   msg,
+		Text:      event.Line,
+	}
+}
+
+// errorWarning returns a warning message about the error of event.
+func errorWarning(event defs.Event) seaflog.Message {
+	err := event.Error
+	var perr *defs.ParseError
+	if errors.As(err, &perr) {
+		err = perr.Err
+	}
+	return eventWarning(event, errorKind(err), err.Error())
+}
+
+// warning returns a warning message not tied to one event.
+func warning(kind string, msg string) seaflog.Message {
+	return seaflog.Message{Level: seaflog.LevelWarning, ErrorKind: kind, Message: msg}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/seaflow-uw/seaflog/v2/seaflogtest"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var reproCommand = &cli.Command{
+	Name:      "repro",
+	Usage:     "convert a log file more than once with the same conversion flags and check every output format is byte for byte identical",
+	ArgsUsage: "-- CONVERSION_FLAGS...",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "runs",
+			Usage: "number of conversions to compare",
+			Value: 2,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Int("runs") < 2 {
+			return fmt.Errorf("--runs must be at least 2")
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		dir, err := ioutil.TempDir("", "seaflog-repro")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		// Each run writes every output format valid for the conversion
+		// flags to its own directory
+		outputs, err := reproOutputs(c, c.Args().Slice())
+		if err != nil {
+			return err
+		}
+		runDirs := make([]string, c.Int("runs"))
+		for i := range runDirs {
+			runDirs[i] = filepath.Join(dir, fmt.Sprint(i+1))
+			args := append([]string{}, c.Args().Slice()...)
+			for _, format := range outputs {
+				args = append(args, "--"+format+"-out", filepath.Join(runDirs[i], format))
+			}
+			args = append(args, "--quiet")
+			cmd := exec.Command(exe, args...)
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("run %d: %v", i+1, err)
+			}
+		}
+
+		differ := 0
+		for _, format := range outputs {
+			first, err := ioutil.ReadFile(filepath.Join(runDirs[0], format))
+			if err != nil {
+				return err
+			}
+			for i := 1; i < len(runDirs); i++ {
+				b, err := ioutil.ReadFile(filepath.Join(runDirs[i], format))
+				if err != nil {
+					return err
+				}
+				if d := seaflogtest.Diff(b, first); d != "" {
+					differ++
+					fmt.Fprintf(c.App.Writer, "%s: run %d differs from run 1, %s\n", format, i+1, d)
+				}
+			}
+			fmt.Fprintf(c.App.Writer, "%s: %d runs compared\n", format, len(runDirs))
+		}
+		if differ > 0 {
+			return fmt.Errorf("conversion is not reproducible")
+		}
+		return nil
+	},
+}
+
+// reproOutputs returns the raw output and the output formats which can be
+// written with conversion flags args, parsed with the flags of the app running
+// c, including any --profile. Formats which reject --time-format are skipped
+// with a note.
+func reproOutputs(c *cli.Context, args []string) ([]string, error) {
+	timeFormat := ""
+	app := &cli.App{
+		Name:   c.App.Name,
+		Flags:  c.App.Flags,
+		Writer: ioutil.Discard,
+		Action: func(pc *cli.Context) error {
+			if name := pc.String("profile"); name != "" {
+				if err := applyProfile(pc, name, pc.String("profiles")); err != nil {
+					return err
+				}
+			}
+			timeFormat = pc.String("time-format")
+			return nil
+		},
+	}
+	if err := app.Run(append([]string{c.App.Name}, args...)); err != nil {
+		return nil, err
+	}
+	outputs := []string{"raw"}
+	for _, format := range formats {
+		if format == "tsdata" || format == "intervals" {
+			if err := writer.ValidateTsdataTimeFormat(timeFormat); err != nil {
+				fmt.Fprintf(c.App.Writer, "%s: not compared, %v\n", format, err)
+				continue
+			}
+		}
+		outputs = append(outputs, format)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs the test binary as seaflog when repro re-executes it.
+func TestMain(m *testing.M) {
+	if os.Getenv("SEAFLOG_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRepro(t *testing.T) {
+	os.Setenv("SEAFLOG_TEST_MAIN", "1")
+	defer os.Unsetenv("SEAFLOG_TEST_MAIN")
+	logfile := filepath.Join(t.TempDir(), "SFlog_740.txt")
+	log := "2015-03-14T01-00-00+00-00\nPMT1:1\n2015-03-14T02-00-00+00-00\nPMT1:2\n"
+	if err := ioutil.WriteFile(logfile, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"all formats", nil, []string{"raw", "tsdata", "csv", "intervals"}},
+		{"epochms", []string{"--format", "csv", "--time-format", "epochms"}, []string{"raw", "csv"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			app := newApp()
+			app.Writer = &out
+			args := append([]string{"seaflog", "repro", "--", "--logfile", logfile, "--filetype", "test", "--project", "test"}, tt.args...)
+			if err := app.Run(args); err != nil {
+				t.Fatalf("seaflog repro error = %v; want nil\n%s", err, out.String())
+			}
+			got := []string{}
+			for _, line := range strings.Split(out.String(), "\n") {
+				if strings.HasSuffix(line, " runs compared") {
+					got = append(got, line[:strings.Index(line, ":")])
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("seaflog repro compared %v; want %v\n%s", got, tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+// indexPath returns the path of the sidecar index for a log file.
+func indexPath(logfile string) string {
+	return logfile + ".idx"
+}
+
+// seekEarliest moves f to the last timestamp line before t and returns the
+// number of lines skipped and the header lines before the first timestamp
+// line, which the scanner must still read for the software versions. The
+// position is read from the sidecar index if there is an up to date one, or
+// else found by binary search. Lines skipped by binary search are counted but
+// not parsed, which is much faster than scanning them. Both assume timestamps
+// increase through the file, so f is left at the start if the index shows they
+// don't.
+func seekEarliest(f *os.File, t time.Time) (int, []string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, nil, nil
+	}
+	var off int64
+	lines := 0
+	if idx, ok := readIndex(indexPath(f.Name()), info.Size()); ok {
+		if !idx.Monotonic() {
+			seaflog.Report(warning("index", fmt.Sprintf("Not seeking to --earliest, time goes backward in %s", f.Name())))
+			return 0, nil, nil
+		}
+		cp := idx.Seek(t)
+		off, lines = cp.Offset, cp.Lines
+	} else {
+		if off, err = scanner.SeekTime(f, info.Size(), t); err != nil {
+			return 0, nil, err
+		}
+		if lines, err = countLines(io.NewSectionReader(f, 0, off)); err != nil {
+			return 0, nil, err
+		}
+	}
+	if off == 0 {
+		return 0, nil, nil
+	}
+	header, err := scanner.ReadHeader(io.NewSectionReader(f, 0, off))
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return 0, nil, err
+	}
+	return lines, header, nil
+}
+
+// readIndex reads the index at path, returning false if there is none or it
+// doesn't match a log file of size bytes.
+func readIndex(path string, size int64) (scanner.Index, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return scanner.Index{}, false
+	}
+	defer f.Close()
+	idx, err := scanner.ReadIndex(f)
+	if err != nil {
+		seaflog.Report(warning("index", fmt.Sprintf("Ignoring index %s, %v", path, err)))
+		return scanner.Index{}, false
+	}
+	if idx.Size != size {
+		seaflog.Report(warning("index", fmt.Sprintf("Ignoring out of date index %s", path)))
+		return scanner.Index{}, false
+	}
+	return idx, true
+}
+
+// countLines returns the number of newlines in r.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 1<<20)
+	n := 0
+	for {
+		c, err := r.Read(buf)
+		n += bytes.Count(buf[:c], []byte{'\n'})
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+	}
+}
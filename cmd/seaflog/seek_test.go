@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// convertPiped runs seaflog with args, reading log from STDIN through a pipe,
+// which can't seek.
+func convertPiped(t *testing.T, log string, args []string) error {
+	t.Helper()
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		io.WriteString(pw, log)
+		pw.Close()
+	}()
+	stdin := os.Stdin
+	os.Stdin = pr
+	defer func() {
+		os.Stdin = stdin
+		pr.Close()
+	}()
+	return newApp().Run(append([]string{"seaflog", "--logfile", "-"}, args...))
+}
+
+func TestSeekMatchesStream(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		args []string
+	}{
+		{
+			"normalize time",
+			"2015-03-14T05-00-00+00-00\nPMT1:1\n" +
+				"2015-03-14T01-00-00+00-00\nPMT1:2\n" +
+				"2015-03-14T02-00-00+00-00\nPMT1:3\n" +
+				"2015-03-14T03-00-00+00-00\nPMT1:4\n" +
+				"2015-03-14T04-00-00+00-00\nPMT1:5\n",
+			[]string{"--earliest", "2015-03-14T07:00:00Z", "--normalize-time"},
+		},
+		{
+			"file markers",
+			"#FILE: a.txt\n2015-03-14T01-00-00+00-00\nPMT1:1\n2015-03-14T02-00-00+00-00\nPMT1:2\n" +
+				"#FILE: b.txt\n2015-03-14T03-00-00+00-00\nPMT1:3\n2015-03-14T04-00-00+00-00\nPMT1:4\n",
+			[]string{"--earliest", "2015-03-14T04:00:00Z", "--file-markers", "--provenance"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logfile := filepath.Join(dir, "SFlog_740.txt")
+			if err := ioutil.WriteFile(logfile, []byte(tt.log), 0644); err != nil {
+				t.Fatal(err)
+			}
+			common := []string{"--filetype", "test", "--project", "test"}
+			seeked := filepath.Join(dir, "seeked.tsv")
+			args := append(append([]string{"--logfile", logfile, "--outfile", seeked}, common...), tt.args...)
+			if err := newApp().Run(append([]string{"seaflog"}, args...)); err != nil {
+				t.Fatalf("seaflog --logfile error = %v; want nil", err)
+			}
+			piped := filepath.Join(dir, "piped.tsv")
+			args = append(append([]string{"--outfile", piped}, common...), tt.args...)
+			if err := convertPiped(t, tt.log, args); err != nil {
+				t.Fatalf("seaflog --logfile - error = %v; want nil", err)
+			}
+			want, err := ioutil.ReadFile(piped)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadFile(seeked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("output of seekable file\n%s\nwant output of pipe\n%s", got, want)
+			}
+			if rows := strings.Count(string(want), "\n2015-"); rows == 0 {
+				t.Errorf("output of pipe\n%s\nwant data rows", want)
+			}
+		})
+	}
+}
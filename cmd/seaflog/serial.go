@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serialCapture reads an instrument console on a serial port, appending
+// everything read to a raw capture file.
+type serialCapture struct {
+	port    *os.File
+	capture *os.File
+	r       io.Reader
+	stop    <-chan os.Signal // closed or sent a value to end reads
+}
+
+// parseSerial parses a --serial DEVICE:BAUD value, e.g. /dev/ttyUSB0:9600.
+func parseSerial(spec string) (string, int, error) {
+	i := strings.LastIndex(spec, ":")
+	if i < 1 {
+		return "", 0, fmt.Errorf("invalid --serial %q, want DEVICE:BAUD, e.g. /dev/ttyUSB0:9600", spec)
+	}
+	baud, err := strconv.Atoi(spec[i+1:])
+	if err != nil || baud <= 0 {
+		return "", 0, fmt.Errorf("invalid --serial baud rate %q", spec[i+1:])
+	}
+	return spec[:i], baud, nil
+}
+
+// openSerialCapture opens the serial port in spec as a raw 8N1 line at its
+// baud rate and opens capturePath for appending.
+func openSerialCapture(spec, capturePath string) (*serialCapture, error) {
+	device, baud, err := parseSerial(spec)
+	if err != nil {
+		return nil, err
+	}
+	port, err := openSerialPort(device, baud)
+	if err != nil {
+		return nil, err
+	}
+	capture, err := os.OpenFile(capturePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		port.Close()
+		return nil, err
+	}
+	return &serialCapture{port: port, capture: capture, r: io.TeeReader(port, capture)}, nil
+}
+
+// stopOn makes reads end with io.EOF once stop receives, even on a quiet
+// console.
+func (sc *serialCapture) stopOn(stop <-chan os.Signal) {
+	sc.stop = stop
+}
+
+// Read reads from the serial port. Data is written to the capture file as it
+// is read, before parsing, so the capture is complete even if parsing fails.
+func (sc *serialCapture) Read(p []byte) (int, error) {
+	for {
+		// Wake up now and then to check for a stop
+		sc.port.SetReadDeadline(time.Now().Add(stopPoll))
+		n, err := sc.r.Read(p)
+		if n > 0 || !errors.Is(err, os.ErrDeadlineExceeded) {
+			return n, err
+		}
+		select {
+		case <-sc.stop:
+			return 0, io.EOF
+		default:
+		}
+	}
+}
+
+// Close closes the serial port and the capture file.
+func (sc *serialCapture) Close() error {
+	err := sc.port.Close()
+	if cerr := sc.capture.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+//go:build linux && !ppc64 && !ppc64le
+// +build linux,!ppc64,!ppc64le
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPty opens a pseudoterminal, returning the master and the path of the
+// slave, which stands in for a serial port.
+func openPty(t *testing.T) (*os.File, string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudoterminals, %v", err)
+	}
+	var unlock int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		master.Close()
+		t.Skipf("unlocking pseudoterminal, %v", errno)
+	}
+	var n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		master.Close()
+		t.Skipf("pseudoterminal number, %v", errno)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestOpenSerialPort(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+
+	if _, err := openSerialPort(slave, 1234); err == nil {
+		t.Errorf("openSerialPort(1234 baud) error = nil; want an error")
+	}
+	port, err := openSerialPort(slave, 9600)
+	if err != nil {
+		t.Fatalf("openSerialPort() error = %v; want nil", err)
+	}
+	defer port.Close()
+
+	var tios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, port.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&tios))); errno != 0 {
+		t.Fatal(errno)
+	}
+	if tios.Cflag&cbaud != syscall.B9600 {
+		t.Errorf("baud bits %o; want %o", tios.Cflag&cbaud, syscall.B9600)
+	}
+	if tios.Cflag&syscall.CSIZE != syscall.CS8 || tios.Cflag&(syscall.PARENB|syscall.CSTOPB) != 0 {
+		t.Errorf("c_cflag %o; want 8N1", tios.Cflag)
+	}
+	if tios.Cflag&(syscall.CREAD|syscall.CLOCAL) != syscall.CREAD|syscall.CLOCAL {
+		t.Errorf("c_cflag %o; want CREAD and CLOCAL", tios.Cflag)
+	}
+	if tios.Lflag&(syscall.ICANON|syscall.ECHO|syscall.ISIG) != 0 {
+		t.Errorf("c_lflag %o; want raw, no ICANON, ECHO, or ISIG", tios.Lflag)
+	}
+	if tios.Iflag&(syscall.ICRNL|syscall.IXON) != 0 || tios.Oflag&syscall.OPOST != 0 {
+		t.Errorf("c_iflag %o, c_oflag %o; want no translation", tios.Iflag, tios.Oflag)
+	}
+	if tios.Cc[syscall.VMIN] != 1 || tios.Cc[syscall.VTIME] != 0 {
+		t.Errorf("VMIN %d, VTIME %d; want 1, 0", tios.Cc[syscall.VMIN], tios.Cc[syscall.VTIME])
+	}
+}
+
+func TestSerialCaptureStop(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	capturePath := filepath.Join(t.TempDir(), "capture.txt")
+	sc, err := openSerialCapture(slave+":9600", capturePath)
+	if err != nil {
+		t.Fatalf("openSerialCapture() error = %v; want nil", err)
+	}
+	defer sc.Close()
+	stop := make(chan os.Signal, 1)
+	sc.stopOn(stop)
+
+	if _, err := master.WriteString("PMT1:1\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	n, err := sc.Read(buf)
+	if got := string(buf[:n]); got != "PMT1:1\r\n" || err != nil {
+		t.Fatalf("serialCapture Read() = %q, %v; want %q, nil", got, err, "PMT1:1\r\n")
+	}
+
+	// A read on a quiet console ends on stop
+	go func() {
+		time.Sleep(2 * stopPoll)
+		stop <- os.Interrupt
+	}()
+	done := make(chan error)
+	go func() {
+		_, err := sc.Read(buf)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("serialCapture Read() error = %v; want io.EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serialCapture Read() still waiting after stop")
+	}
+
+	b, err := ioutil.ReadFile(capturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "PMT1:1\r\n" {
+		t.Errorf("capture %q; want %q", b, "PMT1:1\r\n")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux || ppc64 || ppc64le
+// +build !linux ppc64 ppc64le
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// openSerialPort is only supported on Linux, except ppc64.
+func openSerialPort(device string, baud int) (*os.File, error) {
+	return nil, fmt.Errorf("--serial is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestParseSerial(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantDevice string
+		wantBaud   int
+		wantErr    bool
+	}{
+		{"/dev/ttyUSB0:9600", "/dev/ttyUSB0", 9600, false},
+		{"/dev/serial/by-id/usb-FTDI:port0:115200", "/dev/serial/by-id/usb-FTDI:port0", 115200, false},
+		{"/dev/ttyUSB0", "", 0, true},
+		{":9600", "", 0, true},
+		{"/dev/ttyUSB0:", "", 0, true},
+		{"/dev/ttyUSB0:fast", "", 0, true},
+		{"/dev/ttyUSB0:-9600", "", 0, true},
+		{"/dev/ttyUSB0:0", "", 0, true},
+	}
+	for _, tt := range tests {
+		device, baud, err := parseSerial(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSerial(%q) error = %v; want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if device != tt.wantDevice || baud != tt.wantBaud {
+			t.Errorf("parseSerial(%q) = %q, %d; want %q, %d", tt.spec, device, baud, tt.wantDevice, tt.wantBaud)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/urfave/cli/v2"
+)
+
+var sleepCommand = &cli.Command{
+	Name:  "sleep",
+	Usage: "report the sleep/wake cycle in a log file: sleep windows per UTC day, total sleep time, and duty cycle",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		pairs := pipeline.NewPairs(defs.PairDefs)
+		intervals := []pipeline.Interval{}
+		var first, last time.Time
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			event := es.Event()
+			if event.Error != nil || event.Time.IsZero() {
+				continue
+			}
+			if first.IsZero() || event.Time.Before(first) {
+				first = event.Time
+			}
+			if event.Time.After(last) {
+				last = event.Time
+			}
+			intervals = append(intervals, pairs.Add(event)...)
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+		intervals = append(intervals, pairs.Flush()...)
+		report := pipeline.AnalyzeSleep(intervals, first, last)
+
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "date\tsleep\twindows")
+		for _, day := range report.Days {
+			windows := make([]string, len(day.Windows))
+			for i, w := range day.Windows {
+				windows[i] = fmt.Sprintf("%s-%s", w.Start.UTC().Format("15:04:05"), w.End.UTC().Format("15:04:05"))
+				if w.End.Sub(day.Date) == 24*time.Hour {
+					windows[i] = fmt.Sprintf("%s-24:00:00", w.Start.UTC().Format("15:04:05"))
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%v\t%s\n", day.Date.Format("2006-01-02"), day.Sleep, strings.Join(windows, ","))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		duty := "NA"
+		if !math.IsNaN(report.DutyCycle) {
+			duty = fmt.Sprintf("%.1f%%", report.DutyCycle*100)
+		}
+		_, err = fmt.Fprintf(
+			c.App.Writer, "\nspan: %v\ntotal sleep: %v\nduty cycle: %s awake\n",
+			report.End.Sub(report.Start), report.Sleep, duty,
+		)
+		return err
+	},
+}
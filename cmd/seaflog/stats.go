@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/urfave/cli/v2"
+)
+
+var statsCommand = &cli.Command{
+	Name:  "stats",
+	Usage: "summarize the distribution of each float event, and time spent in each state of boolean events, in a log file",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:  "memory-budget",
+			Usage: "MiB of float values to hold in memory before spilling to a temporary file, 0 for no limit",
+		},
+		&cli.StringFlag{
+			Name:  "temp-dir",
+			Usage: "directory for spilled values, default the system temporary directory",
+		},
+		&cli.IntFlag{
+			Name:  "buckets",
+			Usage: "number of histogram buckets per event, 0 for no histograms",
+			Value: 10,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		summaries := pipeline.NewSummaries()
+		if mib := c.Int("memory-budget"); mib > 0 {
+			summaries.Spill(int64(mib)<<20, c.String("temp-dir"))
+		}
+		defer summaries.Close()
+		bools := pipeline.NewBoolDurations()
+		var end time.Time
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			event := es.Event()
+			summaries.Add(event)
+			bools.Add(event)
+			if event.Time.After(end) {
+				end = event.Time
+			}
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+		if err := summaries.Err(); err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(c.App.Writer, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "event\tcount\tmin\tp5\tp50\tp95\tmax")
+		for _, name := range summaries.Names() {
+			fmt.Fprintf(
+				tw, "%s\t%d\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\n",
+				name, summaries.Count(name),
+				summaries.Quantile(name, 0), summaries.Quantile(name, 0.05), summaries.Quantile(name, 0.5),
+				summaries.Quantile(name, 0.95), summaries.Quantile(name, 1),
+			)
+		}
+		if c.Int("buckets") > 0 {
+			fmt.Fprintln(tw, "\nevent\tbucket\tcount")
+			for _, name := range summaries.Names() {
+				for _, b := range summaries.Histogram(name, c.Int("buckets")) {
+					fmt.Fprintf(tw, "%s\t%.4g - %.4g\t%d\n", name, b.Start, b.End, b.Count)
+				}
+			}
+		}
+		if durations := bools.Durations(end); len(durations) > 0 {
+			fmt.Fprintln(tw, "\nevent\ttrue\tfalse\ttransitions")
+			for _, d := range durations {
+				fmt.Fprintf(tw, "%s\t%v\t%v\t%d\n", d.Name, d.True, d.False, d.Transitions)
+			}
+		}
+		// Reading spilled values back may also fail
+		if err := summaries.Err(); err != nil {
+			return err
+		}
+		return tw.Flush()
+	},
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runSummary is a machine readable summary of one conversion, for workflow
+// engines to capture without parsing log messages.
+type runSummary struct {
+	Logfile         string          `json:"logfile"`
+	LastLine        int             `json:"last_line"`
+	Events          int             `json:"events"`
+	Errors          int             `json:"errors"`
+	ErrorKinds      map[string]int  `json:"error_kinds"` // errors by kind, e.g. "bad_float"
+	Unrecognized    int             `json:"unrecognized"`
+	TimeAnomalies   int             `json:"time_anomalies"`
+	Restarts        int             `json:"restarts"`                   // of the instrument software
+	Alerts          int             `json:"alerts"`                     // fired by --rules
+	SoftwareVersion string          `json:"software_version,omitempty"` // last seen
+	FirmwareVersion string          `json:"firmware_version,omitempty"`
+	Earliest        *time.Time      `json:"earliest"` // of events written
+	Latest          *time.Time      `json:"latest"`
+	Outputs         []outputSummary `json:"outputs"`
+	Interrupted     bool            `json:"interrupted"`
+	Start           time.Time       `json:"start"`
+	DurationSeconds float64         `json:"duration_seconds"`
+}
+
+// outputSummary is the number of rows written to one output, and with
+// --checksum the SHA-256 of its contents for seaflog verify.
+type outputSummary struct {
+	Format string `json:"format"`
+	Path   string `json:"path"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// addError counts an event parsing error by its kind.
+func (s *runSummary) addError(err error) {
+	s.Errors++
+	if s.ErrorKinds == nil {
+		s.ErrorKinds = make(map[string]int)
+	}
+	s.ErrorKinds[errorKind(err)]++
+}
+
+// addTime extends the summary time range to include t.
+func (s *runSummary) addTime(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if s.Earliest == nil || t.Before(*s.Earliest) {
+		t := t
+		s.Earliest = &t
+	}
+	if s.Latest == nil || t.After(*s.Latest) {
+		t := t
+		s.Latest = &t
+	}
+}
+
+// writeSummary writes s as JSON to path, '-' for STDOUT, or fd:N for an open
+// file descriptor.
+func writeSummary(path string, s runSummary) error {
+	return writeJSON(path, s)
+}
+
+// writeJSON writes v as indented JSON to path, '-' for STDOUT, or fd:N for an
+// open file descriptor.
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if strings.HasPrefix(path, "fd:") {
+		fd, err := strconv.Atoi(strings.TrimPrefix(path, "fd:"))
+		if err != nil || fd < 0 {
+			return fmt.Errorf("invalid file descriptor %q", path)
+		}
+		f := os.NewFile(uintptr(fd), path)
+		_, err = f.Write(b)
+		return err
+	}
+	f, err := createOutfile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	if f == os.Stdout {
+		return nil
+	}
+	return f.Close()
+}
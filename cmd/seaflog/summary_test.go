@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunSummary(t *testing.T) {
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "SFlog_740.txt")
+	log := "Software Version: 2.5.1\n2015-03-14T01-00-00+00-00\nPMT1:1\nPMT1:x\nPMT2:y\n" +
+		"2015-03-14T02-00-00+00-00\nPMT1:2\nCELLS PER ML\n"
+	if err := ioutil.WriteFile(logfile, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outfile := filepath.Join(dir, "out.tsdata")
+	path := filepath.Join(dir, "summary.json")
+	err := newApp().Run([]string{
+		"seaflog", "--logfile", logfile, "--filetype", "test", "--project", "test",
+		"--outfile", outfile, "--summary", path, "--quiet",
+	})
+	if err != nil {
+		t.Fatalf("seaflog --summary error = %v; want nil", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("summary is not JSON, %v\n%s", err, b)
+	}
+	want := map[string]interface{}{
+		"logfile":          logfile,
+		"last_line":        8.0,
+		"events":           6.0,
+		"errors":           3.0,
+		"error_kinds":      map[string]interface{}{"bad_float": 2.0, "no_timestamp": 1.0},
+		"unrecognized":     1.0,
+		"time_anomalies":   0.0,
+		"restarts":         0.0,
+		"alerts":           0.0,
+		"software_version": "2.5.1",
+		"earliest":         "2015-03-14T01:00:00Z",
+		"latest":           "2015-03-14T02:00:00Z",
+		"outputs":          []interface{}{map[string]interface{}{"format": "tsdata", "path": outfile, "rows": 3.0}},
+		"interrupted":      false,
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(got[k], v) {
+			t.Errorf("summary %s = %v; want %v", k, got[k], v)
+		}
+	}
+	for _, k := range []string{"start", "duration_seconds"} {
+		if _, ok := got[k]; !ok {
+			t.Errorf("summary has no %s", k)
+		}
+	}
+}
+
+func TestWriteJSONFd(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "fd.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := writeJSON(fmt.Sprintf("fd:%d", f.Fd()), runSummary{Logfile: "-", Events: 2}); err != nil {
+		t.Fatalf("writeJSON(fd:N) error = %v; want nil", err)
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got runSummary
+	if err := json.Unmarshal(b, &got); err != nil || got.Logfile != "-" || got.Events != 2 {
+		t.Errorf("writeJSON(fd:N) wrote %s; want the summary", b)
+	}
+
+	for _, path := range []string{"fd:x", "fd:-1"} {
+		if err := writeJSON(path, runSummary{}); err == nil {
+			t.Errorf("writeJSON(%q) error = nil; want an error", path)
+		}
+	}
+}
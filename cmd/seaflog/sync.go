@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var syncCommand = &cli.Command{
+	Name:      "sync",
+	Usage:     "push the bytes of output files added since the last sync to a shore HTTP endpoint",
+	ArgsUsage: "FILE...",
+	Description: "Each file is sent with an HTTP PUT to URL/NAME, NAME the file's base name, with a\n" +
+		"Content-Range header giving the byte offset of the body, e.g. \"bytes 1024-2047/*\".\n" +
+		"Only bytes appended since the last sync are sent. A file whose already sent bytes\n" +
+		"changed, e.g. because it was reconverted, is sent again from offset 0. Files with\n" +
+		"the same base name, given together or recorded in the state file, are refused,\n" +
+		"since they would be sent to the same URL.",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "url",
+			Usage:    "base URL of the shore endpoint",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "state",
+			Usage:    "JSON file recording how much of each file has been sent",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:  "bwlimit",
+			Usage: "maximum upload rate in KiB per second, 0 for no limit",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return fmt.Errorf("no files to sync")
+		}
+		if c.Int("bwlimit") < 0 {
+			return fmt.Errorf("invalid --bwlimit %d", c.Int("bwlimit"))
+		}
+		state, err := readSyncState(c.String("state"))
+		if err != nil {
+			return err
+		}
+		paths := c.Args().Slice()
+		abs := make([]string, len(paths))
+		for i, path := range paths {
+			if abs[i], err = filepath.Abs(path); err != nil {
+				return err
+			}
+		}
+		if err := checkRemoteNames(abs, state); err != nil {
+			return err
+		}
+		base := strings.TrimSuffix(c.String("url"), "/")
+		for i, path := range paths {
+			prev := state[abs[i]]
+			synced, from, err := syncFile(abs[i], base+"/"+filepath.Base(path), prev, c.Int("bwlimit")*1024)
+			if err != nil {
+				return fmt.Errorf("syncing %s, %v", path, err)
+			}
+			state[abs[i]] = synced
+			switch {
+			case synced == prev:
+				fmt.Fprintf(c.App.Writer, "%s: up to date\n", path)
+			case from == 0 && prev.Offset > 0:
+				fmt.Fprintf(c.App.Writer, "%s: changed, sent all %d bytes\n", path, synced.Offset)
+			default:
+				fmt.Fprintf(c.App.Writer, "%s: sent %d bytes\n", path, synced.Offset-from)
+			}
+			// Save after every file so an interrupted sync resumes
+			if err := writeSyncState(c.String("state"), state); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// syncedFile records how much of a file has been sent.
+type syncedFile struct {
+	Offset int64  `json:"offset"` // bytes sent
+	SHA256 string `json:"sha256"` // hex encoded SHA-256 of the bytes sent
+}
+
+// checkRemoteNames returns an error if two different files among the absolute
+// paths and those recorded in state share a base name, the name they're sent
+// to, so their appends would be mixed up in one remote file.
+func checkRemoteNames(paths []string, state map[string]syncedFile) error {
+	recorded := make([]string, 0, len(state))
+	for path := range state {
+		recorded = append(recorded, path)
+	}
+	// Sorted for deterministic errors
+	sort.Strings(recorded)
+	byName := make(map[string]string)
+	for _, path := range append(recorded, paths...) {
+		name := filepath.Base(path)
+		if other, ok := byName[name]; ok && other != path {
+			return fmt.Errorf("%s and %s would both be sent as %s, sync them to different --url", other, path, name)
+		}
+		byName[name] = path
+	}
+	return nil
+}
+
+// readSyncState reads sync state by absolute file path from path, empty if
+// path doesn't exist yet.
+func readSyncState(path string) (map[string]syncedFile, error) {
+	state := map[string]syncedFile{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("invalid sync state %s, %v", path, err)
+	}
+	return state, nil
+}
+
+// writeSyncState writes state to path, renaming it into place so an
+// interrupted write doesn't lose the previous state.
+func writeSyncState(path string, state map[string]syncedFile) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// syncFile sends the bytes of the file at path after prev.Offset to url,
+// limited to rate bytes per second, 0 for no limit, and returns the new sync
+// state and the offset sending started from. If the first prev.Offset bytes no
+// longer match prev.SHA256 the whole file is sent.
+func syncFile(path string, url string, prev syncedFile, rate int) (syncedFile, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return prev, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return prev, 0, err
+	}
+	// Output may still be growing, only send up to its current size
+	size := fi.Size()
+
+	h := sha256.New()
+	offset := int64(0)
+	if prev.Offset > 0 && prev.Offset <= size {
+		if _, err := io.CopyN(h, f, prev.Offset); err != nil {
+			return prev, 0, err
+		}
+		if hex.EncodeToString(h.Sum(nil)) == prev.SHA256 {
+			offset = prev.Offset
+		}
+	}
+	if offset == 0 {
+		h.Reset()
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return prev, 0, err
+		}
+	}
+	// Nothing new, unless the file was truncated to nothing
+	if offset == size && offset == prev.Offset {
+		return prev, offset, nil
+	}
+	body := &countingReader{r: io.TeeReader(io.LimitReader(f, size-offset), h)}
+	if err := putRange(url, body, offset, size, rate); err != nil {
+		return prev, 0, err
+	}
+	// A server may answer before reading the whole body, don't record bytes
+	// it never got as sent
+	if body.n != size-offset {
+		return prev, 0, fmt.Errorf("sent %d of %d bytes", body.n, size-offset)
+	}
+	return syncedFile{Offset: size, SHA256: hex.EncodeToString(h.Sum(nil))}, offset, nil
+}
+
+// putRange PUTs the size-offset bytes of body to url, as the bytes of the
+// file starting at offset.
+func putRange(url string, body io.Reader, offset int64, size int64, rate int) error {
+	if rate > 0 {
+		body = &throttledReader{r: body, rate: rate, start: time.Now()}
+	}
+	req, err := http.NewRequest(http.MethodPut, url, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size - offset
+	if size > offset {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", offset, size-1))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// throttledReader limits the average rate of reads from r to rate bytes per
+// second.
+type throttledReader struct {
+	r     io.Reader
+	rate  int
+	start time.Time
+	n     int64 // bytes read
+}
+
+// Read reads at most a tenth of a second's worth of bytes, after sleeping
+// until reading them keeps to the rate.
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if max := tr.rate / 10; max > 0 && len(p) > max {
+		p = p[:max]
+	}
+	due := tr.start.Add(time.Duration(float64(tr.n) / float64(tr.rate) * float64(time.Second)))
+	if wait := time.Until(due); wait > 0 {
+		time.Sleep(wait)
+	}
+	n, err := tr.r.Read(p)
+	tr.n += int64(n)
+	return n, err
+}
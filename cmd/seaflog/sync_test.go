@@ -0,0 +1,182 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// putRecord is a PUT received by a test shore endpoint.
+type putRecord struct {
+	contentRange string
+	body         string
+}
+
+func TestSyncFile(t *testing.T) {
+	var puts []putRecord
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		puts = append(puts, putRecord{r.Header.Get("Content-Range"), string(b)})
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "out.tsdata")
+	// Steps run in order against the same file, each starting from the state
+	// the previous step returned.
+	tests := []struct {
+		name     string
+		contents string
+		status   int
+		wantPut  *putRecord
+		wantFrom int64
+		wantOff  int64
+		wantErr  bool
+	}{
+		{"first", "abc\n", http.StatusOK, &putRecord{"bytes 0-3/*", "abc\n"}, 0, 4, false},
+		{"up to date", "abc\n", http.StatusOK, nil, 4, 4, false},
+		{"append", "abc\ndef\n", http.StatusCreated, &putRecord{"bytes 4-7/*", "def\n"}, 4, 8, false},
+		{"rewrite", "xyz\ndef\n", http.StatusOK, &putRecord{"bytes 0-7/*", "xyz\ndef\n"}, 0, 8, false},
+		{"non-2xx", "xyz\ndef\nghi\n", http.StatusInternalServerError, &putRecord{"bytes 8-11/*", "ghi\n"}, 0, 8, true},
+		{"retry", "xyz\ndef\nghi\n", http.StatusOK, &putRecord{"bytes 8-11/*", "ghi\n"}, 8, 12, false},
+		{"truncate to zero", "", http.StatusOK, &putRecord{"", ""}, 0, 0, false},
+	}
+	var state syncedFile
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			puts = nil
+			status = tt.status
+			got, from, err := syncFile(path, ts.URL+"/out.tsdata", state, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("syncFile() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantPut == nil {
+				if len(puts) != 0 {
+					t.Errorf("PUTs %+v; want none", puts)
+				}
+			} else if len(puts) != 1 || puts[0] != *tt.wantPut {
+				t.Errorf("PUTs %+v; want [%+v]", puts, *tt.wantPut)
+			}
+			if err == nil && from != tt.wantFrom {
+				t.Errorf("syncFile() from = %v; want %v", from, tt.wantFrom)
+			}
+			if got.Offset != tt.wantOff {
+				t.Errorf("syncFile() Offset = %v; want %v", got.Offset, tt.wantOff)
+			}
+			state = got
+		})
+	}
+}
+
+func TestSyncFileBwlimit(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got = string(b)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "out.tsdata")
+	contents := strings.Repeat("x", 3000)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 3000 bytes at 10000 bytes/s are read a tenth of a second's worth at a
+	// time, the third read waiting until 200ms after the first
+	start := time.Now()
+	if _, _, err := syncFile(path, ts.URL+"/out.tsdata", syncedFile{}, 10000); err != nil {
+		t.Fatalf("syncFile() error = %v; want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("syncFile() took %v; want at least 200ms", elapsed)
+	}
+	if got != contents {
+		t.Errorf("body length %v; want %v", len(got), len(contents))
+	}
+}
+
+// shortTransport answers 200 OK after reading only the first byte of a body.
+type shortTransport struct{}
+
+func (shortTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	io.CopyN(ioutil.Discard, r.Body, 1)
+	r.Body.Close()
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    r,
+	}, nil
+}
+
+func TestSyncFileShort(t *testing.T) {
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = shortTransport{}
+	defer func() { http.DefaultClient.Transport = transport }()
+
+	path := filepath.Join(t.TempDir(), "out.tsdata")
+	if err := ioutil.WriteFile(path, []byte("abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := syncFile(path, "http://shore.invalid/out.tsdata", syncedFile{}, 0)
+	if err == nil || !strings.Contains(err.Error(), "sent 1 of 4 bytes") {
+		t.Errorf("syncFile() error = %v; want sent 1 of 4 bytes", err)
+	}
+	if got.Offset != 0 {
+		t.Errorf("syncFile() Offset = %v; want 0", got.Offset)
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := readSyncState(path)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("readSyncState() = %v, %v; want empty, nil", state, err)
+	}
+	state["/data/out.tsdata"] = syncedFile{Offset: 8, SHA256: "abc"}
+	if err := writeSyncState(path, state); err != nil {
+		t.Fatalf("writeSyncState() error = %v; want nil", err)
+	}
+	got, err := readSyncState(path)
+	if err != nil {
+		t.Fatalf("readSyncState() error = %v; want nil", err)
+	}
+	if got["/data/out.tsdata"] != state["/data/out.tsdata"] {
+		t.Errorf("readSyncState() = %v; want %v", got, state)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary state file left behind")
+	}
+}
+
+func TestCheckRemoteNames(t *testing.T) {
+	state := map[string]syncedFile{"/data/a/out.tsdata": {Offset: 8, SHA256: "abc"}}
+	tests := []struct {
+		name    string
+		paths   []string
+		wantErr bool
+	}{
+		{"distinct", []string{"/data/a/out.tsdata", "/data/a/out.csv"}, false},
+		{"same file twice", []string{"/data/b/out.csv", "/data/b/out.csv"}, false},
+		{"same name", []string{"/data/b/out.csv", "/data/c/out.csv"}, true},
+		{"same name as recorded", []string{"/data/b/out.tsdata"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRemoteNames(tt.paths, state)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkRemoteNames(%v) error = %v; want error %v", tt.paths, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var timelineCommand = &cli.Command{
+	Name:  "timeline",
+	Usage: "export acquisition runs and other intervals, and faults, as an iCalendar or Mermaid Gantt timeline",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "logfile",
+			Usage:    "SeaFLow v1 instrument log file, '-' for STDIN, or ARCHIVE::PATH for a file inside an archive",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "timeline format, ical or mermaid",
+			Value: writer.TimelineMermaid,
+		},
+		&cli.StringFlag{
+			Name:  "title",
+			Usage: "calendar name or chart title",
+			Value: "SeaFlow activity",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		r, err := openLogfile(c.String("logfile"))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		pairs := pipeline.NewPairs(defs.PairDefs)
+		intervals := []pipeline.Interval{}
+		faults := []defs.Event{}
+		var last time.Time
+		es := scanner.NewEventScanner(bufio.NewReader(r))
+		for es.Scan() {
+			event := es.Event()
+			if event.Error != nil {
+				continue
+			}
+			if event.Time.After(last) {
+				last = event.Time
+			}
+			intervals = append(intervals, pairs.Add(event)...)
+			if defs.EventDefs[event.Name].Fault {
+				faults = append(faults, event)
+			}
+		}
+		if err := es.Err(); err != nil {
+			return err
+		}
+		intervals = append(intervals, pairs.Flush()...)
+
+		timeline, err := writer.Timeline(c.String("format"), c.String("title"), intervals, faults, last)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(c.App.Writer, "%s", timeline)
+		return err
+	},
+}
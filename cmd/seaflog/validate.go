@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/seaflow-uw/seaflog/v2/writer"
+	"github.com/urfave/cli/v2"
+)
+
+var validateCommand = &cli.Command{
+	Name:      "validate",
+	Usage:     "check TSDATA files against a version of the TSDATA spec",
+	ArgsUsage: "FILE...",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "tsdata-version",
+			Usage: "TSDATA spec version to check against",
+			Value: writer.DefaultTsdataVersion,
+		},
+		&cli.IntFlag{
+			Name:  "max-errors",
+			Usage: "report at most this many nonconforming lines per file, 0 for no limit",
+			Value: 10,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return fmt.Errorf("no files to validate")
+		}
+		spec, err := writer.LookupTsdataSpec(c.String("tsdata-version"))
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, path := range c.Args().Slice() {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			violations, err := writer.ValidateTsdata(f, spec, c.Int("max-errors"))
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("reading %s, %v", path, err)
+			}
+			if len(violations) == 0 {
+				fmt.Fprintf(c.App.Writer, "%s: OK\n", path)
+				continue
+			}
+			failed++
+			for _, v := range violations {
+				fmt.Fprintf(c.App.Writer, "%s: %v\n", path, v)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d files don't conform to TSDATA spec version %s", failed, c.NArg(), spec.Version())
+		}
+		return nil
+	},
+}
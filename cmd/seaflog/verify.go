@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+var verifyCommand = &cli.Command{
+	Name:  "verify",
+	Usage: "check output files against the SHA-256 checksums in a run summary written with --checksum",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "summary",
+			Usage:    "JSON run summary written by --summary with --checksum",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		b, err := ioutil.ReadFile(c.String("summary"))
+		if err != nil {
+			return err
+		}
+		var summary runSummary
+		if err := json.Unmarshal(b, &summary); err != nil {
+			return fmt.Errorf("invalid summary %s, %v", c.String("summary"), err)
+		}
+		checked, failed := 0, 0
+		for _, o := range summary.Outputs {
+			if o.SHA256 == "" || o.Path == "-" {
+				continue
+			}
+			checked++
+			sum, err := fileChecksum(o.Path)
+			switch {
+			case err != nil:
+				failed++
+				fmt.Fprintf(c.App.Writer, "%s: FAILED, %v\n", o.Path, err)
+			case sum != o.SHA256:
+				failed++
+				fmt.Fprintf(c.App.Writer, "%s: FAILED\n", o.Path)
+			default:
+				fmt.Fprintf(c.App.Writer, "%s: OK\n", o.Path)
+			}
+		}
+		if checked == 0 {
+			return fmt.Errorf("no checksums for output files in %s, was it written with --checksum?", c.String("summary"))
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d outputs failed verification", failed, checked)
+		}
+		return nil
+	},
+}
+
+// fileChecksum returns the hex encoded SHA-256 of the file at path.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	logfile := filepath.Join(dir, "SFlog_740.txt")
+	log := "2015-03-14T01-00-00+00-00\nPMT1:1\n2015-03-14T02-00-00+00-00\nPMT1:2\n"
+	if err := ioutil.WriteFile(logfile, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tsdata := filepath.Join(dir, "out.tsdata")
+	csv := filepath.Join(dir, "out.csv")
+	summary := filepath.Join(dir, "summary.json")
+	plain := filepath.Join(dir, "plain.json")
+	err := newApp().Run([]string{
+		"seaflog", "--logfile", logfile, "--filetype", "test", "--project", "test",
+		"--outfile", tsdata, "--csv-out", csv, "--summary", summary, "--checksum",
+	})
+	if err != nil {
+		t.Fatalf("seaflog --checksum error = %v; want nil", err)
+	}
+	err = newApp().Run([]string{
+		"seaflog", "--logfile", logfile, "--filetype", "test", "--project", "test",
+		"--outfile", filepath.Join(dir, "plain.tsdata"), "--summary", plain,
+	})
+	if err != nil {
+		t.Fatalf("seaflog error = %v; want nil", err)
+	}
+
+	verify := func(path string) (string, error) {
+		var out bytes.Buffer
+		app := &cli.App{Writer: &out, Commands: []*cli.Command{verifyCommand}}
+		err := app.Run([]string{"seaflog", "verify", "--summary", path})
+		return out.String(), err
+	}
+
+	out, err := verify(summary)
+	if err != nil {
+		t.Fatalf("verify of fresh outputs error = %v; want nil\n%s", err, out)
+	}
+	if want := tsdata + ": OK\n" + csv + ": OK\n"; out != want {
+		t.Errorf("verify of fresh outputs wrote\n%s\nwant\n%s", out, want)
+	}
+
+	f, err := os.OpenFile(csv, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("2015-03-14T03:00:00+00:00,3\n")
+	f.Close()
+	out, err = verify(summary)
+	if err == nil {
+		t.Errorf("verify of a changed output error = nil; want an error")
+	}
+	if want := tsdata + ": OK\n" + csv + ": FAILED\n"; out != want {
+		t.Errorf("verify of a changed output wrote\n%s\nwant\n%s", out, want)
+	}
+
+	if err := os.Remove(tsdata); err != nil {
+		t.Fatal(err)
+	}
+	if out, err = verify(summary); err == nil || !strings.HasPrefix(out, tsdata+": FAILED, ") {
+		t.Errorf("verify of a missing output = %q, %v; want FAILED and an error", out, err)
+	}
+
+	if _, err := verify(plain); err == nil {
+		t.Errorf("verify of a summary without checksums error = nil; want an error")
+	}
+}
@@ -0,0 +1,82 @@
+package seaflog
+
+import (
+	"io"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+// EventDefs hold event defintions keyed by name.
+//
+// Deprecated: Use defs.EventDefs.
+var EventDefs = defs.EventDefs
+
+// EventDef defines a log file event
+//
+// Deprecated: Use defs.EventDef.
+type EventDef = defs.EventDef
+
+// EventForm defines a form of an event with a unique line prefix.
+//
+// Deprecated: Use defs.EventForm.
+type EventForm = defs.EventForm
+
+// EventExample contains example input and parsed data for an Event.
+//
+// Deprecated: Use defs.EventExample.
+type EventExample = defs.EventExample
+
+// Event is parsed log file event
+//
+// Deprecated: Use defs.Event.
+type Event = defs.Event
+
+// EventScanner provides an interface for reading through a SeaFlow v1 instrument log file.
+//
+// Deprecated: Use scanner.EventScanner.
+type EventScanner = scanner.EventScanner
+
+// NewEventScanner creates an EventScanner.
+//
+// Deprecated: Use scanner.NewEventScanner.
+func NewEventScanner(r io.Reader) *EventScanner {
+	return scanner.NewEventScanner(r)
+}
+
+// CreateEvent creates an event
+//
+// Deprecated: Use defs.CreateEvent.
+func CreateEvent(line string, t time.Time, lineNumber int) (Event, error) {
+	return defs.CreateEvent(line, t, lineNumber)
+}
+
+// TimeFilter returns true if an Event lies inclusively within the bounds of the
+// times earliest and latest.
+//
+// Deprecated: Use pipeline.TimeFilter.
+func TimeFilter(event Event, earliest, latest time.Time) bool {
+	return pipeline.TimeFilter(event, earliest, latest)
+}
+
+// UnhandledToNote converts an unhandled event to a note event
+//
+// Deprecated: Use pipeline.UnhandledToNote.
+func UnhandledToNote(unhandled Event) Event {
+	return pipeline.UnhandledToNote(unhandled)
+}
+
+// TsdataWriter provides tools to write SeaFlow log files in TSDATA file format
+//
+// Deprecated: Use writer.TsdataWriter.
+type TsdataWriter = writer.TsdataWriter
+
+// NewTsdataWriter creates a new TsdataWriter struct
+//
+// Deprecated: Use writer.NewTsdataWriter.
+func NewTsdataWriter(fileType string, project string, description string) TsdataWriter {
+	return writer.NewTsdataWriter(fileType, project, description)
+}
@@ -0,0 +1,231 @@
+package seaflog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+// ConverterConfig configures a Converter, like seaflog's command line flags.
+// Filetype and Project are required.
+type ConverterConfig struct {
+	Filetype    string
+	Project     string
+	Description string
+	Format      string // tsdata or csv, empty for tsdata
+	TimeFormat  string // a writer.TimeFormat* name or Go time layout, only RFC3339 for tsdata
+	FloatFormat string // fmt format for float values, e.g. %.4g
+	NA          string // missing value token for csv
+	// Only events in [Earliest, Latest] are written, zero for no limit.
+	Earliest time.Time
+	Latest   time.Time
+	// Only events with these names are written, nil for all events.
+	Events []string
+}
+
+// ConvertStats counts the events of one or more conversions.
+type ConvertStats struct {
+	Conversions  int
+	Events       int // parsed, including errors
+	Errors       int // dropped for parsing errors
+	Unrecognized int // written as notes
+	Rows         int // written, not counting headers
+}
+
+func (s *ConvertStats) add(o ConvertStats) {
+	s.Conversions += o.Conversions
+	s.Events += o.Events
+	s.Errors += o.Errors
+	s.Unrecognized += o.Unrecognized
+	s.Rows += o.Rows
+}
+
+// Converter converts log files with a fixed configuration. It's safe for
+// concurrent use by multiple goroutines, so a long-lived service can share one
+// Converter between requests. Every conversion has its own scanner and writer,
+// and reports nothing through Report, so conversions don't interact.
+//
+// Converters read the package level event definitions, defs.EventDefs, which
+// must not be changed while any Converter is in use.
+type Converter struct {
+	config ConverterConfig
+	events map[string]bool
+
+	mu    sync.Mutex
+	stats ConvertStats
+}
+
+// NewConverter creates a Converter for config, returning an error if config
+// is invalid.
+func NewConverter(config ConverterConfig) (*Converter, error) {
+	if config.Filetype == "" {
+		return nil, fmt.Errorf("missing Filetype")
+	}
+	if config.Project == "" {
+		return nil, fmt.Errorf("missing Project")
+	}
+	switch config.Format {
+	case "":
+		config.Format = "tsdata"
+	case "tsdata", "csv":
+	default:
+		return nil, fmt.Errorf("unknown output format %q", config.Format)
+	}
+	if config.TimeFormat != "" {
+		validate := writer.ValidateTsdataTimeFormat
+		if config.Format == "csv" {
+			validate = writer.ValidateTimeFormat
+		}
+		if err := validate(config.TimeFormat); err != nil {
+			return nil, err
+		}
+	}
+	if config.FloatFormat != "" {
+		if err := writer.ValidateFloatFormat(config.FloatFormat); err != nil {
+			return nil, err
+		}
+	}
+	c := &Converter{config: config}
+	if config.Events != nil {
+		c.events = make(map[string]bool)
+		for _, name := range config.Events {
+			if _, ok := defs.Lookup(name); !ok {
+				return nil, fmt.Errorf("unknown event %q", name)
+			}
+			c.events[name] = true
+		}
+	}
+	c.config.Events = append([]string(nil), config.Events...)
+	return c, nil
+}
+
+// Config returns the Converter's configuration.
+func (c *Converter) Config() ConverterConfig {
+	config := c.config
+	config.Events = append([]string(nil), c.config.Events...)
+	return config
+}
+
+// Convert converts the log in r to w. Unrecognized events become notes and
+// events with errors are dropped. It returns the stats of this conversion,
+// which are also added to the Converter's totals.
+func (c *Converter) Convert(r io.Reader, w io.Writer) (ConvertStats, error) {
+	stats := ConvertStats{Conversions: 1}
+	defer func() {
+		c.mu.Lock()
+		c.stats.add(stats)
+		c.mu.Unlock()
+	}()
+
+	header, eventText, err := c.newWriter()
+	if err != nil {
+		return stats, err
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintln(bw, header()); err != nil {
+		return stats, err
+	}
+	es := scanner.NewEventScanner(r)
+	for es.Scan() {
+		event := es.Event()
+		stats.Events++
+		if errors.Is(event.Error, defs.ErrUnrecognized) {
+			stats.Unrecognized++
+			event = pipeline.UnhandledToNote(event)
+		}
+		if event.Error != nil {
+			stats.Errors++
+			continue
+		}
+		if !pipeline.TimeFilter(event, c.config.Earliest, c.config.Latest) {
+			continue
+		}
+		if c.events != nil && !c.events[event.Name] {
+			continue
+		}
+		line, err := eventText(event)
+		if err != nil {
+			stats.Errors++
+			continue
+		}
+		if _, err := fmt.Fprintln(bw, line); err != nil {
+			return stats, err
+		}
+		stats.Rows++
+	}
+	if err := es.Err(); err != nil {
+		return stats, err
+	}
+	return stats, bw.Flush()
+}
+
+// ConvertFS converts the log at path in fsys to w, like Convert, e.g. from an
+// embed.FS of test fixtures, a zip.Reader, or a cloud storage adapter.
+func (c *Converter) ConvertFS(fsys fs.FS, path string, w io.Writer) (ConvertStats, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return ConvertStats{}, err
+	}
+	defer f.Close()
+	return c.Convert(f, w)
+}
+
+// ConvertFS converts the log at path in fsys to w with a Converter for config.
+func ConvertFS(fsys fs.FS, path string, w io.Writer, config ConverterConfig) (ConvertStats, error) {
+	c, err := NewConverter(config)
+	if err != nil {
+		return ConvertStats{}, err
+	}
+	return c.ConvertFS(fsys, path, w)
+}
+
+// Stats returns the totals of all conversions so far.
+func (c *Converter) Stats() ConvertStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// newWriter creates a writer for one conversion, returning its header and
+// event line functions.
+func (c *Converter) newWriter() (func() string, func(defs.Event) (string, error), error) {
+	config := c.config
+	if config.Format == "csv" {
+		w := writer.NewCSVWriter(config.Filetype, config.Project, config.Description)
+		w.SetNA(config.NA)
+		if err := c.configure(&w.TsdataWriter, w.SetTimeFormat); err != nil {
+			return nil, nil, err
+		}
+		return w.HeaderText, w.EventText, nil
+	}
+	w := writer.NewTsdataWriter(config.Filetype, config.Project, config.Description)
+	if err := c.configure(&w, w.SetTimeFormat); err != nil {
+		return nil, nil, err
+	}
+	return w.HeaderText, w.EventText, nil
+}
+
+// configure applies the time format of the config with setTimeFormat, the
+// writer's own, and the float format to w.
+func (c *Converter) configure(w *writer.TsdataWriter, setTimeFormat func(string) error) error {
+	if c.config.TimeFormat != "" {
+		if err := setTimeFormat(c.config.TimeFormat); err != nil {
+			return err
+		}
+	}
+	if c.config.FloatFormat != "" {
+		if err := w.SetFloatFormat(c.config.FloatFormat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package seaflog_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2"
+)
+
+func TestNewConverter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  seaflog.ConverterConfig
+		wantErr bool
+	}{
+		{"default", seaflog.ConverterConfig{Filetype: "test", Project: "test"}, false},
+		{"csv", seaflog.ConverterConfig{Filetype: "test", Project: "test", Format: "csv", NA: "NaN"}, false},
+		{"empty", seaflog.ConverterConfig{}, true},
+		{"no project", seaflog.ConverterConfig{Filetype: "test"}, true},
+		{"unknown format", seaflog.ConverterConfig{Filetype: "test", Project: "test", Format: "xml"}, true},
+		{"bad float format", seaflog.ConverterConfig{Filetype: "test", Project: "test", FloatFormat: "%d"}, true},
+		{"unknown event", seaflog.ConverterConfig{Filetype: "test", Project: "test", Events: []string{"nope"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := seaflog.NewConverter(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewConverter() error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConverter(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.05\nPMT2:2\nnot a real event data line\n" +
+		"2015-03-14T01-00-00+00-00\nPMT1:1.1\n"
+	c, err := seaflog.NewConverter(seaflog.ConverterConfig{
+		Filetype: "test",
+		Project:  "test",
+		Latest:   time.Date(2015, 3, 14, 0, 30, 0, 0, time.UTC),
+		Events:   []string{"PMT1", "note"},
+	})
+	if err != nil {
+		t.Fatalf("NewConverter() error = %v; want nil", err)
+	}
+
+	var want bytes.Buffer
+	stats, err := c.Convert(strings.NewReader(input), &want)
+	if err != nil {
+		t.Fatalf("Convert() error = %v; want nil", err)
+	}
+	wantStats := seaflog.ConvertStats{Conversions: 1, Events: 4, Unrecognized: 1, Rows: 2}
+	if stats != wantStats {
+		t.Errorf("Convert() stats = %+v; want %+v", stats, wantStats)
+	}
+	lines := strings.Split(strings.TrimSpace(want.String()), "\n")
+	if got := strings.Split(lines[len(lines)-2], "\t")[1]; got != "1.05" {
+		t.Errorf("PMT1 = %v; want 1.05", got)
+	}
+
+	// Concurrent conversions give the same output
+	const n = 8
+	var wg sync.WaitGroup
+	outs := make([]bytes.Buffer, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := c.Convert(strings.NewReader(input), &outs[i]); err != nil {
+				t.Errorf("Convert() error = %v; want nil", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := range outs {
+		if outs[i].String() != want.String() {
+			t.Errorf("concurrent Convert() %d output differs", i)
+		}
+	}
+	if got := c.Stats(); got.Conversions != n+1 || got.Rows != (n+1)*wantStats.Rows {
+		t.Errorf("Stats() = %+v; want %d conversions of %d rows", got, n+1, wantStats.Rows)
+	}
+}
+
+func TestConvertFS(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.05\n"
+	fsys := fstest.MapFS{"logs/SFlog_740.txt": &fstest.MapFile{Data: []byte(input)}}
+	config := seaflog.ConverterConfig{Filetype: "test", Project: "test"}
+
+	var got, want bytes.Buffer
+	if _, err := seaflog.ConvertFS(fsys, "logs/SFlog_740.txt", &got, config); err != nil {
+		t.Fatalf("ConvertFS() error = %v; want nil", err)
+	}
+	c, err := seaflog.NewConverter(config)
+	if err != nil {
+		t.Fatalf("NewConverter() error = %v; want nil", err)
+	}
+	if _, err := c.Convert(strings.NewReader(input), &want); err != nil {
+		t.Fatalf("Convert() error = %v; want nil", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("ConvertFS() output differs from Convert()")
+	}
+
+	if _, err := seaflog.ConvertFS(fsys, "logs/missing.txt", &got, config); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ConvertFS() of missing file error = %v; want fs.ErrNotExist", err)
+	}
+}
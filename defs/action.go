@@ -0,0 +1,51 @@
+package defs
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ValueAction parses the value of an event line matched by an event form, for
+// value actions beyond the built-in ones. A returned error is recorded in the
+// event's Error, wrapped in a *ParseError.
+type ValueAction interface {
+	Value(line string, edef EventDef, eform EventForm) (interface{}, error)
+}
+
+// ValueActionFunc adapts a function to the ValueAction interface.
+type ValueActionFunc func(line string, edef EventDef, eform EventForm) (interface{}, error)
+
+// Value calls f.
+func (f ValueActionFunc) Value(line string, edef EventDef, eform EventForm) (interface{}, error) {
+	return f(line, edef, eform)
+}
+
+var (
+	customActionsMu sync.RWMutex
+	customActions   = make(map[string]ValueAction)
+)
+
+// RegisterValueAction registers action under name, e.g. "as_hexdump", for use
+// as an event form value_action. Register actions before parsing definitions
+// which use them, e.g. from an init function. Built-in and already registered
+// names can't be replaced.
+func RegisterValueAction(name string, action ValueAction) error {
+	if valueActions[name] {
+		return fmt.Errorf("value action %q is built in", name)
+	}
+	customActionsMu.Lock()
+	defer customActionsMu.Unlock()
+	if _, ok := customActions[name]; ok {
+		return fmt.Errorf("value action %q is already registered", name)
+	}
+	customActions[name] = action
+	return nil
+}
+
+// customAction returns the registered value action name.
+func customAction(name string) (ValueAction, bool) {
+	customActionsMu.RLock()
+	defer customActionsMu.RUnlock()
+	action, ok := customActions[name]
+	return action, ok
+}
@@ -0,0 +1,488 @@
+// Package defs holds SeaFlow V1 instrument log event definitions and the
+// Event type, and parses event lines according to those definitions.
+package defs
+
+import (
+	_ "embed" // for event definition JSON
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// init reads event definitions from JSON
+func init() {
+	var err error
+	EventDefs, PairDefs, err = ParseEventDefs([]byte(eventDefsJSON))
+	if err != nil {
+		panic(err)
+	}
+	IgnorePatterns, err = ParseIgnorePatterns([]byte(eventDefsJSON))
+	if err != nil {
+		panic(err)
+	}
+	IndexedDefs, err = ParseIndexedDefs([]byte(eventDefsJSON))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// valueActions are the built-in EventForm ValueAction values. Others may be
+// added with RegisterValueAction.
+var valueActions = map[string]bool{
+	"as_float": true, "as_text": true, "as_true": true, "as_false": true, "as_identity": true, "as_enum": true,
+	"as_float_extract": true,
+}
+
+// ParseEventDefs parses and validates event and pair definitions in the JSON
+// format of the embedded event definitions. Nothing is changed on error, so
+// callers can safely check replacement definitions before swapping them in.
+func ParseEventDefs(data []byte) (map[string]EventDef, []PairDef, error) {
+	result := struct {
+		Events []EventDef
+		Pairs  []PairDef
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, nil, err
+	}
+	events := []EventDef{}
+	for _, edef := range result.Events {
+		expanded, err := expandIndexed(edef)
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, expanded...)
+	}
+	edefs := make(map[string]EventDef)
+	for _, edef := range events {
+		if edef.Name == "" {
+			return nil, nil, fmt.Errorf("event definition with no name")
+		}
+		if _, ok := edefs[edef.Name]; ok {
+			return nil, nil, fmt.Errorf("duplicate event definition %q", edef.Name)
+		}
+		if (edef.Min != nil || edef.Max != nil) && edef.Type != "float" {
+			return nil, nil, fmt.Errorf("event definition %q of type %q has a min or max", edef.Name, edef.Type)
+		}
+		if edef.Min != nil && edef.Max != nil && *edef.Min > *edef.Max {
+			return nil, nil, fmt.Errorf("event definition %q has min %v above max %v", edef.Name, *edef.Min, *edef.Max)
+		}
+		if qc := edef.QC; qc != nil {
+			if edef.Type != "float" {
+				return nil, nil, fmt.Errorf("event definition %q of type %q has qc rules", edef.Name, edef.Type)
+			}
+			if qc.Min != nil && qc.Max != nil && *qc.Min > *qc.Max {
+				return nil, nil, fmt.Errorf("event definition %q has qc min %v above max %v", edef.Name, *qc.Min, *qc.Max)
+			}
+			if qc.Flatline < 0 || qc.Flatline == 1 {
+				return nil, nil, fmt.Errorf("event definition %q has qc flatline %d, want 0 or at least 2", edef.Name, qc.Flatline)
+			}
+		}
+		for _, eform := range edef.EventForms {
+			if eform.StartsWith == "" {
+				return nil, nil, fmt.Errorf("event definition %q has a form with no startswith", edef.Name)
+			}
+			if _, ok := customAction(eform.ValueAction); ok {
+				continue
+			}
+			if !valueActions[eform.ValueAction] {
+				return nil, nil, fmt.Errorf("event definition %q has invalid value_action %q", edef.Name, eform.ValueAction)
+			}
+			// One boolean event may have separate forms for true and false
+			boolAction := eform.ValueAction == "as_true" || eform.ValueAction == "as_false"
+			enumAction := eform.ValueAction == "as_enum"
+			if (edef.Type == "boolean") != boolAction || (edef.Type == "category") != enumAction {
+				return nil, nil, fmt.Errorf(
+					"event definition %q of type %q has value_action %q", edef.Name, edef.Type, eform.ValueAction,
+				)
+			}
+			if eform.ValueAction == "as_float_extract" {
+				if edef.Type != "float" {
+					return nil, nil, fmt.Errorf(
+						"event definition %q of type %q has value_action %q", edef.Name, edef.Type, eform.ValueAction,
+					)
+				}
+				re, err := regexp.Compile(eform.Pattern)
+				if err != nil || eform.Pattern == "" || re.NumSubexp() < 1 {
+					return nil, nil, fmt.Errorf(
+						"event definition %q needs a pattern with a capture group for value_action %q", edef.Name, eform.ValueAction,
+					)
+				}
+			}
+			if eform.MinVersion != "" && eform.MaxVersion != "" && CompareVersions(eform.MinVersion, eform.MaxVersion) >= 0 {
+				return nil, nil, fmt.Errorf(
+					"event definition %q has a form with min_version %q not before max_version %q",
+					edef.Name, eform.MinVersion, eform.MaxVersion,
+				)
+			}
+			if enumAction && len(edef.Values) == 0 {
+				return nil, nil, fmt.Errorf("event definition %q has value_action %q but no values", edef.Name, eform.ValueAction)
+			}
+		}
+		edefs[edef.Name] = edef
+	}
+	for _, pdef := range result.Pairs {
+		for _, b := range []PairBound{pdef.Start, pdef.Stop} {
+			if _, ok := edefs[b.Event]; !ok {
+				return nil, nil, fmt.Errorf("pair definition %q refers to unknown event %q", pdef.Name, b.Event)
+			}
+		}
+	}
+	return edefs, result.Pairs, nil
+}
+
+// ParseIgnorePatterns parses the ignore section of event definitions JSON, a
+// list of regular expressions for log lines to drop before event creation.
+func ParseIgnorePatterns(data []byte) ([]*regexp.Regexp, error) {
+	result := struct {
+		Ignore []string
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	patterns := make([]*regexp.Regexp, 0, len(result.Ignore))
+	for _, expr := range result.Ignore {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+//go:embed event_definitions.json
+var eventDefsJSON string
+
+// EventDefs hold event defintions keyed by name.
+var EventDefs map[string]EventDef
+
+// RestartEvent names the boolean event which marks a restart of the instrument
+// software partway through a log. It matches no lines, the scanner emits it
+// before the first banner line of a restart.
+const RestartEvent = "restart"
+
+// AlertEvent names the text event emitted when a correlation rule fires or a
+// drift check fails. It matches no lines.
+const AlertEvent = "alert"
+
+// Names of the banner events which report versions of the instrument software
+// and firmware.
+const (
+	SoftwareVersionEvent = "software_version"
+	FirmwareVersionEvent = "firmware_version"
+)
+
+// EventDef defines a log file event
+type EventDef struct {
+	Name       string
+	Type       string
+	EventForms []EventForm `json:"forms"`
+	Counter    *CounterDef `json:"counter"` // set for counter-like float events
+	Unit       string      // unit of float values as logged
+	Setting    bool        // true for instrument settings, e.g. PMT voltages
+	Fault      bool        // true for instrument fault reports
+	// Banner is true for lines of the header written when the instrument
+	// software starts. Banner lines after a timestamp line mark a restart.
+	Banner bool
+	// FloatFormat is an optional fmt verb for float values, e.g. "%.2f",
+	// overriding the writer's global float format.
+	FloatFormat string `json:"float_format"`
+	// Missing lists sentinel float values the instrument logs in place of a
+	// missing value, e.g. -999. These are parsed as a nil Value.
+	Missing []float64
+	// Values lists the allowed values of a category event, parsed with the
+	// as_enum value action.
+	Values []string
+	// Min and Max optionally bound the physically possible float values of
+	// the event, inclusive. Values outside are parsed with an ErrOutOfRange
+	// error. Infinite and NaN values are left to the scanner's policy.
+	Min *float64
+	Max *float64
+	// QC holds optional quality control rules for float values, which flag
+	// suspect values rather than rejecting them.
+	QC *QCDef `json:"qc"`
+	// Indexes makes this an indexed definition for a set of channels, e.g.
+	// PMT1 to PMT8, expanded by ParseEventDefs into one definition per index
+	// with {n} in the name and forms replaced by the index. EventDefs only
+	// holds expanded definitions.
+	Indexes []int
+}
+
+// EventForm defines a form of an event with a unique line prefix.
+type EventForm struct {
+	StartsWith  string `json:"startswith"`
+	ValueAction string `json:"value_action"`
+	// TimeFromValue is an optional Go time layout for a timestamp embedded in
+	// the event line. If set, the embedded time overrides the time of the
+	// preceding timestamp line. Layouts without a time zone use the time zone
+	// of the preceding timestamp line.
+	TimeFromValue string `json:"time_from_value"`
+	// Pattern is a regular expression for the as_float_extract value action.
+	// Its first capture group is parsed as the float value, e.g.
+	// "to ([0-9.]+) mL/min" for "Set flow rate to 0.25 mL/min OK".
+	Pattern string
+	// MinVersion and MaxVersion limit this form to lines logged by instrument
+	// software versions in [MinVersion, MaxVersion), for event formats which
+	// changed between versions. Either may be empty for no limit. Versions
+	// are compared as dot separated numbers, e.g. 2.10 is after 2.9.
+	MinVersion string `json:"min_version"`
+	MaxVersion string `json:"max_version"`
+	Examples   []EventExample
+}
+
+// EventExample contains example input and parsed data for an Event.
+type EventExample struct {
+	Text   string
+	Parsed Event
+}
+
+// Event is parsed log file event
+type Event struct {
+	Name       string
+	Type       string
+	Line       string
+	Value      interface{}
+	Time       time.Time
+	LineNumber int `json:"line_number"`
+	// Seq is a sequence number which strictly increases in log order,
+	// starting at 1. It is set by the scanner.
+	Seq int `json:"seq"`
+	// Source names the log file the event was read from, if known. It is set
+	// by the scanner.
+	Source string `json:"source"`
+	// Instrument identifies the instrument which logged the event, if known.
+	Instrument string `json:"instrument"`
+	Error      error
+}
+
+// CreateEvent creates an event
+func CreateEvent(line string, t time.Time, lineNumber int) (event Event, err error) {
+	return createEvent(line, t, lineNumber, matchLine)
+}
+
+// matchLine returns the event definition and form whose prefix matches line,
+// including extra channels of indexed definitions, for any software version.
+func matchLine(line string) (EventDef, EventForm, bool) {
+	return matchVersion(line, "")
+}
+
+// matchVersion returns the event definition and form whose prefix matches
+// line, skipping forms for software versions other than version. Forms for
+// every version match if version is empty.
+func matchVersion(line string, version string) (EventDef, EventForm, bool) {
+	for _, edef := range EventDefs {
+		for _, eform := range edef.EventForms {
+			if strings.HasPrefix(line, eform.StartsWith) && eform.ForVersion(version) {
+				return edef, eform, true
+			}
+		}
+	}
+	return ExtraChannel(line)
+}
+
+// createEvent creates an event using match to find its definition.
+func createEvent(line string, t time.Time, lineNumber int, match func(string) (EventDef, EventForm, bool)) (event Event, err error) {
+	event = Event{Time: t, Line: line, LineNumber: lineNumber}
+
+	// Handle case where this event occurs before any timestamp
+	if event.Time.IsZero() {
+		event.Error = parseError(event, ErrNoTimestamp)
+		return event, nil
+	}
+
+	// Parse the line
+	if edef, eform, ok := match(line); ok {
+		event.Name = edef.Name
+		event.Type = edef.Type
+		switch valueAction := eform.ValueAction; valueAction {
+		case "as_float":
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) < 2 {
+				event.Error = parseError(event, ErrMissingSeparator)
+			} else {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+					event.Error = parseError(event, fmt.Errorf("%w: %v", ErrBadFloat, err))
+				} else if !isMissing(f, edef.Missing) {
+					event.Value = f
+				}
+			}
+		case "as_float_extract":
+			m := extractExpr(eform.Pattern).FindStringSubmatch(line)
+			if m == nil {
+				event.Error = parseError(event, fmt.Errorf("%w: no match for %q", ErrBadFloat, eform.Pattern))
+			} else if f, err := strconv.ParseFloat(strings.TrimSpace(m[1]), 64); err != nil {
+				event.Error = parseError(event, fmt.Errorf("%w: %v", ErrBadFloat, err))
+			} else if !isMissing(f, edef.Missing) {
+				event.Value = f
+			}
+		case "as_text":
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) < 2 {
+				event.Error = parseError(event, ErrMissingSeparator)
+			} else {
+				event.Value = strings.TrimSpace(parts[1])
+			}
+		case "as_enum":
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) < 2 {
+				event.Error = parseError(event, ErrMissingSeparator)
+			} else if v := strings.TrimSpace(parts[1]); !allowed(v, edef.Values) {
+				event.Error = parseError(event, fmt.Errorf("%w: %q", ErrBadEnum, v))
+			} else {
+				event.Value = v
+			}
+		case "as_true":
+			event.Value = true
+		case "as_false":
+			event.Value = false
+		case "as_identity":
+			event.Value = line
+		default:
+			action, ok := customAction(valueAction)
+			if !ok {
+				// Should never happen
+				return event, fmt.Errorf("invalid ValueAction in event defintiion: %v", valueAction)
+			}
+			if v, err := action.Value(line, edef, eform); err != nil {
+				event.Error = parseError(event, err)
+			} else {
+				event.Value = v
+			}
+		}
+		if f, ok := event.Value.(float64); ok && event.Error == nil {
+			if err := edef.checkRange(f); err != nil {
+				event.Error = parseError(event, err)
+			}
+		}
+		if eform.TimeFromValue != "" && event.Error == nil {
+			if tv, err := timeFromLine(line, eform.TimeFromValue, event.Time.Location()); err != nil {
+				event.Error = parseError(event, err)
+			} else {
+				event.Time = tv
+			}
+		}
+		return event, nil
+	}
+
+	// No prefix matched, mark as unhandled
+	event.Error = parseError(event, ErrUnrecognized)
+	event.Name = "unhandled"
+	event.Type = "text"
+	event.Value = event.Line
+
+	return event, nil
+}
+
+// extractExprs caches compiled as_float_extract patterns
+var extractExprs sync.Map
+
+// extractExpr returns the compiled regular expression for an
+// as_float_extract pattern. Patterns from ParseEventDefs are known to be
+// valid, others which don't compile match nothing.
+func extractExpr(pattern string) *regexp.Regexp {
+	if re, ok := extractExprs.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil || re.NumSubexp() < 1 {
+		re = regexp.MustCompile(`$^()`)
+	}
+	extractExprs.Store(pattern, re)
+	return re
+}
+
+// checkRange returns an ErrOutOfRange error if f is outside the range of
+// edef. Infinite and NaN values are never out of range.
+func (edef EventDef) checkRange(f float64) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil
+	}
+	if edef.Min != nil && f < *edef.Min {
+		return fmt.Errorf("%w: %v is below the minimum %v", ErrOutOfRange, f, *edef.Min)
+	}
+	if edef.Max != nil && f > *edef.Max {
+		return fmt.Errorf("%w: %v is above the maximum %v", ErrOutOfRange, f, *edef.Max)
+	}
+	return nil
+}
+
+// allowed returns true if v is one of values.
+func allowed(v string, values []string) bool {
+	for _, a := range values {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+// isMissing returns true if f is one of the missing value sentinels.
+func isMissing(f float64, sentinels []float64) bool {
+	for _, m := range sentinels {
+		if f == m {
+			return true
+		}
+	}
+	return false
+}
+
+// timeFromLine finds and parses the right-most timestamp in line with layout.
+// Layout must produce fixed width output, e.g. zero-padded numeric fields.
+func timeFromLine(line string, layout string, loc *time.Location) (time.Time, error) {
+	// time.Parse reads a comma after seconds as a decimal separator, which
+	// breaks layouts like "15:04:05,02012006", so swap commas for spaces.
+	layout = strings.ReplaceAll(layout, ",", " ")
+	line = strings.ReplaceAll(line, ",", " ")
+	for i := len(line) - len(layout); i >= 0; i-- {
+		if t, err := time.ParseInLocation(layout, line[i:i+len(layout)], loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w with layout %q", ErrNoTimestamp, layout)
+}
+
+// IgnorePatterns match log lines to drop before event creation, from the
+// embedded event definitions.
+var IgnorePatterns []*regexp.Regexp
+
+// PairDefs hold interval definitions from the embedded event definitions.
+var PairDefs []PairDef
+
+// PairDef defines an interval bounded by a start event and a stop event.
+type PairDef struct {
+	Name  string
+	Start PairBound
+	Stop  PairBound
+}
+
+// PairBound identifies the event which starts or stops an interval. If Value
+// is nil any value of the named event matches.
+type PairBound struct {
+	Event string
+	Value interface{}
+}
+
+// QCDef holds quality control rules for a float event, used to flag values
+// following the IOOS QARTOD convention.
+type QCDef struct {
+	// Min and Max bound plausible values, inclusive. Values outside fail.
+	Min *float64
+	Max *float64
+	// Flatline is the number of consecutive identical values at which the
+	// value is considered stuck and further repeats are suspect, 0 for no
+	// check.
+	Flatline int
+}
+
+// CounterDef marks an event as a counter whose value only increases, except
+// for rollovers and resets.
+type CounterDef struct {
+	// Rollover is the modulus of a counter which wraps around to zero, e.g.
+	// 65536 for a 16-bit counter. If zero, any decrease is a reset.
+	Rollover float64
+}
@@ -0,0 +1,498 @@
+package defs_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+func TestParseEventDefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			json: `{"events": [{"name": "a", "type": "float", "forms": [{"startswith": "a:", "value_action": "as_float"}]}],
+				"pairs": [{"name": "p", "start": {"event": "a"}, "stop": {"event": "a"}}]}`,
+		},
+		{
+			name:    "bad json",
+			json:    `{"events": [`,
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			json: `{"events": [{"name": "a", "type": "float", "forms": [{"startswith": "a:", "value_action": "as_float"}]},
+				{"name": "a", "type": "float", "forms": [{"startswith": "b:", "value_action": "as_float"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid value_action",
+			json:    `{"events": [{"name": "a", "type": "float", "forms": [{"startswith": "a:", "value_action": "as_int"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "boolean without as_true or as_false",
+			json:    `{"events": [{"name": "a", "type": "boolean", "forms": [{"startswith": "a:", "value_action": "as_text"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "boolean true and false forms",
+			json: `{"events": [{"name": "a", "type": "boolean", "forms": [{"startswith": "a on", "value_action": "as_true"},
+				{"startswith": "a off", "value_action": "as_false"}]}]}`,
+		},
+		{
+			name:    "as_true for float",
+			json:    `{"events": [{"name": "a", "type": "float", "forms": [{"startswith": "a:", "value_action": "as_true"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "category",
+			json: `{"events": [{"name": "a", "type": "category", "values": ["x", "y"],
+				"forms": [{"startswith": "a:", "value_action": "as_enum"}]}]}`,
+		},
+		{
+			name:    "category without values",
+			json:    `{"events": [{"name": "a", "type": "category", "forms": [{"startswith": "a:", "value_action": "as_enum"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "as_enum for text",
+			json:    `{"events": [{"name": "a", "type": "text", "values": ["x"], "forms": [{"startswith": "a:", "value_action": "as_enum"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "float extract",
+			json: `{"events": [{"name": "a", "type": "float",
+				"forms": [{"startswith": "Set flow", "value_action": "as_float_extract", "pattern": "to ([0-9.]+) mL/min"}]}]}`,
+		},
+		{
+			name: "float extract without capture group",
+			json: `{"events": [{"name": "a", "type": "float",
+				"forms": [{"startswith": "Set flow", "value_action": "as_float_extract", "pattern": "to [0-9.]+"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "version range",
+			json: `{"events": [{"name": "a", "type": "float",
+				"forms": [{"startswith": "a:", "value_action": "as_float", "min_version": "2.9", "max_version": "2.10"}]}]}`,
+		},
+		{
+			name: "empty version range",
+			json: `{"events": [{"name": "a", "type": "float",
+				"forms": [{"startswith": "a:", "value_action": "as_float", "min_version": "3", "max_version": "3.0"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "range",
+			json: `{"events": [{"name": "a", "type": "float", "min": 0, "max": 10,
+				"forms": [{"startswith": "a:", "value_action": "as_float"}]}]}`,
+		},
+		{
+			name: "min above max",
+			json: `{"events": [{"name": "a", "type": "float", "min": 10, "max": 0,
+				"forms": [{"startswith": "a:", "value_action": "as_float"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "range for text",
+			json:    `{"events": [{"name": "a", "type": "text", "max": 10, "forms": [{"startswith": "a:", "value_action": "as_text"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "qc",
+			json: `{"events": [{"name": "a", "type": "float", "qc": {"min": 0, "max": 10, "flatline": 5},
+				"forms": [{"startswith": "a:", "value_action": "as_float"}]}]}`,
+		},
+		{
+			name: "qc min above max",
+			json: `{"events": [{"name": "a", "type": "float", "qc": {"min": 10, "max": 0},
+				"forms": [{"startswith": "a:", "value_action": "as_float"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name: "qc flatline of one",
+			json: `{"events": [{"name": "a", "type": "float", "qc": {"flatline": 1},
+				"forms": [{"startswith": "a:", "value_action": "as_float"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "qc for text",
+			json:    `{"events": [{"name": "a", "type": "text", "qc": {"max": 10}, "forms": [{"startswith": "a:", "value_action": "as_text"}]}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown pair event",
+			json:    `{"events": [], "pairs": [{"name": "p", "start": {"event": "a"}, "stop": {"event": "a"}}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edefs, _, err := defs.ParseEventDefs([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEventDefs() error = %v; want error %v", err, tt.wantErr)
+			}
+			if err == nil && len(edefs) != 1 {
+				t.Errorf("len(ParseEventDefs()) %v; want 1", len(edefs))
+			}
+		})
+	}
+}
+
+func TestIndexedEventDefs(t *testing.T) {
+	edefs, _, err := defs.ParseEventDefs([]byte(`{"events": [{"name": "PMT{n}", "indexes": [1, 2], "type": "float",
+		"forms": [{"startswith": "PMT{n}:", "value_action": "as_float",
+			"examples": [{"text": "PMT{n}:1", "parsed": {"name": "PMT{n}", "line": "PMT{n}:1"}}]}]}]}`))
+	if err != nil {
+		t.Fatalf("ParseEventDefs() error = %v; want nil", err)
+	}
+	if len(edefs) != 2 {
+		t.Fatalf("len(ParseEventDefs()) %v; want 2", len(edefs))
+	}
+	pmt2 := edefs["PMT2"]
+	if pmt2.EventForms[0].StartsWith != "PMT2:" || pmt2.Indexes != nil {
+		t.Errorf("PMT2 %+v; want startswith PMT2: and no indexes", pmt2)
+	}
+	if ex := pmt2.EventForms[0].Examples[0]; ex.Text != "PMT2:1" || ex.Parsed.Name != "PMT2" || ex.Parsed.Line != "PMT2:1" {
+		t.Errorf("PMT2 example %+v; want PMT2 text, name, and line", ex)
+	}
+	if edefs["PMT1"].EventForms[0].StartsWith != "PMT1:" {
+		t.Errorf("PMT1 startswith %q; want PMT1:", edefs["PMT1"].EventForms[0].StartsWith)
+	}
+
+	for name, data := range map[string]string{
+		"no placeholder": `{"events": [{"name": "PMT", "indexes": [1], "type": "float",
+			"forms": [{"startswith": "PMT:", "value_action": "as_float"}]}]}`,
+		"duplicate expansion": `{"events": [{"name": "PMT{n}", "indexes": [1, 1], "type": "float",
+			"forms": [{"startswith": "PMT{n}:", "value_action": "as_float"}]}]}`,
+	} {
+		if _, _, err := defs.ParseEventDefs([]byte(data)); err == nil {
+			t.Errorf("ParseEventDefs() %s error = nil; want error", name)
+		}
+	}
+}
+
+func init() {
+	// as_hexdump parses the text after ':' as hex bytes
+	hexdump := defs.ValueActionFunc(func(line string, edef defs.EventDef, eform defs.EventForm) (interface{}, error) {
+		b, err := hex.DecodeString(strings.TrimSpace(strings.TrimPrefix(line, eform.StartsWith)))
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("%v", b), nil
+	})
+	if err := defs.RegisterValueAction("as_hexdump", hexdump); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterValueAction(t *testing.T) {
+	if err := defs.RegisterValueAction("as_float", nil); err == nil {
+		t.Errorf("RegisterValueAction(as_float) error = nil; want error for a built-in action")
+	}
+	if err := defs.RegisterValueAction("as_hexdump", nil); err == nil {
+		t.Errorf("RegisterValueAction(as_hexdump) error = nil; want error for a registered action")
+	}
+
+	edefs, _, err := defs.ParseEventDefs([]byte(
+		`{"events": [{"name": "hex_test", "type": "text", "forms": [{"startswith": "hex:", "value_action": "as_hexdump"}]}]}`,
+	))
+	if err != nil {
+		t.Fatalf("ParseEventDefs() error = %v; want nil", err)
+	}
+	defs.EventDefs["hex_test"] = edefs["hex_test"]
+	defer delete(defs.EventDefs, "hex_test")
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+
+	event, err := defs.CreateEvent("hex: 0a0b", t0, 1)
+	if err != nil || event.Error != nil || event.Value != "[10 11]" {
+		t.Errorf("CreateEvent() %+v, %v; want value [10 11]", event, err)
+	}
+	event, err = defs.CreateEvent("hex: zz", t0, 2)
+	var perr *defs.ParseError
+	if err != nil || !errors.As(event.Error, &perr) {
+		t.Errorf("CreateEvent() error %v, %v; want a *ParseError", event.Error, err)
+	}
+}
+
+func TestParseIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    int
+		wantErr bool
+	}{
+		{name: "none", json: `{"events": []}`, want: 0},
+		{name: "valid", json: `{"ignore": ["^Fault:$", "^DEBUG "], "events": []}`, want: 2},
+		{name: "invalid regexp", json: `{"ignore": ["("]}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := defs.ParseIgnorePatterns([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIgnorePatterns() error = %v; want error %v", err, tt.wantErr)
+			}
+			if len(patterns) != tt.want {
+				t.Errorf("len(ParseIgnorePatterns()) %v; want %v", len(patterns), tt.want)
+			}
+		})
+	}
+}
+
+func TestExtraChannel(t *testing.T) {
+	tests := []struct {
+		line string
+		want string // "" for no extra channel
+	}{
+		{"PMT9:1.05", "PMT9"},
+		{"PMT12:1.05", "PMT12"},
+		{"PMT1:1.05", ""}, // defined
+		{"PMT09:1.05", ""},
+		{"PMT9 1.05", ""},
+		{"PMT:1.05", ""},
+		{"ALL PMT:1.05", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			edef, eform, ok := defs.ExtraChannel(tt.line)
+			if got := edef.Name; got != tt.want || ok != (tt.want != "") {
+				t.Fatalf("ExtraChannel() %q, %v; want %q", got, ok, tt.want)
+			}
+			if ok && (eform.StartsWith != tt.want+":" || edef.Unit != "V") {
+				t.Errorf("ExtraChannel() form %+v, unit %q; want startswith %s: and unit V", eform, edef.Unit, tt.want)
+			}
+		})
+	}
+
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	event, err := defs.NewMatcher().CreateEvent("PMT9:1.05", t0, 1)
+	if err != nil || event.Error != nil || event.Name != "PMT9" || event.Value != 1.05 {
+		t.Errorf("Matcher.CreateEvent() %+v, %v; want PMT9 1.05", event, err)
+	}
+	if edef, ok := defs.Lookup("PMT9"); !ok || edef.Name != "PMT9" {
+		t.Errorf("Lookup(PMT9) %+v, %v; want PMT9", edef, ok)
+	}
+	if _, ok := defs.Lookup("PMT9x"); ok {
+		t.Errorf("Lookup(PMT9x) ok; want not found")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	lines := []string{
+		"PMT1:1.05", "PMT1:2", "trigger level:-2.10", "Stream pressure locked.", "Stream pressure unlocked.",
+		"Pump over 25 psi, check setting or nozzle clog, 12:31:06,02082015", "not a real event", "PMT1:x",
+	}
+	m := defs.NewMatcher()
+	for i := 0; i < 2; i++ { // second pass hits the cache
+		for _, line := range lines {
+			want, _ := defs.CreateEvent(line, t0, 1)
+			got, err := m.CreateEvent(line, t0, 1)
+			if err != nil {
+				t.Fatalf("Matcher.CreateEvent() error = %v; want nil", err)
+			}
+			if got.Name != want.Name || got.Value != want.Value || (got.Error == nil) != (want.Error == nil) {
+				t.Errorf("Matcher.CreateEvent(%q) %+v; want %+v", line, got, want)
+			}
+		}
+	}
+}
+
+func TestMatcherAddDefs(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	m := defs.NewMatcher()
+	if got, _ := m.CreateEvent("Sheath Temp: 21.5", t0, 1); got.Error == nil {
+		t.Fatalf("Matcher.CreateEvent() before AddDefs %+v; want an unrecognized error", got)
+	}
+	m.AddDefs(defs.EventDef{
+		Name: "sheath_temp", Type: "float",
+		EventForms: []defs.EventForm{{StartsWith: "Sheath Temp:", ValueAction: "as_float"}},
+	})
+	got, err := m.CreateEvent("Sheath Temp: 21.5", t0, 1)
+	if err != nil {
+		t.Fatalf("Matcher.CreateEvent() error = %v; want nil", err)
+	}
+	if got.Name != "sheath_temp" || got.Value != 21.5 || got.Error != nil {
+		t.Errorf("Matcher.CreateEvent() %+v; want sheath_temp 21.5", got)
+	}
+	if _, ok := defs.EventDefs["sheath_temp"]; ok {
+		t.Errorf("EventDefs has sheath_temp after AddDefs; want it unchanged")
+	}
+}
+
+func TestFloatExtract(t *testing.T) {
+	defs.EventDefs["extract_test"] = defs.EventDef{
+		Name: "extract_test",
+		Type: "float",
+		EventForms: []defs.EventForm{
+			{StartsWith: "Set flow rate", ValueAction: "as_float_extract", Pattern: `to ([0-9.]+) mL/min`},
+		},
+	}
+	defer delete(defs.EventDefs, "extract_test")
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+
+	event, err := defs.CreateEvent("Set flow rate to 0.25 mL/min OK", t0, 1)
+	if err != nil || event.Error != nil || event.Value != 0.25 {
+		t.Errorf("CreateEvent() %+v, %v; want value 0.25", event, err)
+	}
+	event, err = defs.CreateEvent("Set flow rate to max", t0, 2)
+	if err != nil || !errors.Is(event.Error, defs.ErrBadFloat) {
+		t.Errorf("CreateEvent() error %v, %v; want %v", event.Error, err, defs.ErrBadFloat)
+	}
+}
+
+var benchLines = []string{
+	"PMT1:1.05", "PMT2:1.10", "laser: 1", "write evt: 1", "trigger level:-2.10", "Stream pressure locked.",
+}
+
+func BenchmarkCreateEvent(b *testing.B) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	for i := 0; i < b.N; i++ {
+		_, _ = defs.CreateEvent(benchLines[i%len(benchLines)], t0, i)
+	}
+}
+
+func BenchmarkMatcherCreateEvent(b *testing.B) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	m := defs.NewMatcher()
+	for i := 0; i < b.N; i++ {
+		_, _ = m.CreateEvent(benchLines[i%len(benchLines)], t0, i)
+	}
+}
+
+func TestEventPool(t *testing.T) {
+	e := defs.AcquireEvent()
+	e.Name = "PMT1"
+	e.Value = 1.05
+	defs.ReleaseEvent(e)
+	e = defs.AcquireEvent()
+	if e.Name != "" || e.Value != nil {
+		t.Errorf("AcquireEvent() %+v; want a zero Event", e)
+	}
+	defs.ReleaseEvent(e)
+}
+
+// sink keeps benchmark results from being optimized away.
+var sink *defs.Event
+
+func BenchmarkNewEvent(b *testing.B) {
+	b.ReportAllocs()
+	events := make(chan *defs.Event, 1)
+	for i := 0; i < b.N; i++ {
+		e := new(defs.Event)
+		e.Name = "PMT1"
+		e.LineNumber = i
+		events <- e
+		sink = <-events
+	}
+}
+
+func BenchmarkAcquireEvent(b *testing.B) {
+	b.ReportAllocs()
+	events := make(chan *defs.Event, 1)
+	for i := 0; i < b.N; i++ {
+		e := defs.AcquireEvent()
+		e.Name = "PMT1"
+		e.LineNumber = i
+		events <- e
+		sink = <-events
+		defs.ReleaseEvent(sink)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.9", "2.10", -1},
+		{"2.10", "2.9", 1},
+		{"2", "2.0.0", 0},
+		{"2.0.1", "2", 1},
+		{"1.2b", "1.2a", 1},
+	}
+	for _, tt := range tests {
+		if got := defs.CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherVersion(t *testing.T) {
+	defs.EventDefs["test_flow"] = defs.EventDef{
+		Name: "test_flow",
+		Type: "float",
+		EventForms: []defs.EventForm{
+			{StartsWith: "flow:", ValueAction: "as_float", MaxVersion: "2"},
+			{StartsWith: "flow:", ValueAction: "as_float_extract", Pattern: "([0-9.]+) mL", MinVersion: "2"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_flow")
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+
+	tests := []struct {
+		version string
+		line    string
+		want    interface{}
+	}{
+		{"1.5", "flow: 0.25", 0.25},
+		{"2.1", "flow: set to 0.5 mL/min", 0.5},
+		{"1.5", "flow: 1", 1.0},
+	}
+	m := defs.NewMatcher()
+	for _, tt := range tests {
+		m.SetVersion(tt.version)
+		got, err := m.CreateEvent(tt.line, t0, 1)
+		if err != nil || got.Error != nil {
+			t.Fatalf("Matcher.CreateEvent(%q) version %s error %v, %v", tt.line, tt.version, err, got.Error)
+		}
+		if got.Value != tt.want {
+			t.Errorf("Matcher.CreateEvent(%q) version %s Value %v; want %v", tt.line, tt.version, got.Value, tt.want)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	lo, hi := 0.0, 10.0
+	defs.EventDefs["test_pmt"] = defs.EventDef{
+		Name: "test_pmt",
+		Type: "float",
+		Min:  &lo,
+		Max:  &hi,
+		EventForms: []defs.EventForm{
+			{StartsWith: "test pmt:", ValueAction: "as_float"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_pmt")
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+
+	tests := []struct {
+		line       string
+		outOfRange bool
+	}{
+		{"test pmt:0", false},
+		{"test pmt:10", false},
+		{"test pmt:1e1", false},
+		{"test pmt:-0.5", true},
+		{"test pmt:1.2E3", true},
+		{"test pmt:inf", false}, // left to the scanner's special float policy
+		{"test pmt:NaN", false},
+	}
+	for _, tt := range tests {
+		event, err := defs.CreateEvent(tt.line, t0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := errors.Is(event.Error, defs.ErrOutOfRange); got != tt.outOfRange {
+			t.Errorf("CreateEvent(%q) Error %v; want out of range %v", tt.line, event.Error, tt.outOfRange)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package defs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for event parsing. Event.Error wraps one of these in a
+// *ParseError, so callers can test the kind of error with errors.Is.
+var (
+	// ErrNoTimestamp marks an event with no time, either because it occurs
+	// before the first timestamp line or because an embedded timestamp could
+	// not be parsed.
+	ErrNoTimestamp = errors.New("no timestamp")
+	// ErrBadFloat marks an event whose value could not be parsed as a float.
+	ErrBadFloat = errors.New("bad float value")
+	// ErrMissingSeparator marks an event line without the expected ':'
+	// separator between name and value.
+	ErrMissingSeparator = errors.New("missing expected separator ':'")
+	// ErrBadEnum marks an event whose value is not one of the allowed values
+	// of its category event definition.
+	ErrBadEnum = errors.New("value not allowed")
+	// ErrOutOfRange marks a float value outside the physically possible
+	// range of its event definition.
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrSpecialFloat marks an infinite or NaN float value, e.g. "inf" or
+	// "nan", if the scanner is set to treat these as errors.
+	ErrSpecialFloat = errors.New("infinite or NaN value")
+	// ErrUnrecognized marks a line which matches no event definition.
+	ErrUnrecognized = errors.New("unrecognized event")
+)
+
+// ParseError records an event parsing error along with the line it occurred
+// on.
+type ParseError struct {
+	LineNumber int
+	Line       string
+	Err        error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, %v", e.LineNumber, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError wraps err with the line context of event.
+func parseError(event Event, err error) *ParseError {
+	return &ParseError{LineNumber: event.LineNumber, Line: event.Line, Err: err}
+}
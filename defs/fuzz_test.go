@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package defs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// FuzzCreateEvent checks that no line crashes event parsing.
+func FuzzCreateEvent(f *testing.F) {
+	for _, edef := range defs.EventDefs {
+		for _, eform := range edef.EventForms {
+			for _, ex := range eform.Examples {
+				f.Add(ex.Text)
+			}
+		}
+	}
+	f.Add("PMT9:1.0")
+	f.Add("PMT01:1.0")
+	f.Add("not a real event data line")
+	t0 := time.Date(2015, 3, 14, 0, 26, 52, 0, time.UTC)
+	f.Fuzz(func(t *testing.T, line string) {
+		event, err := defs.CreateEvent(line, t0, 1)
+		if err != nil {
+			t.Fatalf("CreateEvent(%q) error = %v; want nil", line, err)
+		}
+		if event.Error == nil && event.Name == "" {
+			t.Errorf("CreateEvent(%q) Event.Name empty without an error", line)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package defs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// indexPlaceholder is replaced by each index of an indexed event definition
+const indexPlaceholder = "{n}"
+
+// expandIndexed expands an indexed event definition, e.g. "PMT{n}" with
+// indexes 1 to 8, into one definition per index, replacing {n} in names,
+// line prefixes, patterns, and examples. Other definitions are returned as
+// is.
+func expandIndexed(edef EventDef) ([]EventDef, error) {
+	if len(edef.Indexes) == 0 {
+		return []EventDef{edef}, nil
+	}
+	if !strings.Contains(edef.Name, indexPlaceholder) {
+		return nil, fmt.Errorf("indexed event definition %q has no %s in its name", edef.Name, indexPlaceholder)
+	}
+	expanded := make([]EventDef, 0, len(edef.Indexes))
+	for _, i := range edef.Indexes {
+		r := strings.NewReplacer(indexPlaceholder, strconv.Itoa(i))
+		d := edef
+		d.Indexes = nil
+		d.Name = r.Replace(edef.Name)
+		d.EventForms = make([]EventForm, len(edef.EventForms))
+		for j, eform := range edef.EventForms {
+			eform.StartsWith = r.Replace(eform.StartsWith)
+			eform.Pattern = r.Replace(eform.Pattern)
+			eform.Examples = make([]EventExample, len(edef.EventForms[j].Examples))
+			for k, ex := range edef.EventForms[j].Examples {
+				ex.Text = r.Replace(ex.Text)
+				ex.Parsed.Name = r.Replace(ex.Parsed.Name)
+				ex.Parsed.Line = r.Replace(ex.Parsed.Line)
+				eform.Examples[k] = ex
+			}
+			d.EventForms[j] = eform
+		}
+		expanded = append(expanded, d)
+	}
+	return expanded, nil
+}
+
+// IndexedDefs hold the indexed event definitions from the embedded event
+// definitions, before expansion, e.g. PMT{n}.
+var IndexedDefs []EventDef
+
+// ParseIndexedDefs returns the indexed event definitions in event definitions
+// JSON, before expansion. Use ParseEventDefs to validate them.
+func ParseIndexedDefs(data []byte) ([]EventDef, error) {
+	result := struct {
+		Events []EventDef
+	}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	indexed := []EventDef{}
+	for _, edef := range result.Events {
+		if len(edef.Indexes) > 0 {
+			indexed = append(indexed, edef)
+		}
+	}
+	return indexed, nil
+}
+
+// ExtraChannel matches line against indexed event definitions for a channel
+// index outside the defined indexes, e.g. "PMT9:" on a modified instrument
+// when PMT{n} defines PMT1 to PMT8. It returns the definition and form
+// expanded for that index.
+func ExtraChannel(line string) (EventDef, EventForm, bool) {
+	for _, idef := range IndexedDefs {
+		for j, eform := range idef.EventForms {
+			n, ok := channelIndex(line, eform.StartsWith, true)
+			if !ok || hasIndex(idef.Indexes, n) {
+				continue
+			}
+			if edef, ok := expandIndex(idef, n); ok {
+				return edef, edef.EventForms[j], true
+			}
+		}
+	}
+	return EventDef{}, EventForm{}, false
+}
+
+// Lookup returns the event definition for name from EventDefs, or for an
+// extra channel of an indexed definition, e.g. PMT9 for PMT{n}.
+func Lookup(name string) (EventDef, bool) {
+	if edef, ok := EventDefs[name]; ok {
+		return edef, true
+	}
+	for _, idef := range IndexedDefs {
+		if n, ok := channelIndex(name, idef.Name, false); ok {
+			return expandIndex(idef, n)
+		}
+	}
+	return EventDef{}, false
+}
+
+// channelIndex returns the index in s of template with one {n} placeholder.
+// If prefix is true s need only start with the expanded template, otherwise it
+// must match it exactly.
+func channelIndex(s string, template string, prefix bool) (int, bool) {
+	parts := strings.SplitN(template, indexPlaceholder, 2)
+	if len(parts) != 2 || !strings.HasPrefix(s, parts[0]) {
+		return 0, false
+	}
+	rest := s[len(parts[0]):]
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	rest = rest[digits:]
+	if !strings.HasPrefix(rest, parts[1]) || (!prefix && rest != parts[1]) {
+		return 0, false
+	}
+	// Indexes are written without leading zeros
+	text := s[len(parts[0]) : len(parts[0])+digits]
+	n, err := strconv.Atoi(text)
+	return n, err == nil && strconv.Itoa(n) == text
+}
+
+// expandIndex expands indexed definition idef for index n.
+func expandIndex(idef EventDef, n int) (EventDef, bool) {
+	idef.Indexes = []int{n}
+	expanded, err := expandIndexed(idef)
+	if err != nil {
+		return EventDef{}, false
+	}
+	return expanded[0], true
+}
+
+// hasIndex returns true if n is in indexes.
+func hasIndex(indexes []int, n int) bool {
+	for _, i := range indexes {
+		if i == n {
+			return true
+		}
+	}
+	return false
+}
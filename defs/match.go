@@ -0,0 +1,108 @@
+package defs
+
+import (
+	"strings"
+	"time"
+)
+
+// maxCachedPrefixes bounds the size of a Matcher's cache, since lines without
+// a ':' separator are cached by their whole text.
+const maxCachedPrefixes = 4096
+
+type cachedMatch struct {
+	edef  EventDef
+	eform EventForm
+	ok    bool
+}
+
+// Matcher creates events like CreateEvent, but caches which event definition
+// matches each line prefix, up to and including the first ':', so that
+// repeated event types are matched without searching all definitions. Changes
+// to EventDefs after a Matcher is created may not be seen by it.
+type Matcher struct {
+	cache   map[string]cachedMatch
+	version string     // instrument software version, empty if unknown
+	extra   []EventDef // definitions matched after EventDefs
+}
+
+// NewMatcher creates a Matcher with an empty cache.
+func NewMatcher() *Matcher {
+	return &Matcher{cache: make(map[string]cachedMatch)}
+}
+
+// SetVersion selects the event forms for instrument software version, empty
+// for forms of every version. Changing the version clears the cache.
+func (m *Matcher) SetVersion(version string) {
+	if version != m.version {
+		m.version = version
+		m.cache = make(map[string]cachedMatch)
+	}
+}
+
+// AddDefs adds definitions this Matcher matches lines with after EventDefs,
+// e.g. definitions discovered in a log, leaving EventDefs unchanged. Adding
+// clears the cache.
+func (m *Matcher) AddDefs(edefs ...EventDef) {
+	m.extra = append(m.extra, edefs...)
+	m.cache = make(map[string]cachedMatch)
+}
+
+// CreateEvent creates an event
+func (m *Matcher) CreateEvent(line string, t time.Time, lineNumber int) (Event, error) {
+	return createEvent(line, t, lineNumber, m.match)
+}
+
+// match returns the event definition and form whose prefix matches line.
+func (m *Matcher) match(line string) (EventDef, EventForm, bool) {
+	key := line
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		key = line[:i+1]
+	}
+	if c, ok := m.cache[key]; ok {
+		return c.edef, c.eform, c.ok
+	}
+	edef, eform, ok := matchVersion(line, m.version)
+	if !ok {
+		edef, eform, ok = matchDefs(line, m.extra)
+	}
+	if len(m.cache) < maxCachedPrefixes && m.cacheable(key, eform, ok) {
+		m.cache[key] = cachedMatch{edef: edef, eform: eform, ok: ok}
+	}
+	return edef, eform, ok
+}
+
+// cacheable returns true if every line starting with key resolves to the same
+// match, i.e. the matched prefix lies within key, or for no match, no prefix
+// extends past key.
+func (m *Matcher) cacheable(key string, eform EventForm, ok bool) bool {
+	if ok {
+		return strings.HasPrefix(key, eform.StartsWith)
+	}
+	for _, edef := range EventDefs {
+		for _, eform := range edef.EventForms {
+			if strings.HasPrefix(eform.StartsWith, key) {
+				return false
+			}
+		}
+	}
+	for _, edef := range m.extra {
+		for _, eform := range edef.EventForms {
+			if strings.HasPrefix(eform.StartsWith, key) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matchDefs returns the first of edefs with a form whose prefix matches line.
+func matchDefs(line string, edefs []EventDef) (EventDef, EventForm, bool) {
+	for _, edef := range edefs {
+		for _, eform := range edef.EventForms {
+			if strings.HasPrefix(line, eform.StartsWith) {
+				return edef, eform, true
+			}
+		}
+	}
+	return EventDef{}, EventForm{}, false
+}
@@ -0,0 +1,19 @@
+package defs
+
+import "sync"
+
+var eventPool = sync.Pool{New: func() interface{} { return new(Event) }}
+
+// AcquireEvent returns a zeroed Event from a shared pool, for high throughput
+// pipelines which pass events by pointer, e.g. over channels. Return it with
+// ReleaseEvent once it's no longer used.
+func AcquireEvent() *Event {
+	return eventPool.Get().(*Event)
+}
+
+// ReleaseEvent zeroes e and returns it to the pool. e must not be used after
+// it is released.
+func ReleaseEvent(e *Event) {
+	*e = Event{}
+	eventPool.Put(e)
+}
@@ -0,0 +1,55 @@
+package defs
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ForVersion returns true if lines of this form may be logged by instrument
+// software version, or if version is unknown, i.e. empty.
+func (eform EventForm) ForVersion(version string) bool {
+	if version == "" {
+		return true
+	}
+	if eform.MinVersion != "" && CompareVersions(version, eform.MinVersion) < 0 {
+		return false
+	}
+	if eform.MaxVersion != "" && CompareVersions(version, eform.MaxVersion) >= 0 {
+		return false
+	}
+	return true
+}
+
+// CompareVersions compares dot separated versions a and b, returning -1, 0, or
+// +1 if a is before, the same as, or after b. Numeric parts compare as numbers
+// and other parts as text, and a missing part counts as 0, so 2.10 is after
+// 2.9 and 2.0 is the same as 2.
+func CompareVersions(a string, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		ap, bp := "0", "0"
+		if i < len(as) {
+			ap = strings.TrimSpace(as[i])
+		}
+		if i < len(bs) {
+			bp = strings.TrimSpace(bs[i])
+		}
+		an, aerr := strconv.ParseUint(ap, 10, 64)
+		bn, berr := strconv.ParseUint(bp, 10, 64)
+		switch {
+		case aerr == nil && berr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case ap != bp:
+			if ap < bp {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
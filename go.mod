@@ -1,4 +1,4 @@
-module github.com/seaflow-uw/seaflog
+module github.com/seaflow-uw/seaflog/v2
 
 go 1.16
 
@@ -0,0 +1,3 @@
+// Package proc holds helpers for the child processes seaflog runs, plugins and
+// encryption programs. It's internal so they aren't part of the stable API.
+package proc
@@ -0,0 +1,9 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package proc
+
+import "os/exec"
+
+// OwnProcessGroup does nothing where process groups aren't supported.
+func OwnProcessGroup(cmd *exec.Cmd) {}
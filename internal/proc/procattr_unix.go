@@ -0,0 +1,16 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package proc
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// OwnProcessGroup starts cmd in a new process group, so an interrupt from the
+// terminal only reaches seaflog, which then closes the child's STDIN and waits
+// for it to finish.
+func OwnProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
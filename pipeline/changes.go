@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Change records a change of value of a setting event.
+type Change struct {
+	Name       string
+	Time       time.Time
+	LineNumber int
+	From       interface{} // nil for the first value seen
+	To         interface{}
+}
+
+func (c Change) String() string {
+	if c.From == nil {
+		return fmt.Sprintf("Line %d, %s set to %v", c.LineNumber, c.Name, c.To)
+	}
+	return fmt.Sprintf("Line %d, %s changed from %v to %v", c.LineNumber, c.Name, c.From, c.To)
+}
+
+// Changes tracks the values of setting events, recording each change.
+type Changes struct {
+	last    map[string]interface{}
+	changes []Change
+}
+
+// NewChanges creates a Changes for all setting events in EventDefs.
+func NewChanges() *Changes {
+	return &Changes{last: make(map[string]interface{})}
+}
+
+// Add checks a setting event for a change of value. It returns the change and
+// true if the value differs from the previous value of the same setting,
+// including the first value seen. Other events are ignored.
+func (c *Changes) Add(event defs.Event) (Change, bool) {
+	if edef, _ := defs.Lookup(event.Name); !edef.Setting || event.Error != nil || event.Value == nil {
+		return Change{}, false
+	}
+	prev, seen := c.last[event.Name]
+	if seen && prev == event.Value {
+		return Change{}, false
+	}
+	c.last[event.Name] = event.Value
+	change := Change{
+		Name: event.Name, Time: event.Time, LineNumber: event.LineNumber, From: prev, To: event.Value,
+	}
+	c.changes = append(c.changes, change)
+	return change, true
+}
+
+// Changes returns all changes recorded so far in log order.
+func (c *Changes) Changes() []Change {
+	return c.changes
+}
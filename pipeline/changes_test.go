@@ -0,0 +1,36 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestChanges(t *testing.T) {
+	events := []defs.Event{
+		{Name: "PMT1", Value: 1.0, LineNumber: 1},
+		{Name: "PMT1", Value: 1.0, LineNumber: 2},
+		{Name: "note", Value: "hello", LineNumber: 3},
+		{Name: "PMT1", Value: 1.5, LineNumber: 4},
+		{Name: "laser", Value: 1.0, LineNumber: 5},
+	}
+	c := pipeline.NewChanges()
+	for _, event := range events {
+		c.Add(event)
+	}
+	want := []pipeline.Change{
+		{Name: "PMT1", LineNumber: 1, To: 1.0},
+		{Name: "PMT1", LineNumber: 4, From: 1.0, To: 1.5},
+		{Name: "laser", LineNumber: 5, To: 1.0},
+	}
+	got := c.Changes()
+	if len(got) != len(want) {
+		t.Fatalf("len(Changes()) %v; want %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Change %+v; want %+v", got[i], want[i])
+		}
+	}
+}
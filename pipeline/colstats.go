@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// ColumnStat summarizes the values written to one event column.
+type ColumnStat struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Count int      `json:"count"`
+	Min   *float64 `json:"min,omitempty"` // of finite float values
+	Max   *float64 `json:"max,omitempty"`
+	// Last is the last value, with non-finite floats as text, e.g. "NaN", so
+	// it can be written as JSON.
+	Last     interface{} `json:"last"`
+	LastTime time.Time   `json:"last_time"`
+}
+
+// ColumnStats collects a ColumnStat for each event column in a single pass,
+// e.g. for QC dashboards to read instead of rereading the output.
+type ColumnStats struct {
+	stats map[string]*ColumnStat
+}
+
+// NewColumnStats creates an empty ColumnStats.
+func NewColumnStats() *ColumnStats {
+	return &ColumnStats{stats: make(map[string]*ColumnStat)}
+}
+
+// Add adds an event. Events with an error or no value are ignored.
+func (cs *ColumnStats) Add(event defs.Event) {
+	if event.Error != nil || event.Value == nil {
+		return
+	}
+	st := cs.stats[event.Name]
+	if st == nil {
+		st = &ColumnStat{Name: event.Name, Type: event.Type}
+		cs.stats[event.Name] = st
+	}
+	st.Count++
+	st.Last = event.Value
+	st.LastTime = event.Time
+	v, ok := event.Value.(float64)
+	if !ok {
+		return
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		st.Last = strconv.FormatFloat(v, 'g', -1, 64)
+		return
+	}
+	if st.Min == nil || v < *st.Min {
+		st.Min = &v
+	}
+	if st.Max == nil || v > *st.Max {
+		st.Max = &v
+	}
+}
+
+// Stats returns the statistics of each column with values, sorted by name.
+func (cs *ColumnStats) Stats() []ColumnStat {
+	stats := make([]ColumnStat, 0, len(cs.stats))
+	for _, st := range cs.stats {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,41 @@
+package pipeline_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestColumnStats(t *testing.T) {
+	t0 := time.Date(2015, 3, 14, 0, 0, 0, 0, time.UTC)
+	cs := pipeline.NewColumnStats()
+	for i, v := range []float64{2, -1, 5, 3} {
+		cs.Add(defs.Event{Name: "PMT1", Type: "float", Value: v, Time: t0.Add(time.Duration(i) * time.Minute)})
+	}
+	cs.Add(defs.Event{Name: "PMT2", Type: "float", Value: math.NaN(), Time: t0})
+	cs.Add(defs.Event{Name: "note", Type: "text", Value: "hello", Time: t0})
+	cs.Add(defs.Event{Name: "note", Type: "text", Value: nil, Time: t0})
+
+	stats := cs.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("len(Stats()) %v; want 3", len(stats))
+	}
+	pmt1 := stats[0]
+	if pmt1.Name != "PMT1" || pmt1.Count != 4 || *pmt1.Min != -1 || *pmt1.Max != 5 || pmt1.Last != 3.0 ||
+		!pmt1.LastTime.Equal(t0.Add(3*time.Minute)) {
+		t.Errorf("PMT1 stats %+v; want count 4, min -1, max 5, last 3 at 00:03", pmt1)
+	}
+	if pmt2 := stats[1]; pmt2.Min != nil || pmt2.Last != "NaN" {
+		t.Errorf("PMT2 stats %+v; want no min, last NaN", pmt2)
+	}
+	if note := stats[2]; note.Count != 1 || note.Last != "hello" {
+		t.Errorf("note stats %+v; want count 1, last hello", note)
+	}
+	if _, err := json.Marshal(stats); err != nil {
+		t.Errorf("json.Marshal(Stats()) error = %v; want nil", err)
+	}
+}
@@ -0,0 +1,345 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Computed is a column computed from the latest values of float events, e.g.
+// pmt_ratio=PMT1/PMT2.
+//
+// Expressions combine float event names and numbers with +, -, *, /, and
+// parentheses. The functions mean, min, max, and stddev take an event name and
+// a trailing window duration, e.g. mean(PMT1, 10m), to smooth noisy values.
+type Computed struct {
+	Name string
+	Expr string
+	root exprNode
+}
+
+// ParseComputed parses a computed column definition of the form NAME=EXPR.
+func ParseComputed(spec string) (Computed, error) {
+	i := strings.Index(spec, "=")
+	if i < 0 {
+		return Computed{}, fmt.Errorf("computed column %q not in NAME=EXPR form", spec)
+	}
+	name, expr := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+	if !isIdent(name) {
+		return Computed{}, fmt.Errorf("computed column %q has bad name %q", spec, name)
+	}
+	if _, ok := defs.Lookup(name); ok {
+		return Computed{}, fmt.Errorf("computed column %q has the name of an event", spec)
+	}
+	p := exprParser{s: expr}
+	root, err := p.parse()
+	if err != nil {
+		return Computed{}, fmt.Errorf("computed column %q: %v", spec, err)
+	}
+	return Computed{Name: name, Expr: expr, root: root}, nil
+}
+
+// Computer evaluates computed columns from a running snapshot of the latest
+// value of each float event.
+type Computer struct {
+	columns  []Computed
+	snapshot map[string]float64
+}
+
+// NewComputer creates a Computer for columns. Columns hold window state, so
+// each Computer needs its own parsed columns.
+func NewComputer(columns []Computed) *Computer {
+	return &Computer{columns: columns, snapshot: make(map[string]float64)}
+}
+
+// Names returns computed column names in the order given to NewComputer.
+func (c *Computer) Names() []string {
+	names := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// Columns returns the computed columns in the order given to NewComputer.
+func (c *Computer) Columns() []Computed {
+	return c.columns
+}
+
+// Update adds event to the snapshot and returns the value of each computed
+// column afterwards. Values which can't be computed, because an input has no
+// value yet or the result isn't finite, are NaN. Events must be added in log
+// order.
+func (c *Computer) Update(event defs.Event) []float64 {
+	if v, ok := event.Value.(float64); ok && event.Error == nil {
+		c.snapshot[event.Name] = v
+		for _, col := range c.columns {
+			col.root.add(event)
+		}
+	}
+	values := make([]float64, len(c.columns))
+	for i, col := range c.columns {
+		v, ok := col.root.eval(c.snapshot)
+		if !ok || math.IsInf(v, 0) || math.IsNaN(v) {
+			v = math.NaN()
+		}
+		values[i] = v
+	}
+	return values
+}
+
+type exprNode interface {
+	eval(snapshot map[string]float64) (float64, bool)
+	add(event defs.Event) // updates window state
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]float64) (float64, bool) { return float64(n), true }
+func (n numberNode) add(defs.Event)                          {}
+
+type eventNode string
+
+func (n eventNode) eval(snapshot map[string]float64) (float64, bool) {
+	v, ok := snapshot[string(n)]
+	return v, ok
+}
+func (n eventNode) add(defs.Event) {}
+
+type negNode struct{ x exprNode }
+
+func (n negNode) eval(snapshot map[string]float64) (float64, bool) {
+	v, ok := n.x.eval(snapshot)
+	return -v, ok
+}
+func (n negNode) add(event defs.Event) { n.x.add(event) }
+
+type binaryNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n binaryNode) eval(snapshot map[string]float64) (float64, bool) {
+	l, lok := n.l.eval(snapshot)
+	r, rok := n.r.eval(snapshot)
+	if !lok || !rok {
+		return 0, false
+	}
+	switch n.op {
+	case '+':
+		return l + r, true
+	case '-':
+		return l - r, true
+	case '*':
+		return l * r, true
+	default:
+		return l / r, true
+	}
+}
+
+func (n binaryNode) add(event defs.Event) {
+	n.l.add(event)
+	n.r.add(event)
+}
+
+type windowNode struct {
+	fn     string
+	name   string
+	window *Window
+}
+
+func (n windowNode) eval(map[string]float64) (float64, bool) {
+	s := n.window.Stats(n.name)
+	if s.Count == 0 {
+		return 0, false
+	}
+	switch n.fn {
+	case "mean":
+		return s.Mean, true
+	case "min":
+		return s.Min, true
+	case "max":
+		return s.Max, true
+	default:
+		return s.Stddev, s.Count > 1
+	}
+}
+
+func (n windowNode) add(event defs.Event) {
+	if event.Name == n.name {
+		n.window.Add(event)
+	}
+}
+
+var windowFuncs = map[string]bool{"mean": true, "min": true, "max": true, "stddev": true}
+
+// exprParser is a recursive descent parser for computed column expressions.
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) parse() (exprNode, error) {
+	n, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.pos:], p.pos)
+	}
+	return n, nil
+}
+
+func (p *exprParser) sum() (exprNode, error) {
+	n, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek('+') || p.peek('-') {
+		op := p.s[p.pos]
+		p.pos++
+		r, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		n = binaryNode{op: op, l: n, r: r}
+	}
+	return n, nil
+}
+
+func (p *exprParser) product() (exprNode, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek('*') || p.peek('/') {
+		op := p.s[p.pos]
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n = binaryNode{op: op, l: n, r: r}
+	}
+	return n, nil
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if p.peek('-') {
+		p.pos++
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{n}, nil
+	}
+	return p.operand()
+}
+
+func (p *exprParser) operand() (exprNode, error) {
+	if p.peek('(') {
+		p.pos++
+		n, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(')') {
+			return nil, fmt.Errorf("missing ) at offset %d", p.pos)
+		}
+		p.pos++
+		return n, nil
+	}
+	tok := p.token()
+	if tok == "" {
+		return nil, fmt.Errorf("expected a number or event name at offset %d", p.pos)
+	}
+	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+		return numberNode(f), nil
+	}
+	if windowFuncs[tok] && p.peek('(') {
+		return p.window(tok)
+	}
+	if err := checkFloatEvent(tok); err != nil {
+		return nil, err
+	}
+	return eventNode(tok), nil
+}
+
+// window parses the arguments of a window function call.
+func (p *exprParser) window(fn string) (exprNode, error) {
+	p.pos++ // (
+	name := p.token()
+	if err := checkFloatEvent(name); err != nil {
+		return nil, fmt.Errorf("%s(): %v", fn, err)
+	}
+	if !p.peek(',') {
+		return nil, fmt.Errorf("%s() missing window duration at offset %d", fn, p.pos)
+	}
+	p.pos++
+	tok := p.token()
+	size, err := time.ParseDuration(tok)
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("%s() has bad window duration %q", fn, tok)
+	}
+	if !p.peek(')') {
+		return nil, fmt.Errorf("missing ) at offset %d", p.pos)
+	}
+	p.pos++
+	return windowNode{fn: fn, name: name, window: NewWindow(size)}, nil
+}
+
+// token returns the next run of letters, digits, '_', and '.', after any
+// spaces.
+func (p *exprParser) token() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) {
+		r := rune(p.s[p.pos])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// peek reports whether the next byte after any spaces is b.
+func (p *exprParser) peek(b byte) bool {
+	p.skipSpace()
+	return p.pos < len(p.s) && p.s[p.pos] == b
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func checkFloatEvent(name string) error {
+	edef, ok := defs.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown event %q", name)
+	}
+	if edef.Type != "float" {
+		return fmt.Errorf("event %q has type %s, not float", name, edef.Type)
+	}
+	return nil
+}
+
+func isIdent(s string) bool {
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package pipeline_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestParseComputed(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"pmt_ratio=PMT1/PMT2", false},
+		{"x = -(PMT1 + 2.5) * trigger_level", false},
+		{"smooth=mean(PMT1, 10m)", false},
+		{"spread=max(PMT1,1h)-min(PMT1,1h)", false},
+		{"PMT1/PMT2", true},
+		{"1x=PMT1", true},
+		{"PMT1=PMT2", true},
+		{"x=PMT1/", true},
+		{"x=(PMT1", true},
+		{"x=nope", true},
+		{"x=cruise_name", true},
+		{"x=mean(PMT1)", true},
+		{"x=mean(PMT1, soon)", true},
+		{"x=PMT1 PMT2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := pipeline.ParseComputed(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseComputed() error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputer(t *testing.T) {
+	var columns []pipeline.Computed
+	for _, spec := range []string{"ratio=PMT1/PMT2", "smooth=mean(PMT1, 90s)", "neg=-PMT1+1"} {
+		col, err := pipeline.ParseComputed(spec)
+		if err != nil {
+			t.Fatalf("ParseComputed(%q) error = %v; want nil", spec, err)
+		}
+		columns = append(columns, col)
+	}
+	c := pipeline.NewComputer(columns)
+	if names := c.Names(); strings.Join(names, ",") != "ratio,smooth,neg" {
+		t.Fatalf("Names() = %v; want [ratio smooth neg]", names)
+	}
+
+	input := "2015-03-14T00-00-00+00-00\nPMT1:1\nPMT2:4\n" +
+		"2015-03-14T00-01-00+00-00\nPMT1:3\n" +
+		"2015-03-14T00-02-00+00-00\nPMT1:5\nPMT2:0\n"
+	nan := math.NaN()
+	want := [][]float64{
+		{nan, 1, 0},
+		{0.25, 1, 0},
+		{0.75, 2, -2},
+		{1.25, 4, -4},
+		{nan, 4, -4},
+	}
+
+	got := [][]float64{}
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		got = append(got, c.Update(scanner.Event()))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		for j := range got[i] {
+			if got[i][j] != want[i][j] && !(math.IsNaN(got[i][j]) && math.IsNaN(want[i][j])) {
+				t.Errorf("line %d column %d = %v; want %v", i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+}
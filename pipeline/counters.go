@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// CounterValue is the corrected value of a counter event.
+type CounterValue struct {
+	Delta      float64 // change since the previous event, zero for the first event
+	Cumulative float64 // running total corrected for rollovers and resets
+	First      bool    // true for the first event of this counter
+}
+
+// CounterReset records a decrease of a counter value.
+type CounterReset struct {
+	Name       string
+	LineNumber int
+	From       float64
+	To         float64
+	Rollover   bool // true if treated as a rollover rather than a reset
+}
+
+func (r CounterReset) String() string {
+	kind := "reset"
+	if r.Rollover {
+		kind = "rollover"
+	}
+	return fmt.Sprintf("Line %d, %s counter %s from %v to %v", r.LineNumber, r.Name, kind, r.From, r.To)
+}
+
+type counterState struct {
+	last       float64
+	cumulative float64
+	seen       bool
+	restarted  bool // instrument software restarted, counting from zero
+}
+
+// Counters computes deltas and cumulative totals for counter events, detecting
+// and compensating for rollovers and resets.
+type Counters struct {
+	defs   map[string]defs.CounterDef
+	state  map[string]*counterState
+	resets []CounterReset
+}
+
+// NewCounters creates a Counters for all counter events in EventDefs.
+func NewCounters() *Counters {
+	c := &Counters{defs: make(map[string]defs.CounterDef), state: make(map[string]*counterState)}
+	for name, edef := range defs.EventDefs {
+		if edef.Counter != nil {
+			c.defs[name] = *edef.Counter
+			c.state[name] = &counterState{}
+		}
+	}
+	return c
+}
+
+// Names returns counter event names in sorted order.
+func (c *Counters) Names() []string {
+	names := make([]string, 0, len(c.defs))
+	for name := range c.defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Update adds a counter event and returns its corrected value. ok is false if
+// event is not a valid counter event. After a defs.RestartEvent event every
+// counter counts from zero again, which is not reported as a reset.
+func (c *Counters) Update(event defs.Event) (cv CounterValue, ok bool) {
+	if event.Name == defs.RestartEvent && event.Error == nil {
+		for _, st := range c.state {
+			st.restarted = st.seen
+		}
+		return cv, false
+	}
+	st, isCounter := c.state[event.Name]
+	val, isFloat := event.Value.(float64)
+	if !isCounter || !isFloat || event.Error != nil {
+		return cv, false
+	}
+
+	if !st.seen {
+		st.seen = true
+		st.last = val
+		st.cumulative = val
+		return CounterValue{Cumulative: val, First: true}, true
+	}
+
+	cv.Delta = val - st.last
+	if st.restarted {
+		st.restarted = false
+		cv.Delta = val
+	} else if cv.Delta < 0 {
+		r := CounterReset{Name: event.Name, LineNumber: event.LineNumber, From: st.last, To: val}
+		if rollover := c.defs[event.Name].Rollover; rollover > 0 {
+			r.Rollover = true
+			cv.Delta += rollover
+		} else {
+			// Counting restarted from zero
+			cv.Delta = val
+		}
+		c.resets = append(c.resets, r)
+	}
+	st.last = val
+	st.cumulative += cv.Delta
+	cv.Cumulative = st.cumulative
+	return cv, true
+}
+
+// Resets returns all counter resets and rollovers seen so far.
+func (c *Counters) Resets() []CounterReset {
+	return c.resets
+}
@@ -0,0 +1,95 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestCounters(t *testing.T) {
+	defs.EventDefs["test_counter"] = defs.EventDef{
+		Name:    "test_counter",
+		Type:    "float",
+		Counter: &defs.CounterDef{Rollover: 100},
+		EventForms: []defs.EventForm{
+			{StartsWith: "test counter:", ValueAction: "as_float"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_counter")
+
+	input := "2015-03-14T00-00-00+00-00\n" +
+		"Syringe pump injection:5\nSyringe pump injection:7\nSyringe pump injection:2\n" +
+		"test counter:98\ntest counter:3\n"
+	want := []pipeline.CounterValue{
+		{Delta: 0, Cumulative: 5, First: true},
+		{Delta: 2, Cumulative: 7},
+		{Delta: 2, Cumulative: 9},
+		{Delta: 0, Cumulative: 98, First: true},
+		{Delta: 5, Cumulative: 103},
+	}
+
+	counters := pipeline.NewCounters()
+	got := []pipeline.CounterValue{}
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		if cv, ok := counters.Update(scanner.Event()); ok {
+			got = append(got, cv)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("CounterValue %+v; want %+v", got[i], want[i])
+		}
+	}
+
+	resets := counters.Resets()
+	if len(resets) != 2 {
+		t.Fatalf("len(Resets()) %v; want 2", len(resets))
+	}
+	if resets[0].Rollover || !resets[1].Rollover {
+		t.Errorf("Resets() rollover flags %v, %v; want false, true", resets[0].Rollover, resets[1].Rollover)
+	}
+}
+
+func TestCountersRestart(t *testing.T) {
+	input := "2015-03-14T00-00-00+00-00\n" +
+		"Syringe pump injection:5\nSyringe pump injection:7\n" +
+		"Instrument Serial: 740\n" +
+		"2015-03-14T01-00-00+00-00\n" +
+		"Syringe pump injection:2\nSyringe pump injection:4\n"
+	want := []pipeline.CounterValue{
+		{Delta: 0, Cumulative: 5, First: true},
+		{Delta: 2, Cumulative: 7},
+		{Delta: 2, Cumulative: 9},
+		{Delta: 2, Cumulative: 11},
+	}
+
+	counters := pipeline.NewCounters()
+	got := []pipeline.CounterValue{}
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		if cv, ok := counters.Update(scanner.Event()); ok {
+			got = append(got, cv)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("CounterValue %+v; want %+v", got[i], want[i])
+		}
+	}
+	if resets := counters.Resets(); len(resets) != 0 {
+		t.Errorf("Resets() = %v; want none after a restart", resets)
+	}
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+)
+
+// AcquisitionInterval is the name of the interval definition for data
+// acquisition, during which the instrument writes EVT files.
+const AcquisitionInterval = "acquisition"
+
+// DataFile is an EVT, OPP, or other data file with the time of its name.
+type DataFile struct {
+	Name string
+	Time time.Time
+}
+
+// FileCheck is the result of matching acquisition intervals with data files.
+type FileCheck struct {
+	Intervals int
+	Files     int
+	Empty     []Interval // acquisition intervals with no data files
+	Orphans   []DataFile // data files outside every acquisition interval
+}
+
+// OK reports whether every acquisition interval has data files and every data
+// file lies in an acquisition interval.
+func (fc FileCheck) OK() bool {
+	return len(fc.Empty) == 0 && len(fc.Orphans) == 0
+}
+
+// CheckDataFiles matches the AcquisitionInterval intervals with files, which
+// needn't be sorted. Intervals which were never stopped end at end. Each
+// interval is widened by tolerance at both ends, for files named a little
+// before acquisition started or after it stopped.
+func CheckDataFiles(intervals []Interval, files []DataFile, end time.Time, tolerance time.Duration) FileCheck {
+	sorted := append([]DataFile(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	matched := make([]bool, len(sorted))
+
+	fc := FileCheck{Files: len(sorted)}
+	for _, iv := range intervals {
+		if iv.Name != AcquisitionInterval {
+			continue
+		}
+		fc.Intervals++
+		ivEnd := iv.End
+		if ivEnd.IsZero() {
+			ivEnd = end
+		}
+		from, to := iv.Start.Add(-tolerance), ivEnd.Add(tolerance)
+		i := sort.Search(len(sorted), func(i int) bool { return !sorted[i].Time.Before(from) })
+		found := false
+		for ; i < len(sorted) && !sorted[i].Time.After(to); i++ {
+			matched[i] = true
+			found = true
+		}
+		if !found {
+			fc.Empty = append(fc.Empty, iv)
+		}
+	}
+	for i, f := range sorted {
+		if !matched[i] {
+			fc.Orphans = append(fc.Orphans, f)
+		}
+	}
+	return fc
+}
@@ -0,0 +1,41 @@
+package pipeline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestCheckDataFiles(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	at := func(m int) time.Time { return t0.Add(time.Duration(m) * time.Minute) }
+	intervals := []pipeline.Interval{
+		{Name: "acquisition", Start: at(0), End: at(10)},
+		{Name: "laser_on", Start: at(0), End: at(100)},
+		{Name: "acquisition", Start: at(20), End: at(30)}, // no files
+		{Name: "acquisition", Start: at(40)},              // unfinished, ends at 60
+	}
+	files := []pipeline.DataFile{
+		{Name: "c", Time: at(59)},
+		{Name: "a", Time: at(3)},
+		{Name: "b", Time: at(11)}, // within tolerance
+		{Name: "orphan", Time: at(35)},
+	}
+
+	fc := pipeline.CheckDataFiles(intervals, files, at(60), 2*time.Minute)
+	if fc.Intervals != 3 || fc.Files != 4 || fc.OK() {
+		t.Errorf("FileCheck %+v; want 3 intervals, 4 files, not OK", fc)
+	}
+	if len(fc.Empty) != 1 || !fc.Empty[0].Start.Equal(at(20)) {
+		t.Errorf("Empty %+v; want interval at 20m", fc.Empty)
+	}
+	if len(fc.Orphans) != 1 || fc.Orphans[0].Name != "orphan" {
+		t.Errorf("Orphans %+v; want orphan", fc.Orphans)
+	}
+
+	fc = pipeline.CheckDataFiles(intervals[:1], files[1:3], at(60), 2*time.Minute)
+	if !fc.OK() {
+		t.Errorf("FileCheck %+v; want OK", fc)
+	}
+}
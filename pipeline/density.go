@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// DensityErrors is the name events with errors are counted under by Density.
+const DensityErrors = "error"
+
+// DensityCell is the number of events of one name in one time bucket.
+type DensityCell struct {
+	Start time.Time // start of the bucket
+	Name  string
+	Count int
+}
+
+type densityKey struct {
+	start time.Time
+	name  string
+}
+
+// Density counts events by name in fixed time buckets, e.g. for a heatmap of
+// when the instrument was chatty, silent, or faulting. Events with errors are
+// counted under DensityErrors, events without a time are ignored.
+type Density struct {
+	bucket      time.Duration
+	counts      map[densityKey]int
+	names       map[string]bool
+	first, last time.Time // bucket starts
+}
+
+// NewDensity creates a Density with buckets of length bucket, which must be
+// positive. Buckets are aligned to the zero time, so whole hours start on the
+// hour.
+func NewDensity(bucket time.Duration) *Density {
+	return &Density{bucket: bucket, counts: make(map[densityKey]int), names: make(map[string]bool)}
+}
+
+// Add counts an event.
+func (d *Density) Add(event defs.Event) {
+	if event.Time.IsZero() {
+		return
+	}
+	name := event.Name
+	if event.Error != nil {
+		name = DensityErrors
+	}
+	if name == "" {
+		return
+	}
+	start := event.Time.UTC().Truncate(d.bucket)
+	if len(d.counts) == 0 || start.Before(d.first) {
+		d.first = start
+	}
+	if len(d.counts) == 0 || start.After(d.last) {
+		d.last = start
+	}
+	d.counts[densityKey{start, name}]++
+	d.names[name] = true
+}
+
+// Names returns the names of all counted events, sorted.
+func (d *Density) Names() []string {
+	names := make([]string, 0, len(d.names))
+	for name := range d.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Cells returns a cell for every bucket from the first to the last counted
+// event and every name, including empty ones, ordered by bucket start then
+// name.
+func (d *Density) Cells() []DensityCell {
+	cells := []DensityCell{}
+	if len(d.counts) == 0 {
+		return cells
+	}
+	names := d.Names()
+	for start := d.first; !start.After(d.last); start = start.Add(d.bucket) {
+		for _, name := range names {
+			cells = append(cells, DensityCell{Start: start, Name: name, Count: d.counts[densityKey{start, name}]})
+		}
+	}
+	return cells
+}
@@ -0,0 +1,49 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestDensity(t *testing.T) {
+	input := "2015-03-14T00-10-00+00-00\nPMT1:1.0\nPMT1:1.1\n" +
+		"2015-03-14T02-20-00+00-00\nPMT1:bad\nPump over 25 psi, check setting, 02:20:00,03142015\n"
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	want := []pipeline.DensityCell{
+		{Start: t0, Name: "PMT1", Count: 2},
+		{Start: t0, Name: "error", Count: 0},
+		{Start: t0, Name: "pump_fault", Count: 0},
+		{Start: t0.Add(time.Hour), Name: "PMT1", Count: 0},
+		{Start: t0.Add(time.Hour), Name: "error", Count: 0},
+		{Start: t0.Add(time.Hour), Name: "pump_fault", Count: 0},
+		{Start: t0.Add(2 * time.Hour), Name: "PMT1", Count: 0},
+		{Start: t0.Add(2 * time.Hour), Name: "error", Count: 1},
+		{Start: t0.Add(2 * time.Hour), Name: "pump_fault", Count: 1},
+	}
+
+	density := pipeline.NewDensity(time.Hour)
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		density.Add(es.Event())
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	got := density.Cells()
+	if len(got) != len(want) {
+		t.Fatalf("len(Cells()) %v; want %v: %+v", len(got), len(want), got)
+	}
+	for i := range got {
+		if !got[i].Start.Equal(want[i].Start) || got[i].Name != want[i].Name || got[i].Count != want[i].Count {
+			t.Errorf("DensityCell %+v; want %+v", got[i], want[i])
+		}
+	}
+
+	if cells := pipeline.NewDensity(time.Hour).Cells(); len(cells) != 0 {
+		t.Errorf("empty Density Cells() %+v; want none", cells)
+	}
+}
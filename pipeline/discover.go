@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// keyExpr matches the key of a structured "Key: value" line
+var keyExpr = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9 _./-]*$`)
+
+// nameExpr matches runs of characters replaced by _ in discovered names
+var nameExpr = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Discoverer finds structured "Key: value" lines among unrecognized events,
+// for exploratory conversion of logs with an unknown schema, and creates an
+// event definition for each key.
+type Discoverer struct {
+	keys  map[string]*discovered
+	order []string
+}
+
+type discovered struct {
+	float bool // all values seen are floats
+	count int
+}
+
+// NewDiscoverer creates a Discoverer.
+func NewDiscoverer() *Discoverer {
+	return &Discoverer{keys: make(map[string]*discovered)}
+}
+
+// Add records event if it's an unrecognized "Key: value" line. Keys start
+// with a letter and hold only letters, digits, spaces, and _./- characters.
+func (d *Discoverer) Add(event defs.Event) {
+	if !errors.Is(event.Error, defs.ErrUnrecognized) {
+		return
+	}
+	parts := strings.SplitN(event.Line, ":", 2)
+	if len(parts) < 2 {
+		return
+	}
+	key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if value == "" || key != parts[0] || !keyExpr.MatchString(key) {
+		return
+	}
+	k, ok := d.keys[key]
+	if !ok {
+		k = &discovered{float: true}
+		d.keys[key] = k
+		d.order = append(d.order, key)
+	}
+	k.count++
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		k.float = false
+	}
+}
+
+// Defs returns an event definition for each key found, sorted by name. Keys
+// whose values are all floats become float events, others text events.
+// Events are named from their key in lower case with runs of other characters
+// replaced by _, e.g. "Flow Rate" becomes flow_rate. Keys whose name is
+// already defined in EventDefs, or duplicates another key's, are skipped.
+func (d *Discoverer) Defs() []defs.EventDef {
+	found := []defs.EventDef{}
+	names := make(map[string]bool)
+	for _, key := range d.order {
+		name := strings.Trim(nameExpr.ReplaceAllString(strings.ToLower(key), "_"), "_")
+		if _, ok := defs.Lookup(name); ok || names[name] {
+			continue
+		}
+		names[name] = true
+		edef := defs.EventDef{Name: name, Type: "text"}
+		action := "as_text"
+		if d.keys[key].float {
+			edef.Type = "float"
+			action = "as_float"
+		}
+		edef.EventForms = []defs.EventForm{{StartsWith: key + ":", ValueAction: action}}
+		found = append(found, edef)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
@@ -0,0 +1,41 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestDiscoverer(t *testing.T) {
+	input := strings.Join([]string{
+		"2015-03-14T00-26-52+00-00",
+		"Flow Rate: 0.25",
+		"Flow Rate: 0.30",
+		"Sheath Status: OK",
+		"Sheath Status: 2",
+		"PMT1: 1.05", // defined
+		"Vessel_name: KM1906",
+		"some junk",
+		"12:31:06 clock",
+		"Empty key:",
+		"Bad, key: 1",
+		"Instrument-Serial: 740", // same name as instrument_serial
+	}, "\n")
+	d := pipeline.NewDiscoverer()
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		d.Add(es.Event())
+	}
+
+	got := []string{}
+	for _, edef := range d.Defs() {
+		got = append(got, edef.Name+" "+edef.Type+" "+edef.EventForms[0].StartsWith)
+	}
+	stringsEqual(got, []string{
+		"flow_rate float Flow Rate:",
+		"sheath_status text Sheath Status:",
+		"vessel_name text Vessel_name:",
+	}, t)
+}
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// DriftEvent is the float event checked for drift by default.
+const DriftEvent = "laser_power"
+
+// minDriftReadings is the number of readings needed to fit a trend.
+const minDriftReadings = 3
+
+// DriftSummary is a linear least squares trend of a float event's readings.
+type DriftSummary struct {
+	Name     string
+	Readings int
+	First    time.Time
+	Last     time.Time
+	// Slope is the trend in units per day. Start and End are the fitted values
+	// at First and Last. All are NaN with too few readings to fit a trend.
+	Slope float64
+	Start float64
+	End   float64
+	// Drift is the change from Start to End as a fraction of Start.
+	Drift    float64
+	Exceeded bool // true if |Drift| is more than the threshold
+}
+
+// Drift fits a trend to the readings of a float event as events are added,
+// e.g. laser power over a cruise, and reports drift beyond a threshold.
+// Events must be added in time order.
+type Drift struct {
+	name      string
+	threshold float64
+	alerted   bool
+	summary   DriftSummary
+	// Sums for the fit, with x in days since the first reading
+	n, sx, sy, sxx, sxy float64
+}
+
+// NewDrift creates a Drift for the float event name, which flags drift of more
+// than threshold, a fraction of the fitted starting value, e.g. 0.05 for 5%.
+func NewDrift(name string, threshold float64) (*Drift, error) {
+	edef, ok := defs.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown event %q", name)
+	}
+	if edef.Type != "float" {
+		return nil, fmt.Errorf("event %q is %s, not float", name, edef.Type)
+	}
+	if threshold <= 0 || math.IsNaN(threshold) || math.IsInf(threshold, 0) {
+		return nil, fmt.Errorf("drift threshold %v is not positive", threshold)
+	}
+	return &Drift{name: name, threshold: threshold}, nil
+}
+
+// Add adds an event and returns an alert event when the fitted drift first
+// exceeds the threshold. Another alert is returned only after the drift
+// returns within the threshold. Other events are ignored.
+func (d *Drift) Add(event defs.Event) (defs.Event, bool) {
+	v, ok := event.Value.(float64)
+	if event.Name != d.name || event.Error != nil || !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		return defs.Event{}, false
+	}
+	if d.n == 0 {
+		d.summary.First = event.Time
+	}
+	d.summary.Last = event.Time
+	x := event.Time.Sub(d.summary.First).Hours() / 24
+	d.n++
+	d.sx += x
+	d.sy += v
+	d.sxx += x * x
+	d.sxy += x * v
+
+	s := d.Summary()
+	if !s.Exceeded {
+		d.alerted = false
+		return defs.Event{}, false
+	}
+	if d.alerted {
+		return defs.Event{}, false
+	}
+	d.alerted = true
+	return defs.Event{
+		Name:       defs.AlertEvent,
+		Type:       "text",
+		Value:      fmt.Sprintf("drift: %s changed %+.1f%% since %s", d.name, s.Drift*100, s.First.UTC().Format(time.RFC3339)),
+		Line:       event.Line,
+		LineNumber: event.LineNumber,
+		Time:       event.Time,
+		Source:     event.Source,
+		Instrument: event.Instrument,
+	}, true
+}
+
+// Summary returns the trend of the readings so far.
+func (d *Drift) Summary() DriftSummary {
+	s := d.summary
+	s.Name = d.name
+	s.Readings = int(d.n)
+	s.Slope, s.Start, s.End, s.Drift = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+	denom := d.n*d.sxx - d.sx*d.sx
+	if s.Readings < minDriftReadings || denom == 0 {
+		return s
+	}
+	s.Slope = (d.n*d.sxy - d.sx*d.sy) / denom
+	intercept := (d.sy - s.Slope*d.sx) / d.n
+	s.Start = intercept
+	s.End = intercept + s.Slope*s.Last.Sub(s.First).Hours()/24
+	s.Drift = (s.End - s.Start) / math.Abs(s.Start)
+	s.Exceeded = math.Abs(s.Drift) > d.threshold
+	return s
+}
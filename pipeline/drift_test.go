@@ -0,0 +1,77 @@
+package pipeline_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestNewDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     string
+		threshold float64
+		wantErr   bool
+	}{
+		{"laser power", "laser_power", 0.05, false},
+		{"unknown event", "laser_energy", 0.05, true},
+		{"text event", "note", 0.05, true},
+		{"zero threshold", "laser_power", 0, true},
+		{"NaN threshold", "laser_power", math.NaN(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pipeline.NewDrift(tt.event, tt.threshold)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewDrift() error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDrift(t *testing.T) {
+	// 100 mW falling 2 mW a day
+	input := "2015-03-14T00-00-00+00-00\nLaser power:100\n" +
+		"2015-03-15T00-00-00+00-00\nLaser power:98\n" +
+		"2015-03-16T00-00-00+00-00\nLaser power:96\n" +
+		"2015-03-17T00-00-00+00-00\nLaser power:94\nPMT1:1.0\n"
+	d, err := pipeline.NewDrift(pipeline.DriftEvent, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := []defs.Event{}
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		if alert, ok := d.Add(es.Event()); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+
+	s := d.Summary()
+	if s.Readings != 4 || s.Slope != -2 || s.Start != 100 || s.End != 94 || math.Abs(s.Drift+0.06) > 1e-9 || !s.Exceeded {
+		t.Errorf("Summary() = %+v; want 4 readings, slope -2, 100 to 94, drift -0.06, exceeded", s)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("len(alerts) %v; want 1", len(alerts))
+	}
+	if alerts[0].Name != defs.AlertEvent || alerts[0].LineNumber != 8 ||
+		alerts[0].Value != "drift: laser_power changed -6.0% since 2015-03-14T00:00:00Z" {
+		t.Errorf("alert %+v; want laser_power drift -6.0%% at line 8", alerts[0])
+	}
+
+	short, _ := pipeline.NewDrift(pipeline.DriftEvent, 0.05)
+	es = scanner.NewEventScanner(strings.NewReader("2015-03-14T00-00-00+00-00\nLaser power:100\n"))
+	for es.Scan() {
+		short.Add(es.Event())
+	}
+	if s := short.Summary(); s.Readings != 1 || !math.IsNaN(s.Slope) || s.Exceeded {
+		t.Errorf("Summary() with 1 reading = %+v; want NaN slope, not exceeded", s)
+	}
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// BoolDuration is the time a boolean event spent in each state.
+type BoolDuration struct {
+	Name        string
+	True        time.Duration
+	False       time.Duration
+	Transitions int // number of changes of value
+}
+
+// BoolDurations sums the time boolean events, e.g. stream_pressure_locked
+// logged as separate locked and unlocked lines, spend true and false. Each
+// value lasts until the next value of the same event.
+type BoolDurations struct {
+	last  map[string]defs.Event
+	sums  map[string]*BoolDuration
+	names []string
+}
+
+// NewBoolDurations creates a BoolDurations.
+func NewBoolDurations() *BoolDurations {
+	return &BoolDurations{last: make(map[string]defs.Event), sums: make(map[string]*BoolDuration)}
+}
+
+// Add records a boolean event. Other events, events with an error, and
+// events without a time are ignored.
+func (b *BoolDurations) Add(event defs.Event) {
+	v, ok := event.Value.(bool)
+	if !ok || event.Type != "boolean" || event.Error != nil || event.Time.IsZero() {
+		return
+	}
+	if event.Name == defs.RestartEvent {
+		// A point in time, not a state
+		return
+	}
+	sum, ok := b.sums[event.Name]
+	if !ok {
+		sum = &BoolDuration{Name: event.Name}
+		b.sums[event.Name] = sum
+		b.names = append(b.names, event.Name)
+	}
+	if prev, ok := b.last[event.Name]; ok {
+		b.accrue(sum, prev, event.Time)
+		if prev.Value.(bool) != v {
+			sum.Transitions++
+		}
+	}
+	b.last[event.Name] = event
+}
+
+// accrue adds the time from prev to t to the state of prev.
+func (b *BoolDurations) accrue(sum *BoolDuration, prev defs.Event, t time.Time) {
+	d := t.Sub(prev.Time)
+	if d <= 0 {
+		return
+	}
+	if prev.Value.(bool) {
+		sum.True += d
+	} else {
+		sum.False += d
+	}
+}
+
+// Durations returns state durations for each boolean event seen, sorted by
+// name. The last value of each event lasts until end, e.g. the time of the
+// last event in the log, if end is later.
+func (b *BoolDurations) Durations(end time.Time) []BoolDuration {
+	names := append([]string{}, b.names...)
+	sort.Strings(names)
+	durations := make([]BoolDuration, 0, len(names))
+	for _, name := range names {
+		sum := *b.sums[name]
+		b.accrue(&sum, b.last[name], end)
+		durations = append(durations, sum)
+	}
+	return durations
+}
@@ -0,0 +1,47 @@
+package pipeline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestBoolDurations(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00Z")
+	at := func(m int) time.Time { return t0.Add(time.Duration(m) * time.Minute) }
+	locked := func(m int, v bool) defs.Event {
+		return defs.Event{Name: "stream_pressure_locked", Type: "boolean", Value: v, Time: at(m)}
+	}
+	b := pipeline.NewBoolDurations()
+	for _, e := range []defs.Event{
+		locked(0, true),
+		locked(10, true), // repeated value isn't a transition
+		locked(15, false),
+		{Name: "PMT1", Type: "float", Value: 1.0, Time: at(20)},
+		locked(20, true),
+		{Name: "laser_alignment", Type: "boolean", Value: true, Time: at(25)},
+		{Name: "stream_pressure_locked", Type: "boolean", Time: at(27), Error: defs.ErrUnrecognized},
+	} {
+		b.Add(e)
+	}
+
+	got := b.Durations(at(30))
+	want := []pipeline.BoolDuration{
+		{Name: "laser_alignment", True: 5 * time.Minute},
+		{Name: "stream_pressure_locked", True: 25 * time.Minute, False: 5 * time.Minute, Transitions: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Durations() %v; want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Durations()[%d] %+v; want %+v", i, got[i], want[i])
+		}
+	}
+	// Durations doesn't change the running sums
+	if got := b.Durations(at(20)); got[1].True != 15*time.Minute {
+		t.Errorf("Durations(at(20)) stream_pressure_locked True %v; want 15m", got[1].True)
+	}
+}
@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"sort"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// FileSettings are the instrument settings in effect when a data file was
+// created, by setting event name. Settings not yet seen are missing.
+type FileSettings struct {
+	File   DataFile
+	Values map[string]interface{}
+}
+
+// SettingNames returns the names of all setting events in EventDefs, sorted.
+func SettingNames() []string {
+	names := []string{}
+	for name, edef := range defs.EventDefs {
+		if edef.Setting {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SettingsAtFiles returns the settings in effect at the time of each file,
+// in time order, given changes in time order as from Changes. A change at the
+// same time as a file is in effect for it.
+func SettingsAtFiles(changes []Change, files []DataFile) []FileSettings {
+	sorted := append([]DataFile(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	current := make(map[string]interface{})
+	out := make([]FileSettings, 0, len(sorted))
+	i := 0
+	for _, f := range sorted {
+		for ; i < len(changes) && !changes[i].Time.After(f.Time); i++ {
+			current[changes[i].Name] = changes[i].To
+		}
+		values := make(map[string]interface{}, len(current))
+		for name, v := range current {
+			values[name] = v
+		}
+		out = append(out, FileSettings{File: f, Values: values})
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestSettingsAtFiles(t *testing.T) {
+	input := "2015-03-14T00-00-00+00-00\nPMT1:1.05\ntrigger level:0.5\n" +
+		"2015-03-14T00-10-00+00-00\nPMT1:1.10\n"
+	changes := pipeline.NewChanges()
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		changes.Add(es.Event())
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	files := []pipeline.DataFile{
+		{Name: "c", Time: t0.Add(12 * time.Minute)},
+		{Name: "a", Time: t0.Add(-time.Minute)},
+		{Name: "b", Time: t0.Add(10 * time.Minute)},
+	}
+	want := []struct {
+		name    string
+		pmt1    interface{}
+		trigger interface{}
+	}{
+		{"a", nil, nil},
+		{"b", 1.10, 0.5},
+		{"c", 1.10, 0.5},
+	}
+
+	got := pipeline.SettingsAtFiles(changes.Changes(), files)
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; want %v", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.File.Name != w.name || g.Values["PMT1"] != w.pmt1 || g.Values["trigger_level"] != w.trigger {
+			t.Errorf("FileSettings %+v; want %s with PMT1 %v, trigger_level %v", g, w.name, w.pmt1, w.trigger)
+		}
+	}
+
+	names := pipeline.SettingNames()
+	if len(names) == 0 || names[0] != "PMT1" {
+		t.Errorf("SettingNames() = %v; want PMT1 first", names)
+	}
+}
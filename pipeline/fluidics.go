@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"math"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Fluidics event names.
+const (
+	injectionEvent   = "syringe_pump_injection"
+	pumpVoltageEvent = "pump_voltage_change"
+)
+
+// fluidicsFaults are the fault events of the fluidics system.
+var fluidicsFaults = map[string]bool{"pump_fault": true, "syringe_pump_fault": true, "inlet_fault": true}
+
+// FluidicsBucket is the fluidics activity in one time bucket.
+type FluidicsBucket struct {
+	Start      time.Time
+	Injections float64 // syringe pump injections
+	// Volume is Injections times the injection volume, NaN if the injection
+	// volume is unknown.
+	Volume             float64
+	PumpVoltageChanges int
+	PumpVoltage        float64 // pump voltage setting at the end of the bucket, NaN if not yet seen
+	Faults             int     // pump, syringe pump, and inlet faults
+}
+
+// Fluidics summarizes syringe and pump events in fixed time buckets, e.g. for
+// maintenance scheduling. Syringe pump injections are counted from the change
+// of the injection counter, corrected for resets like Counters, with the
+// first reading counted from zero. Events must be added in time order.
+type Fluidics struct {
+	bucket   time.Duration
+	volume   float64
+	counters *Counters
+	voltage  float64
+	buckets  []FluidicsBucket
+}
+
+// NewFluidics creates a Fluidics with buckets of length bucket, which must be
+// positive. volume is the volume of one syringe pump injection, or NaN if
+// unknown. Buckets are aligned to the zero time, so whole hours start on the
+// hour.
+func NewFluidics(bucket time.Duration, volume float64) *Fluidics {
+	return &Fluidics{bucket: bucket, volume: volume, counters: NewCounters(), voltage: math.NaN()}
+}
+
+// Add adds an event. Events which aren't fluidics events only advance time.
+func (f *Fluidics) Add(event defs.Event) {
+	cv, isCounter := f.counters.Update(event)
+	if event.Error != nil || event.Time.IsZero() {
+		return
+	}
+	b := f.at(event.Time)
+	switch {
+	case event.Name == injectionEvent && isCounter:
+		n := cv.Delta
+		if cv.First {
+			n = cv.Cumulative
+		}
+		b.Injections += n
+		b.Volume = b.Injections * f.volume
+	case event.Name == pumpVoltageEvent:
+		if v, ok := event.Value.(float64); ok {
+			b.PumpVoltageChanges++
+			b.PumpVoltage = v
+			f.voltage = v
+		}
+	case fluidicsFaults[event.Name]:
+		b.Faults++
+	}
+}
+
+// at returns the bucket for t, adding empty buckets up to it.
+func (f *Fluidics) at(t time.Time) *FluidicsBucket {
+	start := t.UTC().Truncate(f.bucket)
+	if len(f.buckets) == 0 {
+		f.buckets = append(f.buckets, f.empty(start))
+	}
+	for last := f.buckets[len(f.buckets)-1].Start; last.Before(start); last = last.Add(f.bucket) {
+		f.buckets = append(f.buckets, f.empty(last.Add(f.bucket)))
+	}
+	// Events out of time order are added to the latest bucket
+	return &f.buckets[len(f.buckets)-1]
+}
+
+func (f *Fluidics) empty(start time.Time) FluidicsBucket {
+	// Volume is NaN, not zero, when the injection volume is unknown
+	return FluidicsBucket{Start: start, Volume: 0 * f.volume, PumpVoltage: f.voltage}
+}
+
+// Buckets returns every bucket from the first to the last event, in time
+// order.
+func (f *Fluidics) Buckets() []FluidicsBucket {
+	return append([]FluidicsBucket(nil), f.buckets...)
+}
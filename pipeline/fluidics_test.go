@@ -0,0 +1,55 @@
+package pipeline_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestFluidics(t *testing.T) {
+	input := "2015-03-14T00-10-00+00-00\nSyringe pump injection:2\nPump voltage change:0.20\n" +
+		"2015-03-14T00-50-00+00-00\nSyringe pump injection:5\n" +
+		"2015-03-14T02-05-00+00-00\nSyringe pump injection:6\nPump over 25 psi, check setting, 02:05:00,03142015\nPump voltage change:0.30\n"
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	want := []pipeline.FluidicsBucket{
+		{Start: t0, Injections: 5, Volume: 2.5, PumpVoltageChanges: 1, PumpVoltage: 0.2},
+		{Start: t0.Add(time.Hour), PumpVoltage: 0.2},
+		{Start: t0.Add(2 * time.Hour), Injections: 1, Volume: 0.5, PumpVoltageChanges: 1, PumpVoltage: 0.3, Faults: 1},
+	}
+
+	f := pipeline.NewFluidics(time.Hour, 0.5)
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		f.Add(es.Event())
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	got := f.Buckets()
+	if len(got) != len(want) {
+		t.Fatalf("len(Buckets()) %v; want %v: %+v", len(got), len(want), got)
+	}
+	for i := range got {
+		g, w := got[i], want[i]
+		if !g.Start.Equal(w.Start) || g.Injections != w.Injections || g.Volume != w.Volume ||
+			g.PumpVoltageChanges != w.PumpVoltageChanges || g.PumpVoltage != w.PumpVoltage || g.Faults != w.Faults {
+			t.Errorf("FluidicsBucket %+v; want %+v", g, w)
+		}
+	}
+
+	unknown := pipeline.NewFluidics(time.Hour, math.NaN())
+	es = scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		unknown.Add(es.Event())
+	}
+	if b := unknown.Buckets()[1]; !math.IsNaN(b.Volume) {
+		t.Errorf("Volume %v with unknown injection volume; want NaN", b.Volume)
+	}
+	if b := pipeline.NewFluidics(time.Hour, 1).Buckets(); len(b) != 0 {
+		t.Errorf("empty Fluidics Buckets() %+v; want none", b)
+	}
+}
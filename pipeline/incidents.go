@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Incident is a cluster of fault events, each within a gap of the previous.
+type Incident struct {
+	Start     time.Time // time of the first fault
+	End       time.Time // time of the last fault
+	StartLine int
+	EndLine   int
+	Faults    int            // number of fault events
+	Counts    map[string]int // fault events by name
+}
+
+// Duration returns the time from the first to the last fault of the incident.
+func (inc Incident) Duration() time.Duration {
+	return inc.End.Sub(inc.Start)
+}
+
+// Incidents groups fault events, those with a Fault definition, into
+// Incidents as events are added. Events must be added in time order.
+type Incidents struct {
+	gap     time.Duration
+	current *Incident
+}
+
+// NewIncidents creates an Incidents which starts a new incident for a fault
+// more than gap after the previous fault.
+func NewIncidents(gap time.Duration) *Incidents {
+	return &Incidents{gap: gap}
+}
+
+// Add adds an event and returns the previous incident if the event is a
+// fault which starts a new one. Other events are ignored.
+func (in *Incidents) Add(event defs.Event) (Incident, bool) {
+	edef, ok := defs.Lookup(event.Name)
+	if event.Error != nil || !ok || !edef.Fault {
+		return Incident{}, false
+	}
+	var done Incident
+	finished := false
+	if in.current != nil && event.Time.Sub(in.current.End) > in.gap {
+		done, finished = *in.current, true
+		in.current = nil
+	}
+	if in.current == nil {
+		in.current = &Incident{Start: event.Time, StartLine: event.LineNumber, Counts: make(map[string]int)}
+	}
+	in.current.End = event.Time
+	in.current.EndLine = event.LineNumber
+	in.current.Faults++
+	in.current.Counts[event.Name]++
+	return done, finished
+}
+
+// Flush returns the incident in progress, if any, and resets the state.
+func (in *Incidents) Flush() (Incident, bool) {
+	if in.current == nil {
+		return Incident{}, false
+	}
+	done := *in.current
+	in.current = nil
+	return done, true
+}
+
+// Reliability summarizes the faults of a sequence of incidents.
+type Reliability struct {
+	Incidents int
+	Faults    int
+	// MTBF is the mean time from the end of one incident to the start of the
+	// next, zero with fewer than two incidents.
+	MTBF time.Duration
+	// MeanDuration is the mean time from the first to the last fault of an
+	// incident.
+	MeanDuration time.Duration
+}
+
+// NewReliability summarizes incidents, which must be in time order.
+func NewReliability(incidents []Incident) Reliability {
+	r := Reliability{Incidents: len(incidents)}
+	if len(incidents) == 0 {
+		return r
+	}
+	var total time.Duration
+	for i, inc := range incidents {
+		r.Faults += inc.Faults
+		total += inc.Duration()
+		if i > 0 {
+			r.MTBF += inc.Start.Sub(incidents[i-1].End)
+		}
+	}
+	if len(incidents) > 1 {
+		r.MTBF /= time.Duration(len(incidents) - 1)
+	}
+	r.MeanDuration = total / time.Duration(len(incidents))
+	return r
+}
@@ -0,0 +1,65 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestIncidents(t *testing.T) {
+	input := "2015-03-14T00-00-00+00-00\nPump over 25 psi, check setting, 00:00:00,03142015\n" +
+		"2015-03-14T00-05-00+00-00\nSyringe pump not communicating with labview.\n" +
+		"2015-03-14T00-06-00+00-00\nPMT1:1.0\n" +
+		"2015-03-14T01-05-00+00-00\nPump over 25 psi, check setting, 01:05:00,03142015\n" +
+		"2015-03-14T03-05-00+00-00\nPump over 25 psi, check setting, 03:05:00,03142015\n" +
+		"2015-03-14T03-15-00+00-00\nPump over 25 psi, check setting, 03:15:00,03142015\n"
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	want := []pipeline.Incident{
+		{Start: t0, End: t0.Add(5 * time.Minute), StartLine: 2, EndLine: 4, Faults: 2},
+		{Start: t0.Add(65 * time.Minute), End: t0.Add(65 * time.Minute), StartLine: 8, EndLine: 8, Faults: 1},
+		{Start: t0.Add(185 * time.Minute), End: t0.Add(195 * time.Minute), StartLine: 10, EndLine: 12, Faults: 2},
+	}
+
+	incidents := pipeline.NewIncidents(10 * time.Minute)
+	got := []pipeline.Incident{}
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		if inc, ok := incidents.Add(es.Event()); ok {
+			got = append(got, inc)
+		}
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	if inc, ok := incidents.Flush(); ok {
+		got = append(got, inc)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) || got[i].StartLine != want[i].StartLine ||
+			got[i].EndLine != want[i].EndLine || got[i].Faults != want[i].Faults {
+			t.Errorf("Incident %+v; want %+v", got[i], want[i])
+		}
+	}
+	if n := got[0].Counts["syringe_pump_fault"]; n != 1 {
+		t.Errorf("Incident.Counts[syringe_pump_fault] %v; want 1", n)
+	}
+	if _, ok := incidents.Flush(); ok {
+		t.Errorf("second Flush() ok = true; want false")
+	}
+
+	r := pipeline.NewReliability(got)
+	// Gaps of 60m and 120m, durations of 5m, 0, and 10m
+	wantR := pipeline.Reliability{Incidents: 3, Faults: 5, MTBF: 90 * time.Minute, MeanDuration: 5 * time.Minute}
+	if r != wantR {
+		t.Errorf("NewReliability() = %+v; want %+v", r, wantR)
+	}
+	if r := pipeline.NewReliability(nil); r != (pipeline.Reliability{}) {
+		t.Errorf("NewReliability(nil) = %+v; want zero", r)
+	}
+}
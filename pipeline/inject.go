@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// NewNote returns a synthetic note event with text at time t, e.g. to
+// annotate output with processing context.
+func NewNote(text string, t time.Time) defs.Event {
+	return defs.Event{Name: "note", Type: "text", Value: text, Time: t}
+}
+
+// Injector merges synthetic events into a time ordered event stream.
+type Injector struct {
+	events []defs.Event
+}
+
+// NewInjector returns an Injector for events. Events with a zero time are
+// injected before the first event of the stream, with its time.
+func NewInjector(events []defs.Event) *Injector {
+	events = append([]defs.Event{}, events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return &Injector{events: events}
+}
+
+// Before returns the injected events due before event, those with a time no
+// later than event's time, in time order.
+func (in *Injector) Before(event defs.Event) []defs.Event {
+	n := 0
+	for n < len(in.events) && !in.events[n].Time.After(event.Time) {
+		if in.events[n].Time.IsZero() {
+			in.events[n].Time = event.Time
+		}
+		n++
+	}
+	due := in.events[:n]
+	in.events = in.events[n:]
+	return due
+}
+
+// Rest returns the injected events not yet returned by Before, for the end of
+// the stream.
+func (in *Injector) Rest() []defs.Event {
+	rest := in.events
+	in.events = nil
+	return rest
+}
@@ -0,0 +1,47 @@
+package pipeline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestInjector(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00Z")
+	at := func(m int) time.Time { return t0.Add(time.Duration(m) * time.Minute) }
+	in := pipeline.NewInjector([]defs.Event{
+		pipeline.NewNote("late", at(10)),
+		pipeline.NewNote("middle", at(1)),
+		pipeline.NewNote("untimed", time.Time{}),
+		pipeline.NewNote("tie", at(2)),
+	})
+
+	var got []string
+	var times []time.Time
+	for _, m := range []int{0, 2, 3} {
+		for _, note := range in.Before(defs.Event{Name: "PMT1", Time: at(m)}) {
+			got = append(got, note.Value.(string))
+			times = append(times, note.Time)
+		}
+		got = append(got, "PMT1")
+		times = append(times, at(m))
+	}
+	for _, note := range in.Rest() {
+		got = append(got, note.Value.(string))
+		times = append(times, note.Time)
+	}
+
+	want := []string{"untimed", "PMT1", "middle", "tie", "PMT1", "PMT1", "late"}
+	wantTimes := []time.Time{at(0), at(0), at(1), at(2), at(2), at(3), at(10)}
+	stringsEqual(got, want, t)
+	for i := range times {
+		if !times[i].Equal(wantTimes[i]) {
+			t.Errorf("times[%d] %v; want %v", i, times[i], wantTimes[i])
+		}
+	}
+	if rest := in.Rest(); len(rest) != 0 {
+		t.Errorf("Rest() %v after Rest(); want none", rest)
+	}
+}
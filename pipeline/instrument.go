@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// InstrumentTagger sets the Instrument field of events, so data from multiple
+// SeaFlow instruments can be pooled.
+type InstrumentTagger struct {
+	id   string
+	auto bool
+}
+
+// NewInstrumentTagger creates an InstrumentTagger which tags events with id.
+// If id is empty the instrument ID is detected from the instrument_serial
+// event, usually in the log file header, and events before it are not tagged.
+func NewInstrumentTagger(id string) *InstrumentTagger {
+	return &InstrumentTagger{id: id, auto: id == ""}
+}
+
+// ID returns the instrument ID, or an empty string if it hasn't been detected.
+func (it *InstrumentTagger) ID() string {
+	return it.id
+}
+
+// Tag returns event tagged with the instrument ID. Events must be passed in
+// log order for detection to work.
+func (it *InstrumentTagger) Tag(event defs.Event) defs.Event {
+	if it.auto && it.id == "" {
+		it.id = serialFromLine(event.Line)
+	}
+	event.Instrument = it.id
+	return event
+}
+
+// Detect detects the instrument ID from a raw log line, returning the line
+// unchanged. It's a scanner.LinePreprocessor, so the scanner can pass it every
+// line, including header lines dropped by the untimed event policy before
+// they reach Tag.
+func (it *InstrumentTagger) Detect(line string) (string, bool) {
+	if it.auto && it.id == "" {
+		it.id = serialFromLine(line)
+	}
+	return line, true
+}
+
+// serialFromLine returns the serial number from an instrument_serial event
+// line, or an empty string if line is not one. This checks the line directly
+// because header lines often come before the first timestamp and aren't
+// parsed.
+func serialFromLine(line string) string {
+	for _, eform := range defs.EventDefs["instrument_serial"].EventForms {
+		if strings.HasPrefix(line, eform.StartsWith) {
+			return strings.TrimSpace(strings.TrimPrefix(line, eform.StartsWith))
+		}
+	}
+	return ""
+}
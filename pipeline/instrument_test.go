@@ -0,0 +1,58 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestInstrumentTagger(t *testing.T) {
+	events := []defs.Event{
+		{Name: "note", Line: "note:before"},
+		{Line: "Instrument Serial: 740"},
+		{Name: "PMT1", Line: "PMT1:1.05"},
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		want []string
+	}{
+		{"explicit", "SN751", []string{"SN751", "SN751", "SN751"}},
+		{"detected", "", []string{"", "740", "740"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := pipeline.NewInstrumentTagger(tt.id)
+			for i, event := range events {
+				if got := it.Tag(event).Instrument; got != tt.want[i] {
+					t.Errorf("Event.Instrument %q; want %q", got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInstrumentTaggerUntimedDrop(t *testing.T) {
+	input := "Instrument Serial: 740\n2015-03-14T00-26-52+00-00\nPMT1:1.05\n"
+	it := pipeline.NewInstrumentTagger("")
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	if err := es.UntimedEvents(scanner.UntimedDrop, time.Time{}); err != nil {
+		t.Fatalf("UntimedEvents() error = %v; want nil", err)
+	}
+	es.Preprocess(it.Detect)
+	n := 0
+	for es.Scan() {
+		n++
+		if got := it.Tag(es.Event()).Instrument; got != "740" {
+			t.Errorf("Event.Instrument %q; want 740", got)
+		}
+	}
+	if n != 1 {
+		t.Errorf("got %d events; want 1", n)
+	}
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Interval is a span of time bounded by a pair of start and stop events.
+type Interval struct {
+	Name      string
+	Start     time.Time
+	End       time.Time // zero if the interval was never stopped
+	StartLine int
+	EndLine   int // zero if the interval was never stopped
+}
+
+// Duration returns the length of the interval, or zero if it was never
+// stopped.
+func (iv Interval) Duration() time.Duration {
+	if iv.End.IsZero() {
+		return 0
+	}
+	return iv.End.Sub(iv.Start)
+}
+
+// Pairs pairs start and stop events into Intervals as events are added.
+// Repeated start events before a stop are ignored, keeping the earliest start.
+// Stop events without a preceding start are also ignored.
+type Pairs struct {
+	defs []defs.PairDef
+	open map[string]defs.Event // start event by pair name
+}
+
+// NewPairs creates a new Pairs for a set of interval definitions.
+func NewPairs(pdefs []defs.PairDef) *Pairs {
+	return &Pairs{defs: pdefs, open: make(map[string]defs.Event)}
+}
+
+// Add adds an event and returns any intervals it completes.
+func (p *Pairs) Add(event defs.Event) []Interval {
+	var done []Interval
+	for _, pdef := range p.defs {
+		start, isOpen := p.open[pdef.Name]
+		if isOpen && boundMatches(pdef.Stop, event) {
+			done = append(done, Interval{
+				Name:      pdef.Name,
+				Start:     start.Time,
+				End:       event.Time,
+				StartLine: start.LineNumber,
+				EndLine:   event.LineNumber,
+			})
+			delete(p.open, pdef.Name)
+		} else if !isOpen && boundMatches(pdef.Start, event) {
+			p.open[pdef.Name] = event
+		}
+	}
+	return done
+}
+
+// Flush returns intervals which were started but never stopped, in definition
+// order, and resets their state.
+func (p *Pairs) Flush() []Interval {
+	var unfinished []Interval
+	for _, pdef := range p.defs {
+		if start, ok := p.open[pdef.Name]; ok {
+			unfinished = append(unfinished, Interval{
+				Name:      pdef.Name,
+				Start:     start.Time,
+				StartLine: start.LineNumber,
+			})
+			delete(p.open, pdef.Name)
+		}
+	}
+	return unfinished
+}
+
+// boundMatches returns true if event starts or stops an interval for b.
+func boundMatches(b defs.PairBound, event defs.Event) bool {
+	if event.Name != b.Event || event.Error != nil {
+		return false
+	}
+	return b.Value == nil || event.Value == b.Value
+}
@@ -0,0 +1,55 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestPairs(t *testing.T) {
+	input := "2015-03-14T00-00-00+00-00\nwrite evt: 0\nwrite evt: 1\n" +
+		"2015-03-14T00-10-00+00-00\nwrite evt: 1\n" +
+		"2015-03-14T00-20-00+00-00\nwrite evt: 0\n" +
+		"2015-03-14T00-30-00+00-00\nwrite evt: 1\n"
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	want := []pipeline.Interval{
+		{Name: "acquisition", Start: t0, End: t0.Add(20 * time.Minute), StartLine: 3, EndLine: 7},
+		{Name: "acquisition", Start: t0.Add(30 * time.Minute), StartLine: 9},
+	}
+
+	pairs := pipeline.NewPairs(defs.PairDefs)
+	got := []pipeline.Interval{}
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	for es.Scan() {
+		got = append(got, pairs.Add(es.Event())...)
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	got = append(got, pairs.Flush()...)
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].Name != want[i].Name || !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) ||
+			got[i].StartLine != want[i].StartLine || got[i].EndLine != want[i].EndLine {
+			t.Errorf("Interval %+v; want %+v", got[i], want[i])
+		}
+	}
+}
+
+func TestIntervalDuration(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	iv := pipeline.Interval{Name: "test", Start: t0, End: t0.Add(90 * time.Second)}
+	if got := iv.Duration(); got != 90*time.Second {
+		t.Errorf("Interval.Duration() = %v; want %v", got, 90*time.Second)
+	}
+	iv.End = time.Time{}
+	if got := iv.Duration(); got != 0 {
+		t.Errorf("Interval.Duration() = %v; want 0", got)
+	}
+}
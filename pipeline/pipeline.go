@@ -0,0 +1,32 @@
+// Package pipeline holds steps which filter, transform, and summarize parsed
+// events between scanning and writing.
+package pipeline
+
+import (
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/seaflogtime"
+)
+
+// TimeFilter returns true if an Event lies inclusively within the bounds of the
+// times earliest and latest, and false otherwise. If earliest or latest are
+// zero times they will be ignored.
+func TimeFilter(event defs.Event, earliest, latest time.Time) bool {
+	return seaflogtime.Range{Earliest: earliest, Latest: latest}.Contains(event.Time)
+}
+
+// UnhandledToNote converts an unhandled event to a note event
+func UnhandledToNote(unhandled defs.Event) defs.Event {
+	return defs.Event{
+		Name:       "note",
+		Type:       "text",
+		Value:      unhandled.Line,
+		Line:       unhandled.Line,
+		LineNumber: unhandled.LineNumber,
+		Time:       unhandled.Time,
+		Seq:        unhandled.Seq,
+		Source:     unhandled.Source,
+		Instrument: unhandled.Instrument,
+	}
+}
@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/internal/proc"
+)
+
+// Plugin is an external program run as a filter or sink for events.
+//
+// The protocol is line oriented JSON over the program's STDIN and STDOUT.
+// Each event is written to STDIN as one JSON object with the fields of
+// pluginEvent. For every object read the program must write one line to
+// STDOUT holding a JSON array of events to pass on: the event itself,
+// modified events, several events, or an empty array to drop it. A sink
+// answers every event with an empty array. STDERR is passed through.
+//
+// JSON has no infinite or NaN numbers, so those float values are sent as the
+// strings "Inf", "-Inf", and "NaN", and these strings are read back as floats
+// in the value of float events answered.
+type Plugin struct {
+	path   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	enc    *json.Encoder
+	stdout *bufio.Scanner
+}
+
+// pluginEvent is the wire format of an event. Events with errors are never
+// sent to plugins so there is no error field.
+type pluginEvent struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Value      interface{} `json:"value"`
+	Line       string      `json:"line"`
+	LineNumber int         `json:"line_number"`
+	Time       time.Time   `json:"time"`
+	Seq        int         `json:"seq"`
+	Source     string      `json:"source"`
+	Instrument string      `json:"instrument"`
+}
+
+// StartPlugin starts the plugin program path with args.
+func StartPlugin(path string, args ...string) (*Plugin, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	proc.OwnProcessGroup(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", path, err)
+	}
+	s := bufio.NewScanner(stdout)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Plugin{path: path, cmd: cmd, stdin: stdin, enc: json.NewEncoder(stdin), stdout: s}, nil
+}
+
+// Process sends event to the plugin and returns the events it passes on.
+func (p *Plugin) Process(event defs.Event) ([]defs.Event, error) {
+	if err := p.enc.Encode(pluginEvent{
+		Name:       event.Name,
+		Type:       event.Type,
+		Value:      wireValue(event.Value),
+		Line:       event.Line,
+		LineNumber: event.LineNumber,
+		Time:       event.Time,
+		Seq:        event.Seq,
+		Source:     event.Source,
+		Instrument: event.Instrument,
+	}); err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", p.path, err)
+	}
+	if !p.stdout.Scan() {
+		if err := p.stdout.Err(); err != nil {
+			return nil, fmt.Errorf("plugin %s: %v", p.path, err)
+		}
+		return nil, fmt.Errorf("plugin %s: exited without answering event at line %d", p.path, event.LineNumber)
+	}
+	var answer []pluginEvent
+	if err := json.Unmarshal(p.stdout.Bytes(), &answer); err != nil {
+		return nil, fmt.Errorf("plugin %s: bad answer to event at line %d, %v", p.path, event.LineNumber, err)
+	}
+	events := make([]defs.Event, len(answer))
+	for i, pe := range answer {
+		events[i] = defs.Event{
+			Name:       pe.Name,
+			Type:       pe.Type,
+			Value:      eventValue(pe.Type, pe.Value),
+			Line:       pe.Line,
+			LineNumber: pe.LineNumber,
+			Time:       pe.Time,
+			Seq:        pe.Seq,
+			Source:     pe.Source,
+			Instrument: pe.Instrument,
+		}
+	}
+	return events, nil
+}
+
+// wireValue returns v as sent to plugins, with infinite and NaN floats as
+// strings.
+func wireValue(v interface{}) interface{} {
+	f, ok := v.(float64)
+	switch {
+	case !ok:
+		return v
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return v
+}
+
+// eventValue returns v of an event of type typ as answered by a plugin, with
+// infinite and NaN float strings as floats.
+func eventValue(typ string, v interface{}) interface{} {
+	if typ != "float" {
+		return v
+	}
+	switch v {
+	case "NaN":
+		return math.NaN()
+	case "Inf":
+		return math.Inf(1)
+	case "-Inf":
+		return math.Inf(-1)
+	}
+	return v
+}
+
+// Close closes the plugin's STDIN and waits for it to exit. Closing a closed
+// plugin does nothing.
+func (p *Plugin) Close() error {
+	if p.stdin == nil {
+		return nil
+	}
+	stdin := p.stdin
+	p.stdin = nil
+	if err := stdin.Close(); err != nil {
+		return fmt.Errorf("plugin %s: %v", p.path, err)
+	}
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("plugin %s: %v", p.path, err)
+	}
+	return nil
+}
+
+// Plugins is a chain of plugins, each receiving the events passed on by the
+// one before.
+type Plugins []*Plugin
+
+// Process runs event through the chain and returns the events passed on by
+// the last plugin.
+func (ps Plugins) Process(event defs.Event) ([]defs.Event, error) {
+	events := []defs.Event{event}
+	for _, p := range ps {
+		next := []defs.Event{}
+		for _, e := range events {
+			out, err := p.Process(e)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, out...)
+		}
+		events = next
+	}
+	return events, nil
+}
+
+// Close closes every plugin in the chain, returning the first error.
+func (ps Plugins) Close() error {
+	var first error
+	for _, p := range ps {
+		if err := p.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
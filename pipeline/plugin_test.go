@@ -0,0 +1,116 @@
+package pipeline_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+// TestHelperPlugin is not a real test, it's the plugin program started by
+// TestPlugin. It drops text events and doubles finite float values.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("SEAFLOG_HELPER_PLUGIN") != "1" {
+		return
+	}
+	s := bufio.NewScanner(os.Stdin)
+	for s.Scan() {
+		var e map[string]interface{}
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		answer := []map[string]interface{}{}
+		if e["type"] == "float" {
+			if v, ok := e["value"].(float64); ok {
+				e["value"] = v * 2
+			}
+			answer = append(answer, e)
+		}
+		b, _ := json.Marshal(answer)
+		fmt.Println(string(b))
+	}
+	os.Exit(0)
+}
+
+func TestPlugin(t *testing.T) {
+	os.Setenv("SEAFLOG_HELPER_PLUGIN", "1")
+	defer os.Unsetenv("SEAFLOG_HELPER_PLUGIN")
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+
+	p, err := pipeline.StartPlugin(os.Args[0], "-test.run=TestHelperPlugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugins := pipeline.Plugins{p}
+
+	tests := []struct {
+		name  string
+		event defs.Event
+		want  []defs.Event
+	}{
+		{
+			name:  "float modified",
+			event: defs.Event{Name: "flow", Type: "float", Value: 1.5, Line: "flow: 1.5", LineNumber: 2, Time: t0, Seq: 2, Source: "SFlog_740.txt", Instrument: "SN740"},
+			want:  []defs.Event{{Name: "flow", Type: "float", Value: 3.0, Line: "flow: 1.5", LineNumber: 2, Time: t0, Seq: 2, Source: "SFlog_740.txt", Instrument: "SN740"}},
+		},
+		{
+			name:  "infinite float",
+			event: defs.Event{Name: "flow", Type: "float", Value: math.Inf(-1), Line: "flow: -inf", LineNumber: 3, Time: t0, Seq: 3},
+			want:  []defs.Event{{Name: "flow", Type: "float", Value: math.Inf(-1), Line: "flow: -inf", LineNumber: 3, Time: t0, Seq: 3}},
+		},
+		{
+			name:  "text dropped",
+			event: defs.Event{Name: "note", Type: "text", Value: "hello", Line: "hello", LineNumber: 4, Time: t0, Seq: 3},
+			want:  []defs.Event{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plugins.Process(tt.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("len(got) %v; want %v", len(got), len(tt.want))
+			}
+			for i := range got {
+				eventsEqual(got[i], tt.want[i], t)
+			}
+		})
+	}
+	t.Run("NaN float", func(t *testing.T) {
+		got, err := plugins.Process(defs.Event{Name: "PMT1", Type: "float", Value: math.NaN(), Time: t0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("len(got) %v; want 1", len(got))
+		}
+		if v, ok := got[0].Value.(float64); !ok || !math.IsNaN(v) {
+			t.Errorf("Event.Value %v; want NaN", got[0].Value)
+		}
+	})
+	if err := plugins.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPluginExited(t *testing.T) {
+	// Without the environment variable the helper is not a plugin and its
+	// output is not an answer
+	p, err := pipeline.StartPlugin(os.Args[0], "-test.run=TestHelperPlugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Process(defs.Event{Name: "flow", Type: "float", Value: 1.0}); err == nil {
+		t.Error("Plugin.Process() with an exited plugin; want an error")
+	}
+	p.Close()
+}
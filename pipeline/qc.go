@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// QC flags, following the IOOS QARTOD convention.
+const (
+	QCPass    = 1
+	QCSuspect = 3
+	QCFail    = 4
+	QCMissing = 9
+)
+
+// QCFlag records a value which did not pass quality control.
+type QCFlag struct {
+	Name       string
+	LineNumber int
+	Value      interface{}
+	Flag       int
+	Reason     string
+}
+
+func (f QCFlag) String() string {
+	kind := "suspect"
+	if f.Flag == QCFail {
+		kind = "failed"
+	}
+	return fmt.Sprintf("Line %d, %s value %v %s QC, %s", f.LineNumber, f.Name, f.Value, kind, f.Reason)
+}
+
+type qcState struct {
+	last   float64
+	repeat int // consecutive values equal to last
+}
+
+// QC flags float values of events with quality control rules in their
+// definitions. Flatline detection depends on previous values, so events must
+// be checked in log order.
+type QC struct {
+	defs    map[string]defs.QCDef
+	state   map[string]*qcState
+	flagged []QCFlag
+}
+
+// NewQC creates a QC for all events in EventDefs with quality control rules.
+func NewQC() *QC {
+	q := &QC{defs: make(map[string]defs.QCDef), state: make(map[string]*qcState)}
+	for name, edef := range defs.EventDefs {
+		if edef.QC != nil {
+			q.defs[name] = *edef.QC
+			q.state[name] = &qcState{}
+		}
+	}
+	return q
+}
+
+// Names returns the names of events with quality control rules in sorted
+// order.
+func (q *QC) Names() []string {
+	names := make([]string, 0, len(q.defs))
+	for name := range q.defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check returns the QC flag for event. ok is false if event has no quality
+// control rules. NaN values are flagged missing and infinite values fail.
+func (q *QC) Check(event defs.Event) (flag int, ok bool) {
+	qc, ok := q.defs[event.Name]
+	if !ok || event.Error != nil {
+		return 0, false
+	}
+	if event.Value == nil {
+		return QCMissing, true
+	}
+	val, isFloat := event.Value.(float64)
+	if !isFloat {
+		return 0, false
+	}
+	if math.IsNaN(val) {
+		return QCMissing, true
+	}
+
+	st := q.state[event.Name]
+	if st.repeat > 0 && val == st.last {
+		st.repeat++
+	} else {
+		st.last = val
+		st.repeat = 1
+	}
+
+	flag = QCPass
+	reason := ""
+	switch {
+	case math.IsInf(val, 0):
+		flag, reason = QCFail, "not finite"
+	case qc.Min != nil && val < *qc.Min:
+		flag, reason = QCFail, fmt.Sprintf("below minimum %v", *qc.Min)
+	case qc.Max != nil && val > *qc.Max:
+		flag, reason = QCFail, fmt.Sprintf("above maximum %v", *qc.Max)
+	case qc.Flatline > 0 && st.repeat >= qc.Flatline:
+		flag, reason = QCSuspect, fmt.Sprintf("unchanged for %d values", st.repeat)
+	}
+	if flag != QCPass {
+		q.flagged = append(q.flagged, QCFlag{
+			Name: event.Name, LineNumber: event.LineNumber, Value: val, Flag: flag, Reason: reason,
+		})
+	}
+	return flag, true
+}
+
+// Flagged returns all values flagged suspect or failed so far.
+func (q *QC) Flagged() []QCFlag {
+	return q.flagged
+}
@@ -0,0 +1,75 @@
+package pipeline_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestQC(t *testing.T) {
+	lo, hi := 0.0, 10.0
+	defs.EventDefs["test_qc"] = defs.EventDef{
+		Name: "test_qc",
+		Type: "float",
+		QC:   &defs.QCDef{Min: &lo, Max: &hi, Flatline: 3},
+		EventForms: []defs.EventForm{
+			{StartsWith: "test qc:", ValueAction: "as_float"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_qc")
+
+	input := "2015-03-14T00-00-00+00-00\n" +
+		"test qc:5\ntest qc:11\ntest qc:-1\ntest qc:2\ntest qc:2\ntest qc:2\ntest qc:2\ntest qc:3\n" +
+		"Syringe pump injection:5\n"
+	want := []int{
+		pipeline.QCPass, pipeline.QCFail, pipeline.QCFail, pipeline.QCPass,
+		pipeline.QCPass, pipeline.QCSuspect, pipeline.QCSuspect, pipeline.QCPass,
+	}
+
+	qc := pipeline.NewQC()
+	if names := qc.Names(); len(names) != 1 || names[0] != "test_qc" {
+		t.Fatalf("Names() = %v; want [test_qc]", names)
+	}
+	got := []int{}
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		if flag, ok := qc.Check(scanner.Event()); ok {
+			got = append(got, flag)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("flag %d = %v; want %v", i, got[i], want[i])
+		}
+	}
+
+	flagged := qc.Flagged()
+	if len(flagged) != 4 {
+		t.Fatalf("len(Flagged()) %v; want 4", len(flagged))
+	}
+	if flagged[0].LineNumber != 3 || flagged[0].Flag != pipeline.QCFail {
+		t.Errorf("Flagged()[0] = %+v; want line 3 failed", flagged[0])
+	}
+
+	if flag, ok := qc.Check(defs.Event{Name: "test_qc", Type: "float"}); !ok || flag != pipeline.QCMissing {
+		t.Errorf("Check(nil value) = %v, %v; want %v, true", flag, ok, pipeline.QCMissing)
+	}
+	if flag, ok := qc.Check(defs.Event{Name: "test_qc", Type: "float", Value: math.NaN()}); !ok || flag != pipeline.QCMissing {
+		t.Errorf("Check(NaN) = %v, %v; want %v, true", flag, ok, pipeline.QCMissing)
+	}
+	for _, v := range []float64{math.Inf(1), math.Inf(-1)} {
+		if flag, ok := qc.Check(defs.Event{Name: "test_qc", Type: "float", Value: v}); !ok || flag != pipeline.QCFail {
+			t.Errorf("Check(%v) = %v, %v; want %v, true", v, flag, ok, pipeline.QCFail)
+		}
+	}
+}
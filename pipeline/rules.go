@@ -0,0 +1,206 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Condition compares the latest value of a float or boolean event with Value.
+type Condition struct {
+	Event string      `json:"event"`
+	Op    string      `json:"op"` // ==, !=, <, <=, >, or >=
+	Value interface{} `json:"value"`
+}
+
+// Rule emits an alert when its When and While conditions have all held for at
+// least For, e.g. flow rate 0 for over 5 minutes while acquisition is running.
+// A rule fires once each time its conditions start to hold.
+type Rule struct {
+	Name    string      `json:"name"`
+	When    Condition   `json:"when"`
+	While   []Condition `json:"while"`
+	For     string      `json:"for"` // Go duration, e.g. 5m, empty for 0
+	Message string      `json:"message"`
+	dur     time.Duration
+}
+
+// ParseRules parses a JSON document of correlation rules, of the form
+// {"rules": [{"name": ..., "when": {...}, "while": [...], "for": ..., "message": ...}]}.
+func ParseRules(b []byte) ([]Rule, error) {
+	var doc struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for i := range doc.Rules {
+		rule := &doc.Rules[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.For != "" {
+			d, err := time.ParseDuration(rule.For)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("rule %q has bad duration %q", rule.Name, rule.For)
+			}
+			rule.dur = d
+		}
+		for _, cond := range append([]Condition{rule.When}, rule.While...) {
+			if err := cond.check(); err != nil {
+				return nil, fmt.Errorf("rule %q: %v", rule.Name, err)
+			}
+		}
+	}
+	return doc.Rules, nil
+}
+
+// check returns an error if c can't be evaluated.
+func (c Condition) check() error {
+	edef, ok := defs.Lookup(c.Event)
+	if !ok {
+		return fmt.Errorf("unknown event %q", c.Event)
+	}
+	switch edef.Type {
+	case "float":
+		if _, ok := c.Value.(float64); !ok {
+			return fmt.Errorf("float event %s compared with %v", c.Event, c.Value)
+		}
+		switch c.Op {
+		case "==", "!=", "<", "<=", ">", ">=":
+		default:
+			return fmt.Errorf("unknown operator %q", c.Op)
+		}
+	case "boolean":
+		if _, ok := c.Value.(bool); !ok {
+			return fmt.Errorf("boolean event %s compared with %v", c.Event, c.Value)
+		}
+		if c.Op != "==" && c.Op != "!=" {
+			return fmt.Errorf("operator %q can't compare boolean event %s", c.Op, c.Event)
+		}
+	default:
+		return fmt.Errorf("event %s has type %s, not float or boolean", c.Event, edef.Type)
+	}
+	return nil
+}
+
+// holds reports whether c holds for the latest values in snapshot. Conditions
+// on events with no value yet don't hold.
+func (c Condition) holds(snapshot map[string]interface{}) bool {
+	v, ok := snapshot[c.Event]
+	if !ok {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return (b == c.Value) == (c.Op == "==")
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return false
+	}
+	want := c.Value.(float64)
+	switch c.Op {
+	case "==":
+		return f == want
+	case "!=":
+		return f != want
+	case "<":
+		return f < want
+	case "<=":
+		return f <= want
+	case ">":
+		return f > want
+	default:
+		return f >= want
+	}
+}
+
+type ruleState struct {
+	since time.Time // when conditions started to hold, zero if they don't
+	fired bool
+}
+
+// Rules evaluates correlation rules against a running snapshot of the latest
+// value of each float and boolean event.
+type Rules struct {
+	rules    []Rule
+	state    []ruleState
+	snapshot map[string]interface{}
+}
+
+// NewRules creates Rules for rules.
+func NewRules(rules []Rule) *Rules {
+	return &Rules{rules: rules, state: make([]ruleState, len(rules)), snapshot: make(map[string]interface{})}
+}
+
+// Add adds event to the snapshot and returns an alert event for each rule
+// which fires. Rules are only evaluated as events arrive, so a rule fires at
+// the first event at least For after its conditions started to hold. Events
+// must be added in time order. After a defs.RestartEvent event all values and
+// rules start over.
+func (r *Rules) Add(event defs.Event) []defs.Event {
+	if event.Error != nil {
+		return nil
+	}
+	if event.Name == defs.RestartEvent {
+		r.snapshot = make(map[string]interface{})
+		r.state = make([]ruleState, len(r.rules))
+		return nil
+	}
+	switch event.Value.(type) {
+	case float64, bool:
+		r.snapshot[event.Name] = event.Value
+	default:
+		return nil
+	}
+
+	var alerts []defs.Event
+	for i, rule := range r.rules {
+		st := &r.state[i]
+		if !r.holds(rule) {
+			*st = ruleState{}
+			continue
+		}
+		if st.since.IsZero() {
+			st.since = event.Time
+		}
+		if !st.fired && event.Time.Sub(st.since) >= rule.dur {
+			st.fired = true
+			text := rule.Name
+			if rule.Message != "" {
+				text += ": " + rule.Message
+			}
+			alerts = append(alerts, defs.Event{
+				Name:       defs.AlertEvent,
+				Type:       "text",
+				Value:      text,
+				Line:       event.Line,
+				LineNumber: event.LineNumber,
+				Time:       event.Time,
+				Source:     event.Source,
+				Instrument: event.Instrument,
+			})
+		}
+	}
+	return alerts
+}
+
+// holds reports whether all conditions of rule hold.
+func (r *Rules) holds(rule Rule) bool {
+	if !rule.When.holds(r.snapshot) {
+		return false
+	}
+	for _, cond := range rule.While {
+		if !cond.holds(r.snapshot) {
+			return false
+		}
+	}
+	return true
+}
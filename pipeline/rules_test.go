@@ -0,0 +1,113 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			json: `{"rules": [{"name": "no flow", "when": {"event": "pump_voltage_change", "op": "==", "value": 0},
+				"while": [{"event": "stream_pressure_locked", "op": "==", "value": true}], "for": "5m"}]}`,
+		},
+		{
+			name:    "no name",
+			json:    `{"rules": [{"when": {"event": "PMT1", "op": ">", "value": 1}}]}`,
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			json: `{"rules": [{"name": "a", "when": {"event": "PMT1", "op": ">", "value": 1}},
+				{"name": "a", "when": {"event": "PMT1", "op": "<", "value": 1}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "bad duration",
+			json:    `{"rules": [{"name": "a", "when": {"event": "PMT1", "op": ">", "value": 1}, "for": "soon"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown event",
+			json:    `{"rules": [{"name": "a", "when": {"event": "nope", "op": ">", "value": 1}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "text event",
+			json:    `{"rules": [{"name": "a", "when": {"event": "note", "op": "==", "value": 1}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "bad operator",
+			json:    `{"rules": [{"name": "a", "when": {"event": "PMT1", "op": "=~", "value": 1}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "ordered boolean",
+			json:    `{"rules": [{"name": "a", "when": {"event": "stream_pressure_locked", "op": "<", "value": true}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "float compared with boolean",
+			json:    `{"rules": [{"name": "a", "when": {"event": "PMT1", "op": "==", "value": true}}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pipeline.ParseRules([]byte(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRules() error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	parsed, err := pipeline.ParseRules([]byte(`{"rules": [
+		{"name": "dark", "when": {"event": "PMT1", "op": "<", "value": 0.5},
+		 "while": [{"event": "write_evt", "op": "==", "value": 1}], "for": "5m", "message": "PMT1 low while acquiring"}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v; want nil", err)
+	}
+	rules := pipeline.NewRules(parsed)
+
+	input := "2015-03-14T00-00-00+00-00\nPMT1:0.1\n" + // not acquiring
+		"2015-03-14T00-01-00+00-00\nwrite evt: 1\n" + // conditions start to hold
+		"2015-03-14T00-04-00+00-00\nPMT1:0.2\n" +
+		"2015-03-14T00-06-00+00-00\nPMT1:0.3\n" + // fires
+		"2015-03-14T00-20-00+00-00\nPMT1:0.3\n" + // already fired
+		"2015-03-14T00-21-00+00-00\nPMT1:1\n" + // re-arms
+		"2015-03-14T00-22-00+00-00\nPMT1:0.1\n" +
+		"2015-03-14T00-27-00+00-00\nPMT1:0.1\n" // fires again
+	alerts := []defs.Event{}
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		alerts = append(alerts, rules.Add(scanner.Event())...)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("len(alerts) %v; want 2", len(alerts))
+	}
+	wantLines := []int{8, 16}
+	for i, alert := range alerts {
+		if alert.Name != defs.AlertEvent || alert.Value != "dark: PMT1 low while acquiring" {
+			t.Errorf("alert %d = %v %v; want %v dark: PMT1 low while acquiring", i, alert.Name, alert.Value, defs.AlertEvent)
+		}
+		if alert.LineNumber != wantLines[i] {
+			t.Errorf("alert %d line %v; want %v", i, alert.LineNumber, wantLines[i])
+		}
+	}
+}
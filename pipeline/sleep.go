@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"math"
+	"time"
+)
+
+// SleepInterval is the name of the interval definition pairing sleeping and
+// waking events.
+const SleepInterval = "sleep"
+
+// SleepDay is the sleep within one UTC day.
+type SleepDay struct {
+	Date    time.Time  // UTC midnight
+	Windows []Interval // sleep intervals, clipped to the day
+	Sleep   time.Duration
+}
+
+// SleepReport summarizes the sleep/wake cycle of an instrument over a span of
+// time.
+type SleepReport struct {
+	Start time.Time
+	End   time.Time
+	Sleep time.Duration
+	// DutyCycle is the fraction of time from Start to End the instrument was
+	// awake, or NaN for an empty span.
+	DutyCycle float64
+	Days      []SleepDay
+}
+
+// AnalyzeSleep summarizes the SleepInterval intervals, which must be in time
+// order, over the span from start to end, e.g. the first and last event times
+// of a log. Sleep intervals which were never stopped end at end.
+func AnalyzeSleep(intervals []Interval, start time.Time, end time.Time) SleepReport {
+	r := SleepReport{Start: start, End: end}
+	for _, iv := range intervals {
+		if iv.Name != SleepInterval {
+			continue
+		}
+		if iv.End.IsZero() {
+			iv.End = end
+		}
+		if iv.End.Before(iv.Start) {
+			continue
+		}
+		r.Sleep += iv.End.Sub(iv.Start)
+		// Split at UTC midnights
+		for day := iv.Start.UTC().Truncate(24 * time.Hour); ; day = day.Add(24 * time.Hour) {
+			next := day.Add(24 * time.Hour)
+			w := iv
+			if w.Start.Before(day) {
+				w.Start = day
+			}
+			if w.End.After(next) {
+				w.End = next
+			}
+			if n := len(r.Days); n == 0 || !r.Days[n-1].Date.Equal(day) {
+				r.Days = append(r.Days, SleepDay{Date: day})
+			}
+			d := &r.Days[len(r.Days)-1]
+			d.Windows = append(d.Windows, w)
+			d.Sleep += w.Duration()
+			if !next.Before(iv.End) {
+				break
+			}
+		}
+	}
+	span := end.Sub(start)
+	if span <= 0 {
+		r.DutyCycle = math.NaN()
+	} else {
+		r.DutyCycle = 1 - float64(r.Sleep)/float64(span)
+	}
+	return r
+}
@@ -0,0 +1,51 @@
+package pipeline_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestAnalyzeSleep(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T20:00:00+00:00")
+	intervals := []pipeline.Interval{
+		{Name: "sleep", Start: t0, End: t0.Add(time.Hour)},
+		{Name: "acquisition", Start: t0, End: t0.Add(10 * time.Hour)},
+		{Name: "sleep", Start: t0.Add(3 * time.Hour), End: t0.Add(6 * time.Hour)}, // 23:00 to 02:00
+		{Name: "sleep", Start: t0.Add(9 * time.Hour)},                             // unfinished
+	}
+	r := pipeline.AnalyzeSleep(intervals, t0, t0.Add(10*time.Hour))
+	if r.Sleep != 5*time.Hour {
+		t.Errorf("Sleep %v; want 5h", r.Sleep)
+	}
+	if r.DutyCycle != 0.5 {
+		t.Errorf("DutyCycle %v; want 0.5", r.DutyCycle)
+	}
+	day1 := time.Date(2015, 3, 14, 0, 0, 0, 0, time.UTC)
+	want := []struct {
+		date    time.Time
+		windows int
+		sleep   time.Duration
+	}{
+		{day1, 2, 2 * time.Hour},
+		{day1.Add(24 * time.Hour), 2, 3 * time.Hour},
+	}
+	if len(r.Days) != len(want) {
+		t.Fatalf("len(Days) %v; want %v", len(r.Days), len(want))
+	}
+	for i, w := range want {
+		d := r.Days[i]
+		if !d.Date.Equal(w.date) || len(d.Windows) != w.windows || d.Sleep != w.sleep {
+			t.Errorf("Days[%d] %v, %d windows, %v; want %v, %d windows, %v", i, d.Date, len(d.Windows), d.Sleep, w.date, w.windows, w.sleep)
+		}
+	}
+	if end := r.Days[0].Windows[1].End; !end.Equal(day1.Add(24 * time.Hour)) {
+		t.Errorf("split window end %v; want midnight", end)
+	}
+
+	if r := pipeline.AnalyzeSleep(nil, t0, t0); !math.IsNaN(r.DutyCycle) || len(r.Days) != 0 {
+		t.Errorf("empty span report %+v; want NaN duty cycle, no days", r)
+	}
+}
@@ -0,0 +1,293 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Bucket is one histogram bucket covering [Start, End), or [Start, End] for
+// the last bucket.
+type Bucket struct {
+	Start float64
+	End   float64
+	Count int
+}
+
+// Summaries collects all float values of each event to summarize their
+// distributions. With Spill, values beyond a memory budget are kept in a
+// temporary file instead, so logs of any length can be summarized.
+type Summaries struct {
+	values map[string][]float64 // in memory
+	sorted map[string]bool
+	stats  map[string]*valueStats
+
+	// Spilling
+	budget int // values held in memory before spilling, 0 for no limit
+	dir    string
+	held   int // values held in memory
+	file   *os.File
+	linked bool // file still has a name to remove on Close
+	size   int64
+	runs   map[string][]spillRun
+	err    error
+}
+
+type valueStats struct {
+	count    int
+	min, max float64
+}
+
+// spillRun is a sorted run of values in the spill file.
+type spillRun struct {
+	off int64
+	n   int
+}
+
+// NewSummaries creates an empty Summaries.
+func NewSummaries() *Summaries {
+	return &Summaries{
+		values: make(map[string][]float64),
+		sorted: make(map[string]bool),
+		stats:  make(map[string]*valueStats),
+		runs:   make(map[string][]spillRun),
+	}
+}
+
+// Spill limits the memory used for values to about budget bytes. Beyond that
+// the largest set of values held is sorted and written to a temporary file in
+// dir, "" for the default temporary directory. Where open files can be
+// removed, e.g. on Unix, the file is removed as soon as it's created, so it
+// doesn't outlive a crash. Elsewhere, e.g. on Windows, it's removed by Close.
+// Call Close to release it.
+func (s *Summaries) Spill(budget int64, dir string) {
+	s.budget = int(budget / 8)
+	if s.budget < 1 {
+		s.budget = 1
+	}
+	s.dir = dir
+}
+
+// Err returns the first error writing or reading spilled values. Summaries
+// with an error are incomplete.
+func (s *Summaries) Err() error {
+	return s.err
+}
+
+// Close releases the temporary file of spilled values.
+func (s *Summaries) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	f := s.file
+	s.file = nil
+	err := f.Close()
+	if s.linked {
+		s.linked = false
+		if rerr := os.Remove(f.Name()); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// Add adds the value of a float event. Events with an error, no float value,
+// or an infinite or NaN value are ignored.
+func (s *Summaries) Add(event defs.Event) {
+	v, ok := event.Value.(float64)
+	if !ok || event.Error != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	st := s.stats[event.Name]
+	if st == nil {
+		st = &valueStats{min: v, max: v}
+		s.stats[event.Name] = st
+	}
+	st.count++
+	st.min = math.Min(st.min, v)
+	st.max = math.Max(st.max, v)
+	s.values[event.Name] = append(s.values[event.Name], v)
+	s.sorted[event.Name] = false
+	s.held++
+	if s.budget > 0 && s.held > s.budget && s.err == nil {
+		s.err = s.spill()
+	}
+}
+
+// spill writes the largest set of values held in memory to the spill file as
+// a sorted run.
+func (s *Summaries) spill() error {
+	if s.file == nil {
+		f, err := os.CreateTemp(s.dir, "seaflog-spill-*")
+		if err != nil {
+			return err
+		}
+		// Removing an open file fails on Windows, Close removes it there
+		s.linked = os.Remove(f.Name()) != nil
+		s.file = f
+	}
+	name := ""
+	for n, vs := range s.values {
+		if name == "" || len(vs) > len(s.values[name]) || (len(vs) == len(s.values[name]) && n < name) {
+			name = n
+		}
+	}
+	vs := s.sortedValues(name)
+	buf := make([]byte, 8*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(v))
+	}
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		return err
+	}
+	s.runs[name] = append(s.runs[name], spillRun{off: s.size, n: len(vs)})
+	s.size += int64(len(buf))
+	s.held -= len(vs)
+	s.values[name] = nil
+	return nil
+}
+
+// Names returns the names of events with values in sorted order.
+func (s *Summaries) Names() []string {
+	names := make([]string, 0, len(s.stats))
+	for name := range s.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Count returns the number of values of event name.
+func (s *Summaries) Count(name string) int {
+	if st := s.stats[name]; st != nil {
+		return st.count
+	}
+	return 0
+}
+
+// Quantile returns quantile q, between 0 and 1, of the values of event name,
+// interpolating linearly between the closest ranks. It returns NaN if there are
+// no values.
+func (s *Summaries) Quantile(name string, q float64) float64 {
+	n := s.Count(name)
+	if n == 0 {
+		return math.NaN()
+	}
+	pos := q * float64(n-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	var vlo, vhi float64
+	i := 0
+	s.eachSorted(name, func(v float64) bool {
+		if i == lo {
+			vlo = v
+		}
+		if i == hi {
+			vhi = v
+			return false
+		}
+		i++
+		return true
+	})
+	return vlo + (vhi-vlo)*(pos-float64(lo))
+}
+
+// Histogram returns n equal width buckets spanning the values of event name.
+// If all values are equal there is a single bucket.
+func (s *Summaries) Histogram(name string, n int) []Bucket {
+	st := s.stats[name]
+	if st == nil || n < 1 {
+		return nil
+	}
+	min, max := st.min, st.max
+	if min == max {
+		return []Bucket{{Start: min, End: max, Count: st.count}}
+	}
+	width := (max - min) / float64(n)
+	buckets := make([]Bucket, n)
+	for i := range buckets {
+		buckets[i].Start = min + float64(i)*width
+		buckets[i].End = min + float64(i+1)*width
+	}
+	buckets[n-1].End = max
+	s.eachSorted(name, func(v float64) bool {
+		i := int((v - min) / width)
+		if i >= n {
+			i = n - 1
+		}
+		buckets[i].Count++
+		return true
+	})
+	return buckets
+}
+
+func (s *Summaries) sortedValues(name string) []float64 {
+	if !s.sorted[name] {
+		sort.Float64s(s.values[name])
+		s.sorted[name] = true
+	}
+	return s.values[name]
+}
+
+// eachSorted calls f with the values of event name in ascending order, merging
+// spilled runs with values in memory, until f returns false.
+func (s *Summaries) eachSorted(name string, f func(float64) bool) {
+	type cursor struct {
+		r    *bufio.Reader
+		left int
+		v    float64
+	}
+	cursors := []*cursor{}
+	next := func(c *cursor) bool {
+		if c.left == 0 {
+			return false
+		}
+		var b [8]byte
+		if _, err := io.ReadFull(c.r, b[:]); err != nil {
+			if s.err == nil {
+				s.err = err
+			}
+			return false
+		}
+		c.v = math.Float64frombits(binary.LittleEndian.Uint64(b[:]))
+		c.left--
+		return true
+	}
+	for _, run := range s.runs[name] {
+		c := &cursor{r: bufio.NewReader(io.NewSectionReader(s.file, run.off, int64(8*run.n))), left: run.n}
+		if next(c) {
+			cursors = append(cursors, c)
+		}
+	}
+	mem := s.sortedValues(name)
+	for {
+		// Smallest of the memory and run heads
+		best := -1
+		for i, c := range cursors {
+			if best < 0 || c.v < cursors[best].v {
+				best = i
+			}
+		}
+		if len(mem) > 0 && (best < 0 || mem[0] <= cursors[best].v) {
+			if !f(mem[0]) {
+				return
+			}
+			mem = mem[1:]
+			continue
+		}
+		if best < 0 {
+			return
+		}
+		if !f(cursors[best].v) {
+			return
+		}
+		if !next(cursors[best]) {
+			cursors = append(cursors[:best], cursors[best+1:]...)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package pipeline_test
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestSummaries(t *testing.T) {
+	s := pipeline.NewSummaries()
+	for _, v := range []float64{5, 1, math.NaN(), 4, 2, math.Inf(1), 3} {
+		s.Add(defs.Event{Name: "PMT1", Value: v})
+	}
+	s.Add(defs.Event{Name: "note", Value: "text"})
+	s.Add(defs.Event{Name: "PMT2", Value: math.NaN()})
+
+	if names := s.Names(); len(names) != 1 || names[0] != "PMT1" {
+		t.Errorf("Names() %v; want [PMT1]", names)
+	}
+	quantiles := []struct{ q, want float64 }{{0, 1}, {0.5, 3}, {0.95, 4.8}, {1, 5}}
+	for _, tt := range quantiles {
+		if got := s.Quantile("PMT1", tt.q); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Quantile(%v) %v; want %v", tt.q, got, tt.want)
+		}
+	}
+	if got := s.Quantile("missing", 0.5); !math.IsNaN(got) {
+		t.Errorf("Quantile(missing) %v; want NaN", got)
+	}
+
+	want := []pipeline.Bucket{{1, 3, 2}, {3, 5, 3}}
+	got := s.Histogram("PMT1", 2)
+	if len(got) != len(want) {
+		t.Fatalf("len(Histogram()) %v; want %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Bucket %+v; want %+v", got[i], want[i])
+		}
+	}
+}
+
+func TestSummariesSpill(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	mem := pipeline.NewSummaries()
+	spilled := pipeline.NewSummaries()
+	dir := t.TempDir()
+	spilled.Spill(8*50, dir) // 50 values
+	for i := 0; i < 1000; i++ {
+		event := defs.Event{Name: []string{"PMT1", "PMT2", "trigger_level"}[r.Intn(3)], Value: r.NormFloat64()}
+		if i%100 == 0 {
+			event.Value = 0.0 // repeated values
+		}
+		mem.Add(event)
+		spilled.Add(event)
+	}
+	if err := spilled.Err(); err != nil {
+		t.Fatalf("Err() = %v; want nil", err)
+	}
+
+	for _, name := range mem.Names() {
+		if got, want := spilled.Count(name), mem.Count(name); got != want {
+			t.Errorf("%s Count() %v; want %v", name, got, want)
+		}
+		for _, q := range []float64{0, 0.05, 0.33, 0.5, 0.95, 1} {
+			if got, want := spilled.Quantile(name, q), mem.Quantile(name, q); got != want {
+				t.Errorf("%s Quantile(%v) %v; want %v", name, q, got, want)
+			}
+		}
+		got, want := spilled.Histogram(name, 7), mem.Histogram(name, 7)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s Bucket %+v; want %+v", name, got[i], want[i])
+			}
+		}
+	}
+	if err := spilled.Err(); err != nil {
+		t.Errorf("Err() after reading = %v; want nil", err)
+	}
+	if err := spilled.Close(); err != nil {
+		t.Errorf("Close() error = %v; want nil", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("spill directory after Close() %v, %v; want empty", entries, err)
+	}
+}
@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// unit defines a unit by conversion to and from the base unit of its
+// dimension.
+type unit struct {
+	dimension string
+	toBase    func(float64) float64
+	fromBase  func(float64) float64
+}
+
+func scaled(dimension string, perBase float64) unit {
+	return unit{
+		dimension: dimension,
+		toBase:    func(v float64) float64 { return v / perBase },
+		fromBase:  func(v float64) float64 { return v * perBase },
+	}
+}
+
+// units holds known units keyed by name.
+var units = map[string]unit{
+	"V":      scaled("voltage", 1),
+	"mV":     scaled("voltage", 1000),
+	"degC":   scaled("temperature", 1),
+	"degF":   {"temperature", func(v float64) float64 { return (v - 32) * 5 / 9 }, func(v float64) float64 { return v*9/5 + 32 }},
+	"K":      {"temperature", func(v float64) float64 { return v - 273.15 }, func(v float64) float64 { return v + 273.15 }},
+	"mL/min": scaled("flow", 1),
+	"uL/min": scaled("flow", 1000),
+	"W":      scaled("power", 1),
+	"mW":     scaled("power", 1000),
+}
+
+// UnitSystems are named sets of target units. Instrument voltages, flows, and
+// laser powers are reported in the same units in both.
+var UnitSystems = map[string]string{
+	"si": "V,degC,mL/min,mW",
+	"us": "V,degF,mL/min,mW",
+}
+
+// normalizeUnit maps alternate spellings of a unit to its name in units.
+func normalizeUnit(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.ReplaceAll(name, "µ", "u")
+	name = strings.ReplaceAll(name, "°", "deg")
+	return name
+}
+
+// ConvertUnit converts v from one unit to another of the same dimension.
+func ConvertUnit(v float64, from string, to string) (float64, error) {
+	fromUnit, ok := units[normalizeUnit(from)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	toUnit, ok := units[normalizeUnit(to)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if fromUnit.dimension != toUnit.dimension {
+		return 0, fmt.Errorf("can't convert %s to %s", from, to)
+	}
+	if normalizeUnit(from) == normalizeUnit(to) {
+		return v, nil
+	}
+	return toUnit.fromBase(fromUnit.toBase(v)), nil
+}
+
+// UnitConverter converts event values from the unit declared in their event
+// definition to a target unit system.
+type UnitConverter struct {
+	targets map[string]string // target unit by dimension
+}
+
+// NewUnitConverter creates a UnitConverter for a unit system, either a name in
+// UnitSystems or a comma-separated list of target units, e.g. "mV,degF". Units
+// for dimensions not in the system are left as is.
+func NewUnitConverter(system string) (*UnitConverter, error) {
+	if named, ok := UnitSystems[system]; ok {
+		system = named
+	}
+	uc := &UnitConverter{targets: make(map[string]string)}
+	for _, name := range strings.Split(system, ",") {
+		name = normalizeUnit(name)
+		u, ok := units[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown unit %q in unit system %q", name, system)
+		}
+		if prev, ok := uc.targets[u.dimension]; ok {
+			return nil, fmt.Errorf("unit system %q has more than one %s unit, %s and %s", system, u.dimension, prev, name)
+		}
+		uc.targets[u.dimension] = name
+	}
+	return uc, nil
+}
+
+// Target returns the unit values in unit from are converted to.
+func (uc *UnitConverter) Target(from string) string {
+	if u, ok := units[normalizeUnit(from)]; ok {
+		if to, ok := uc.targets[u.dimension]; ok {
+			return to
+		}
+	}
+	return from
+}
+
+// Convert returns event with a float value converted to the target unit for
+// the unit in its event definition. Values in units missing from the units
+// table are passed through unchanged.
+func (uc *UnitConverter) Convert(event defs.Event) (defs.Event, error) {
+	v, ok := event.Value.(float64)
+	if !ok {
+		return event, nil
+	}
+	edef, _ := defs.Lookup(event.Name)
+	from := edef.Unit
+	if _, ok := units[normalizeUnit(from)]; !ok {
+		return event, nil
+	}
+	converted, err := ConvertUnit(v, from, uc.Target(from))
+	if err != nil {
+		return event, err
+	}
+	event.Value = converted
+	return event, nil
+}
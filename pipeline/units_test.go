@@ -0,0 +1,68 @@
+package pipeline_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestConvertUnit(t *testing.T) {
+	tests := []struct {
+		v    float64
+		from string
+		to   string
+		want float64
+	}{
+		{1.05, "V", "mV", 1050},
+		{1050, "mV", "V", 1.05},
+		{100, "degC", "degF", 212},
+		{-40, "°F", "°C", -40},
+		{0.25, "mL/min", "µL/min", 250},
+		{250, "uL/min", "mL/min", 0.25},
+		{1, "V", "V", 1},
+		{1500, "mW", "W", 1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"_"+tt.to, func(t *testing.T) {
+			got, err := pipeline.ConvertUnit(tt.v, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("ConvertUnit() error = %v; want nil", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ConvertUnit(%v, %q, %q) = %v; want %v", tt.v, tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("incompatible", func(t *testing.T) {
+		if _, err := pipeline.ConvertUnit(1, "V", "degC"); err == nil {
+			t.Errorf("ConvertUnit() error = nil; want an error")
+		}
+	})
+}
+
+func TestUnitConverterLaserPower(t *testing.T) {
+	uc, err := pipeline.NewUnitConverter("si")
+	if err != nil {
+		t.Fatalf("NewUnitConverter() error = %v; want nil", err)
+	}
+	event := defs.Event{Name: "laser_power", Type: "float", Value: 42.5}
+	got, err := uc.Convert(event)
+	if err != nil {
+		t.Fatalf("Convert() error = %v; want nil", err)
+	}
+	if got.Value != 42.5 {
+		t.Errorf("Convert() value = %v; want 42.5 mW unchanged", got.Value)
+	}
+	if unit := uc.Target("mW"); unit != "mW" {
+		t.Errorf("Target(mW) = %v; want mW", unit)
+	}
+
+	uc, _ = pipeline.NewUnitConverter("W")
+	if got, _ := uc.Convert(event); math.Abs(got.Value.(float64)-0.0425) > 1e-12 {
+		t.Errorf("Convert() to W value = %v; want 0.0425", got.Value)
+	}
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"math"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Stats summarizes a set of float values.
+type Stats struct {
+	Count  int
+	Mean   float64
+	Min    float64
+	Max    float64
+	Stddev float64 // sample standard deviation, zero for fewer than two values
+}
+
+type sample struct {
+	t time.Time
+	v float64
+}
+
+// Window computes rolling statistics of float event values over a trailing
+// time window, separately for each event name. Events should be added in time
+// order.
+type Window struct {
+	size    time.Duration
+	samples map[string][]sample
+}
+
+// NewWindow creates a Window covering the trailing duration size.
+func NewWindow(size time.Duration) *Window {
+	return &Window{size: size, samples: make(map[string][]sample)}
+}
+
+// Add adds a float event and returns the statistics of that event's values in
+// the window ending at the event's time. ok is false, and the event ignored,
+// if it has an error or no float value.
+func (w *Window) Add(event defs.Event) (stats Stats, ok bool) {
+	v, isFloat := event.Value.(float64)
+	if !isFloat || event.Error != nil {
+		return Stats{}, false
+	}
+	w.samples[event.Name] = append(w.samples[event.Name], sample{t: event.Time, v: v})
+	w.evict(event.Name, event.Time)
+	return w.Stats(event.Name), true
+}
+
+// Stats returns the statistics of the values of event name currently in the
+// window.
+func (w *Window) Stats(name string) Stats {
+	samples := w.samples[name]
+	if len(samples) == 0 {
+		return Stats{}
+	}
+	s := Stats{Count: len(samples), Min: samples[0].v, Max: samples[0].v}
+	sum := 0.0
+	for _, x := range samples {
+		sum += x.v
+		s.Min = math.Min(s.Min, x.v)
+		s.Max = math.Max(s.Max, x.v)
+	}
+	s.Mean = sum / float64(s.Count)
+	if s.Count > 1 {
+		ss := 0.0
+		for _, x := range samples {
+			ss += (x.v - s.Mean) * (x.v - s.Mean)
+		}
+		s.Stddev = math.Sqrt(ss / float64(s.Count-1))
+	}
+	return s
+}
+
+// evict drops values of event name at or before the start of the window
+// ending at t.
+func (w *Window) evict(name string, t time.Time) {
+	start := t.Add(-w.size)
+	samples := w.samples[name]
+	i := 0
+	for i < len(samples) && !samples[i].t.After(start) {
+		i++
+	}
+	w.samples[name] = samples[i:]
+}
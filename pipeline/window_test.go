@@ -0,0 +1,45 @@
+package pipeline_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+func TestWindow(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	w := pipeline.NewWindow(2 * time.Minute)
+	events := []defs.Event{
+		{Name: "PMT1", Value: 1.0, Time: t0},
+		{Name: "PMT1", Value: 3.0, Time: t0.Add(time.Minute)},
+		{Name: "PMT2", Value: 100.0, Time: t0.Add(time.Minute)},
+		{Name: "PMT1", Value: 5.0, Time: t0.Add(2 * time.Minute)}, // first value drops out
+		{Name: "note", Value: "text", Time: t0.Add(2 * time.Minute)},
+	}
+	want := []pipeline.Stats{
+		{Count: 1, Mean: 1, Min: 1, Max: 1},
+		{Count: 2, Mean: 2, Min: 1, Max: 3, Stddev: math.Sqrt2},
+		{Count: 1, Mean: 100, Min: 100, Max: 100},
+		{Count: 2, Mean: 4, Min: 3, Max: 5, Stddev: math.Sqrt2},
+	}
+	got := []pipeline.Stats{}
+	for _, event := range events {
+		if stats, ok := w.Add(event); ok {
+			got = append(got, stats)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; want %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("Stats %+v; want %+v", got[i], want[i])
+		}
+	}
+	if stats := w.Stats("missing"); stats.Count != 0 {
+		t.Errorf("Stats(missing).Count %v; want 0", stats.Count)
+	}
+}
@@ -0,0 +1,184 @@
+package seaflog
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Log message formats
+const (
+	// LogFormatText writes banner separated text messages. This is the
+	// default.
+	LogFormatText = "text"
+	// LogFormatJSON writes one JSON object per message, per line.
+	LogFormatJSON = "json"
+)
+
+// Message levels, from most to least severe
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelInfo    = "info"
+)
+
+// VerbosityQuiet turns off all messages when passed to SetVerbosity.
+const VerbosityQuiet = "quiet"
+
+// levels ranks message levels, lower is more severe
+var levels = map[string]int{LevelError: 1, LevelWarning: 2, LevelInfo: 3}
+
+var (
+	logFormat  = LogFormatText
+	verbosity  = levels[LevelWarning]
+	suppressed = map[string]bool{}
+)
+
+// Rate limiting of identical messages
+var (
+	maxRepeats  int
+	interval    time.Duration
+	seen        = map[[3]string]int{} // times each message was reported
+	held        = map[[3]string]int{} // times each message was suppressed since the last summary
+	heldOrder   [][3]string           // keys of held, in order first suppressed
+	lastSummary time.Time
+)
+
+// Message is one diagnostic message written by Report.
+type Message struct {
+	Level     string `json:"level"`                // e.g. LevelWarning
+	File      string `json:"file,omitempty"`       // log file being read
+	Line      int    `json:"line,omitempty"`       // line number in File
+	ErrorKind string `json:"error_kind,omitempty"` // e.g. "bad_float"
+	Message   string `json:"message"`
+	Text      string `json:"text,omitempty"` // text of the offending line
+	// Suppressed is the number of identical messages this one summarizes,
+	// see LimitRepeats.
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// SetLogFormat sets the format of messages written by Report, one of
+// LogFormatText or LogFormatJSON.
+func SetLogFormat(format string) error {
+	switch format {
+	case LogFormatText:
+		Log.SetPrefix(banner)
+	case LogFormatJSON:
+		Log.SetPrefix("")
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	logFormat = format
+	return nil
+}
+
+// SetVerbosity sets the least severe level of message written by Report, one
+// of LevelError, LevelWarning, or LevelInfo, or VerbosityQuiet to write none.
+// The default is LevelWarning.
+func SetVerbosity(level string) error {
+	if level == VerbosityQuiet {
+		verbosity = 0
+		return nil
+	}
+	v, ok := levels[level]
+	if !ok {
+		return fmt.Errorf("unknown verbosity %q", level)
+	}
+	verbosity = v
+	return nil
+}
+
+// Suppress turns off or back on Report messages with ErrorKind kind, e.g.
+// "unrecognized", regardless of verbosity.
+func Suppress(kind string, on bool) {
+	if on {
+		suppressed[kind] = true
+	} else {
+		delete(suppressed, kind)
+	}
+}
+
+// LimitRepeats limits the number of identical messages, with the same
+// level, kind, and message text but possibly different lines, which Report
+// writes to n. Further identical messages are counted, and summarized as
+// "message xN (suppressed)" every interval, if interval is positive, and by
+// FlushRepeats. n of 0, the default, removes the limit.
+func LimitRepeats(n int, every time.Duration) {
+	maxRepeats = n
+	interval = every
+	lastSummary = time.Now()
+}
+
+// FlushRepeats writes a summary of each message suppressed by LimitRepeats
+// since the last summary. Call it before exiting.
+func FlushRepeats() {
+	for _, k := range heldOrder {
+		write(Message{
+			Level:      k[0],
+			ErrorKind:  k[1],
+			Message:    fmt.Sprintf("%s x%d (suppressed)", k[2], held[k]),
+			Suppressed: held[k],
+		})
+		delete(held, k)
+	}
+	heldOrder = nil
+	lastSummary = time.Now()
+}
+
+// Report writes m to Log in the current log format, unless its level is
+// below the current verbosity or its kind is suppressed. Messages with an
+// unknown level are treated as warnings.
+func Report(m Message) {
+	level, ok := levels[m.Level]
+	if !ok {
+		level = levels[LevelWarning]
+	}
+	if level > verbosity || suppressed[m.ErrorKind] {
+		return
+	}
+	if maxRepeats > 0 {
+		k := repeatKey(m)
+		seen[k]++
+		if seen[k] > maxRepeats {
+			if held[k] == 0 {
+				heldOrder = append(heldOrder, k)
+			}
+			held[k]++
+			if interval > 0 && time.Since(lastSummary) >= interval {
+				FlushRepeats()
+			}
+			return
+		}
+	}
+	write(m)
+}
+
+// write writes m to Log in the current log format.
+func write(m Message) {
+	if logFormat == LogFormatJSON {
+		b, err := json.Marshal(m)
+		if err != nil {
+			// Still a valid JSON line, marshalled rather than formatted,
+			// since Go string escapes aren't all valid in JSON
+			b, _ = json.Marshal(struct {
+				Level string `json:"level"`
+				Error string `json:"error"`
+			}{LevelError, err.Error()})
+		}
+		Log.Printf("%s\n", b)
+		return
+	}
+	switch {
+	case m.Line > 0 && m.Text != "":
+		Log.Printf("Line %d, %s.\n  %s\n", m.Line, m.Message, m.Text)
+	case m.Line > 0:
+		Log.Printf("Line %d, %s.\n", m.Line, m.Message)
+	default:
+		Log.Printf("%s.\n", m.Message)
+	}
+}
+
+// repeatKey identifies identical messages, which may differ in line.
+func repeatKey(m Message) [3]string {
+	return [3]string{m.Level, m.ErrorKind, m.Message}
+}
@@ -0,0 +1,47 @@
+//go:build go1.18
+// +build go1.18
+
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzScan checks that no log file content crashes the scanner, and that
+// events stay in log order.
+func FuzzScan(f *testing.F) {
+	f.Add("2015-03-14T00-26-52+00-00\nPMT1:1.05\nnot a real event data line\n")
+	f.Add("Instrument Serial: 740\n2015-03-14T00-26-52+00-00\nlaser: 1\n2015-03-14T00-26-51+00-00\nFault:\n")
+	f.Add("2015-03-14T23-59-60.500+00-00\r\nPMT9:2\r\n")
+	f.Add("PMT1:1.05\n\n\n")
+	f.Fuzz(func(t *testing.T, log string) {
+		es := NewEventScanner(strings.NewReader(log))
+		seq := 0
+		for es.Scan() {
+			event := es.Event()
+			if event.Seq <= seq {
+				t.Fatalf("Event.Seq %d after %d", event.Seq, seq)
+			}
+			seq = event.Seq
+		}
+		es.Err()
+		es.TimeAnomalies()
+	})
+}
+
+// FuzzParseTimestamp checks that no text crashes timestamp parsing, and that
+// parsed timestamps are never zero.
+func FuzzParseTimestamp(f *testing.F) {
+	f.Add("2015-03-14T00-26-52+00-00")
+	f.Add("2015-03-14T00-26-52.250+00-00")
+	f.Add("2016-12-31T23-59-60+00-00")
+	f.Add("2015-03-14T00:26:52+00:00")
+	f.Add("9999-99-99T99-99-99+99-99")
+	f.Fuzz(func(t *testing.T, text string) {
+		ts, _, err := parseTimestamp(text)
+		if err == nil && ts.IsZero() {
+			t.Errorf("parseTimestamp(%q) = zero time without an error", text)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// indexHeader starts every index file, followed by the size of the indexed
+// log file.
+const indexHeader = "seaflog-index v1"
+
+// Checkpoint is the position of a timestamp line in a log file.
+type Checkpoint struct {
+	Time   time.Time // timestamp as read
+	Offset int64     // byte offset of the timestamp line
+	Lines  int       // number of lines before the timestamp line
+}
+
+// Index holds checkpoints for fast seeking to a time in a log file.
+type Index struct {
+	Size        int64 // size of the indexed log file in bytes
+	Checkpoints []Checkpoint
+}
+
+// BuildIndex reads a log file from r and returns an Index with a checkpoint
+// at the first timestamp line after every spacing bytes.
+func BuildIndex(r io.Reader, spacing int64) (Index, error) {
+	var idx Index
+	br := bufio.NewReader(r)
+	var off int64
+	next := int64(0) // offset after which to add the next checkpoint
+	lines := 0
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			if off >= next {
+				if t, _, perr := parseTimestamp(strings.TrimRight(line, "\r\n")); perr == nil {
+					idx.Checkpoints = append(idx.Checkpoints, Checkpoint{Time: t, Offset: off, Lines: lines})
+					next = off + spacing
+				}
+			}
+			off += int64(len(line))
+			lines++
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return Index{}, err
+		}
+	}
+	idx.Size = off
+	return idx, nil
+}
+
+// Seek returns the last checkpoint before time t, or a zero Checkpoint for the
+// start of the file. Scanning from it sees every event at or after t as long
+// as timestamps increase through the file.
+func (idx Index) Seek(t time.Time) Checkpoint {
+	var best Checkpoint
+	for _, cp := range idx.Checkpoints {
+		if !cp.Time.Before(t) {
+			break
+		}
+		best = cp
+	}
+	return best
+}
+
+// Monotonic returns true if checkpoint times never decrease through the file,
+// i.e. Seek can be trusted as far as the index shows. A backward clock jump
+// between checkpoints isn't detected.
+func (idx Index) Monotonic() bool {
+	for i := 1; i < len(idx.Checkpoints); i++ {
+		if idx.Checkpoints[i].Time.Before(idx.Checkpoints[i-1].Time) {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the index in a tab-delimited text format.
+func (idx Index) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	c, err := fmt.Fprintf(bw, "%s\t%d\n", indexHeader, idx.Size)
+	n += int64(c)
+	if err != nil {
+		return n, err
+	}
+	for _, cp := range idx.Checkpoints {
+		c, err := fmt.Fprintf(bw, "%d\t%d\t%s\n", cp.Offset, cp.Lines, cp.Time.Format(time.RFC3339Nano))
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// ReadIndex reads an index written by Index.WriteTo.
+func ReadIndex(r io.Reader) (Index, error) {
+	var idx Index
+	s := bufio.NewScanner(r)
+	if !s.Scan() {
+		if err := s.Err(); err != nil {
+			return Index{}, err
+		}
+		return Index{}, fmt.Errorf("empty index")
+	}
+	header := strings.Split(s.Text(), "\t")
+	if len(header) != 2 || header[0] != indexHeader {
+		return Index{}, fmt.Errorf("not a seaflog index")
+	}
+	size, err := strconv.ParseInt(header[1], 10, 64)
+	if err != nil {
+		return Index{}, fmt.Errorf("bad index size: %v", err)
+	}
+	idx.Size = size
+	for s.Scan() {
+		fields := strings.Split(s.Text(), "\t")
+		if len(fields) != 3 {
+			return Index{}, fmt.Errorf("bad index line %q", s.Text())
+		}
+		var cp Checkpoint
+		if cp.Offset, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+			return Index{}, fmt.Errorf("bad index offset: %v", err)
+		}
+		if cp.Lines, err = strconv.Atoi(fields[1]); err != nil {
+			return Index{}, fmt.Errorf("bad index line count: %v", err)
+		}
+		if cp.Time, err = time.Parse(time.RFC3339Nano, fields[2]); err != nil {
+			return Index{}, fmt.Errorf("bad index time: %v", err)
+		}
+		idx.Checkpoints = append(idx.Checkpoints, cp)
+	}
+	return idx, s.Err()
+}
@@ -0,0 +1,74 @@
+package scanner_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestIndex(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	var b strings.Builder
+	b.WriteString("Instrument Serial: 740\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "%s\nPMT1:%d\n", t0.Add(time.Duration(i)*time.Minute).Format("2006-01-02T15-04-05+00-00"), i)
+	}
+	input := b.String()
+
+	idx, err := scanner.BuildIndex(strings.NewReader(input), 300)
+	if err != nil {
+		t.Fatalf("BuildIndex() error = %v; want nil", err)
+	}
+	if idx.Size != int64(len(input)) {
+		t.Errorf("Index.Size %v; want %v", idx.Size, len(input))
+	}
+	if len(idx.Checkpoints) < 2 {
+		t.Fatalf("len(Index.Checkpoints) %v; want several", len(idx.Checkpoints))
+	}
+
+	var buf bytes.Buffer
+	if _, err := idx.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v; want nil", err)
+	}
+	idx, err = scanner.ReadIndex(&buf)
+	if err != nil {
+		t.Fatalf("ReadIndex() error = %v; want nil", err)
+	}
+
+	target := t0.Add(50 * time.Minute)
+	cp := idx.Seek(target)
+	if !cp.Time.Before(target) {
+		t.Errorf("Seek() checkpoint time %v; want before %v", cp.Time, target)
+	}
+	// Scanning from the checkpoint must reach the target with the right line number
+	es := scanner.NewEventScanner(strings.NewReader(input[cp.Offset:]))
+	es.LineOffset(cp.Lines)
+	found := false
+	for es.Scan() {
+		if event := es.Event(); event.Time.Equal(target) {
+			found = true
+			if event.LineNumber != 103 {
+				t.Errorf("Event.LineNumber %v; want 103", event.LineNumber)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no event at %v after seeking", target)
+	}
+
+	if !idx.Monotonic() {
+		t.Errorf("Monotonic() = false; want true")
+	}
+	idx.Checkpoints[1].Time = t0.Add(-time.Hour)
+	if idx.Monotonic() {
+		t.Errorf("Monotonic() after a backward jump = true; want false")
+	}
+
+	if _, err := scanner.ReadIndex(strings.NewReader("not an index\n")); err == nil {
+		t.Errorf("ReadIndex() error = nil; want an error")
+	}
+}
@@ -0,0 +1,673 @@
+// Package scanner reads SeaFlow V1 instrument log files as a stream of events.
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/seaflogtime"
+)
+
+// EventScanner provides an interface for reading through a SeaFlow v1 instrument log file.
+type EventScanner struct {
+	scanner  *bufio.Scanner
+	matcher  *defs.Matcher
+	t        time.Time // time for last seen timestamp line
+	i        int       // current line number, starting at 1
+	event    defs.Event
+	error    error
+	done     bool
+	tc       timeChecker
+	untimed  string        // policy for events before the first timestamp
+	tdef     time.Time     // default time for UntimedDefault
+	pending  []pendingLine // lines held for UntimedBackdate
+	queue    []queued      // events ready to be returned by Scan
+	interp   string        // time interpolation mode
+	epsilon  time.Duration // per line time step for InterpolateEpsilon
+	held     []queued      // events held for InterpolateEven
+	seq      int           // sequence number of the last event returned
+	source   string        // name of the input for Event.Source
+	markers  bool          // FileMarker lines start another file
+	tsLine   string        // text of the last timestamp line
+	rawTs    string        // last timestamp line as read
+	ev       queued        // current event with its lines
+	onEvent  map[string][]func(defs.Event)
+	onError  []func(defs.Event)
+	pre      []LinePreprocessor
+	syslog   *syslogInput
+	ignore   []*regexp.Regexp // patterns of event lines to drop
+	timed    bool             // a timestamp line was read since the start or last restart
+	running  bool             // an event followed a timestamp line since the start or last restart
+	special  string           // policy for infinite and NaN float values
+	software string           // instrument software version from the last banner
+	firmware string           // instrument firmware version from the last banner
+}
+
+// Policies for events which occur before the first timestamp line.
+const (
+	// UntimedError passes untimed events through with a defs.ErrNoTimestamp
+	// error. This is the default.
+	UntimedError = "error"
+	// UntimedDrop silently drops untimed events.
+	UntimedDrop = "drop"
+	// UntimedBackdate assigns untimed events the time of the first timestamp
+	// line. If the input has no timestamp lines they are passed through with
+	// an error as with UntimedError.
+	UntimedBackdate = "backdate"
+	// UntimedDefault assigns untimed events a caller provided time.
+	UntimedDefault = "default"
+)
+
+// Modes for interpolating event times between timestamp lines.
+const (
+	// InterpolateOff gives events the time of the preceding timestamp line.
+	// This is the default.
+	InterpolateOff = "off"
+	// InterpolateEven spreads events evenly between the preceding and
+	// following timestamp lines. Events are held until the following
+	// timestamp line is read. Events after the last timestamp line, or
+	// before a backward time jump, keep the time of the preceding timestamp
+	// line.
+	InterpolateEven = "even"
+	// InterpolateEpsilon adds a fixed step to event times for each line
+	// after the preceding timestamp line.
+	InterpolateEpsilon = "epsilon"
+)
+
+// Policies for infinite and NaN float values, e.g. from "inf" or "nan".
+const (
+	// SpecialFloatPass passes special values on as is. This is the default.
+	SpecialFloatPass = "pass"
+	// SpecialFloatDrop silently drops events with special values.
+	SpecialFloatDrop = "drop"
+	// SpecialFloatError passes events with special values on with a
+	// defs.ErrSpecialFloat error.
+	SpecialFloatError = "error"
+)
+
+// LinePreprocessor rewrites a line before timestamp and event matching, e.g.
+// to strip a prefix added when logs were forwarded. It returns false to drop
+// the line.
+type LinePreprocessor func(line string) (string, bool)
+
+// StripPrefix returns a LinePreprocessor which removes the match of re at the
+// start of lines, leaving other lines as is.
+func StripPrefix(re *regexp.Regexp) LinePreprocessor {
+	return func(line string) (string, bool) {
+		if loc := re.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			return line[loc[1]:], true
+		}
+		return line, true
+	}
+}
+
+// FileMarker starts lines which mark the start of another log file in a
+// stream of concatenated logs, e.g. "#FILE: HOT227/SFlog_740.txt", when
+// FileMarkers is on.
+const FileMarker = "#FILE:"
+
+// queued is an event waiting to be returned by Scan, along with the text of
+// the timestamp line which preceded it, and both lines as read.
+type queued struct {
+	event  defs.Event
+	tsLine string
+	raw    string // event line as read, empty for events with no line of their own
+	rawTs  string
+}
+
+// pendingLine is an event line held until its time is known.
+type pendingLine struct {
+	line string
+	raw  string
+	i    int
+}
+
+// NewEventScanner returns a new EventScanner to read from r.
+func NewEventScanner(r io.Reader) *EventScanner {
+	return &EventScanner{
+		scanner: newLineScanner(r),
+		matcher: defs.NewMatcher(),
+		ignore:  defs.IgnorePatterns,
+	}
+}
+
+// NormalizeTime turns on correction of impossible timestamp jumps. After a
+// backward jump, or a UTC offset change not matched by the wall clock, the
+// times of all subsequent events are shifted by the size of the jump so that
+// event times stay consistent. Anomalies are recorded whether or not this is
+// on.
+func (es *EventScanner) NormalizeTime(on bool) {
+	es.tc.normalize = on
+}
+
+// AddDefs adds event definitions used by this scanner only, after
+// defs.EventDefs, e.g. definitions discovered in the log.
+func (es *EventScanner) AddDefs(edefs ...defs.EventDef) {
+	es.matcher.AddDefs(edefs...)
+}
+
+// LineOffset sets the number of lines which precede the input in its log
+// file, e.g. after seeking partway into the file, so event line numbers match
+// the whole file.
+func (es *EventScanner) LineOffset(n int) {
+	es.i = n
+}
+
+// SourceName sets the name of the input, e.g. its file path, recorded in the
+// Source field of every event.
+func (es *EventScanner) SourceName(name string) {
+	es.source = name
+}
+
+// FileMarkers turns on reading FileMarker lines in a stream of concatenated
+// log files, e.g. from a tar pipeline on STDIN. The name after the marker
+// becomes the Source of following events, line numbers count from the line
+// after the marker, and each file starts untimed, as at the start of the
+// input. Events held for time interpolation or backdating are passed on at
+// the end of their file, as at the end of the input.
+func (es *EventScanner) FileMarkers(on bool) {
+	es.markers = on
+}
+
+// Preprocess adds functions which rewrite or drop every line, including
+// timestamp lines, before anything else, in order. Dropped lines still count
+// for line numbers.
+func (es *EventScanner) Preprocess(fns ...LinePreprocessor) {
+	es.pre = append(es.pre, fns...)
+}
+
+// IgnoreLines adds patterns for event lines to drop silently before event
+// creation, in addition to defs.IgnorePatterns. Timestamp lines are never
+// dropped.
+func (es *EventScanner) IgnoreLines(patterns ...*regexp.Regexp) {
+	es.ignore = append(es.ignore[:len(es.ignore):len(es.ignore)], patterns...)
+}
+
+// MaxStaleLines sets the number of lines which may follow a timestamp line
+// before the times of further events are considered stale. Each run of stale
+// lines is recorded as an AnomalyStale time anomaly. Zero, the default, turns
+// off stale time detection.
+func (es *EventScanner) MaxStaleLines(n int) {
+	es.tc.maxStale = n
+}
+
+// UntimedEvents sets the policy for events which occur before the first
+// timestamp line, one of UntimedError, UntimedDrop, UntimedBackdate, or
+// UntimedDefault. t is the time assigned by UntimedDefault and is ignored
+// otherwise.
+func (es *EventScanner) UntimedEvents(policy string, t time.Time) error {
+	switch policy {
+	case UntimedError, UntimedDrop, UntimedBackdate:
+	case UntimedDefault:
+		if t.IsZero() {
+			return fmt.Errorf("untimed event policy %q requires a time", policy)
+		}
+	default:
+		return fmt.Errorf("unknown untimed event policy %q", policy)
+	}
+	es.untimed = policy
+	es.tdef = t
+	return nil
+}
+
+// InterpolateTime sets the mode for interpolating event times between
+// timestamp lines, one of InterpolateOff, InterpolateEven, or
+// InterpolateEpsilon. epsilon is the per line step used by InterpolateEpsilon
+// and is ignored otherwise. Events with a time from their own value are never
+// interpolated.
+func (es *EventScanner) InterpolateTime(mode string, epsilon time.Duration) error {
+	switch mode {
+	case InterpolateOff, InterpolateEven:
+	case InterpolateEpsilon:
+		if epsilon <= 0 {
+			return fmt.Errorf("time interpolation mode %q requires a positive step", mode)
+		}
+	default:
+		return fmt.Errorf("unknown time interpolation mode %q", mode)
+	}
+	es.interp = mode
+	es.epsilon = epsilon
+	return nil
+}
+
+// SpecialFloats sets the policy for infinite and NaN float values, one of
+// SpecialFloatPass, SpecialFloatDrop, or SpecialFloatError.
+func (es *EventScanner) SpecialFloats(policy string) error {
+	switch policy {
+	case SpecialFloatPass, SpecialFloatDrop, SpecialFloatError:
+	default:
+		return fmt.Errorf("unknown special float policy %q", policy)
+	}
+	es.special = policy
+	return nil
+}
+
+// OnEvent registers fn to be called with each event named name, as Scan
+// returns it. Events with an error go to OnError callbacks instead.
+func (es *EventScanner) OnEvent(name string, fn func(defs.Event)) {
+	if es.onEvent == nil {
+		es.onEvent = make(map[string][]func(defs.Event))
+	}
+	es.onEvent[name] = append(es.onEvent[name], fn)
+}
+
+// OnError registers fn to be called with each event with an error, including
+// unrecognized events, as Scan returns it.
+func (es *EventScanner) OnError(fn func(defs.Event)) {
+	es.onError = append(es.onError, fn)
+}
+
+// Run scans all remaining events, calling any registered callbacks, and
+// returns any unrecoverable error.
+func (es *EventScanner) Run() error {
+	for es.Scan() {
+	}
+	return es.Err()
+}
+
+// TimeAnomalies returns suspicious timestamp jumps seen so far. Line ranges
+// are only final once Scan has returned false.
+func (es *EventScanner) TimeAnomalies() []TimeAnomaly {
+	return es.tc.anomalies
+}
+
+// Scan advances to the next event, which will then be available through the
+// Event method. Returns false when the end of the input has been reached or
+// after encountering an unrevorable error. This error which will be available
+// with the Err method.
+func (es *EventScanner) Scan() bool {
+	if len(es.queue) > 0 {
+		return es.next()
+	}
+	if es.done {
+		return false
+	}
+
+	for es.scanner.Scan() {
+		es.i++
+		raw := es.scanner.Text()
+		line := strings.TrimSuffix(raw, "\r")
+		var sysT time.Time
+		if es.syslog != nil {
+			line, sysT = es.syslog.parse(line)
+		}
+		line, keep := es.preprocess(line)
+		if !keep {
+			continue
+		}
+		if es.markers && strings.HasPrefix(line, FileMarker) {
+			if !es.nextFile(strings.TrimSpace(strings.TrimPrefix(line, FileMarker))) {
+				return false
+			}
+			if len(es.queue) > 0 {
+				return es.next()
+			}
+			continue
+		}
+		tnew, leap, err := parseTimestamp(line)
+		if err == nil {
+			// New timestamp line
+			tprev := es.t
+			es.t = es.tc.check(tnew, leap, es.i)
+			es.tsLine = line
+			es.rawTs = raw
+			es.timed = true
+			if len(es.held) > 0 {
+				// Release events held for interpolation
+				es.queue = append(es.queue, interpolate(es.held, tprev, es.t)...)
+				es.held = nil
+			}
+			// Release events held for backdating
+			if len(es.pending) > 0 && !es.release(es.t) {
+				return false
+			}
+			if len(es.queue) > 0 {
+				return es.next()
+			}
+		} else {
+			es.tc.extend(es.i)
+			// Event data line
+			if line == "" || line == "Fault:" {
+				// A lot of these, just skip
+				continue
+			}
+			if es.ignored(line) {
+				continue
+			}
+			es.checkVersion(line)
+			t := es.t
+			if t.IsZero() && es.syslog != nil && es.syslog.useTime {
+				t = sysT
+			}
+			if t.IsZero() {
+				switch es.untimed {
+				case UntimedDrop:
+					continue
+				case UntimedBackdate:
+					es.pending = append(es.pending, pendingLine{line: line, raw: raw, i: es.i})
+					continue
+				case UntimedDefault:
+					t = es.tdef
+				}
+			}
+			event, err := es.matcher.CreateEvent(line, t, es.i)
+			if err != nil {
+				es.error = err
+				return false
+			}
+			if !es.checkSpecial(&event) {
+				continue
+			}
+			if es.restarted(event) {
+				// Pass the restart on first, then the banner line
+				restart := defs.Event{
+					Name: defs.RestartEvent, Type: "boolean", Value: true,
+					Line: line, Time: event.Time, LineNumber: es.i,
+				}
+				q := queued{event: restart, tsLine: es.tsLine, rawTs: es.rawTs}
+				if es.interp == InterpolateEven {
+					es.held = append(es.held, q)
+				} else {
+					es.queue = append(es.queue, q)
+				}
+			}
+			q := queued{event: event, tsLine: es.tsLine, raw: raw, rawTs: es.rawTs}
+			if !es.t.IsZero() {
+				switch es.interp {
+				case InterpolateEven:
+					es.held = append(es.held, q)
+					continue
+				case InterpolateEpsilon:
+					if event.Time.Equal(es.t) {
+						q.event.Time = q.event.Time.Add(time.Duration(es.i-es.tc.last) * es.epsilon)
+					}
+				}
+			}
+			if len(es.queue) > 0 {
+				es.queue = append(es.queue, q)
+				return es.next()
+			}
+			return es.emit(q)
+		}
+	}
+	es.done = true
+	// No timestamp follows events held for interpolation, pass them on as is
+	es.queue = append(es.queue, es.held...)
+	es.held = nil
+
+	if err := es.scanner.Err(); err != nil {
+		es.error = err
+		return false
+	}
+	// No timestamp ever arrived for held events, pass them on without a time
+	if len(es.pending) > 0 && !es.release(time.Time{}) {
+		return false
+	}
+	if len(es.queue) > 0 {
+		return es.next()
+	}
+	return false
+}
+
+// nextFile ends the current file, queueing its held events, and starts the
+// file name.
+func (es *EventScanner) nextFile(name string) bool {
+	es.queue = append(es.queue, es.held...)
+	es.held = nil
+	if len(es.pending) > 0 && !es.release(time.Time{}) {
+		return false
+	}
+	for j := range es.queue {
+		if es.queue[j].event.Source == "" {
+			es.queue[j].event.Source = es.source
+		}
+	}
+	es.source = name
+	es.i = 0
+	es.t = time.Time{}
+	es.tsLine = ""
+	es.rawTs = ""
+	es.timed = false
+	es.running = false
+	es.tc.newFile()
+	return true
+}
+
+// restarted returns true if event is the first banner line of a restart of the
+// instrument software, i.e. a banner line after timestamped events, and resets
+// per-run state if so.
+func (es *EventScanner) restarted(event defs.Event) bool {
+	if !defs.EventDefs[event.Name].Banner || event.Error != nil {
+		es.running = es.running || es.timed
+		return false
+	}
+	if !es.running {
+		return false
+	}
+	es.timed = false
+	es.running = false
+	es.tc.restart()
+	return true
+}
+
+// Versions returns the instrument software and firmware versions from the
+// most recent version banner lines read, or "" if none have been read.
+// Versions are read whether or not the lines have a time. Event lines are
+// matched with the forms for the current software version.
+func (es *EventScanner) Versions() (software string, firmware string) {
+	return es.software, es.firmware
+}
+
+// Header reads the header lines of a log file, as returned by ReadHeader,
+// when scanning starts after them, e.g. after seeking to a time. Only the
+// versions are read from them, no events are returned.
+func (es *EventScanner) Header(lines []string) {
+	for _, line := range lines {
+		es.checkVersion(line)
+	}
+}
+
+// checkVersion records the version in line if it's a version banner line.
+func (es *EventScanner) checkVersion(line string) {
+	for _, name := range []string{defs.SoftwareVersionEvent, defs.FirmwareVersionEvent} {
+		for _, eform := range defs.EventDefs[name].EventForms {
+			if !strings.HasPrefix(line, eform.StartsWith) {
+				continue
+			}
+			// Any time will do, version lines often precede the first timestamp
+			event, err := defs.CreateEvent(line, time.Unix(0, 0), es.i)
+			if err != nil || event.Error != nil || event.Name != name {
+				continue
+			}
+			v, _ := event.Value.(string)
+			if name == defs.SoftwareVersionEvent {
+				es.software = v
+				es.matcher.SetVersion(v)
+			} else {
+				es.firmware = v
+			}
+		}
+	}
+}
+
+// checkSpecial applies the special float policy to event, returning false if
+// it should be dropped.
+func (es *EventScanner) checkSpecial(event *defs.Event) bool {
+	f, ok := event.Value.(float64)
+	if !ok || !(math.IsInf(f, 0) || math.IsNaN(f)) {
+		return true
+	}
+	switch es.special {
+	case SpecialFloatDrop:
+		return false
+	case SpecialFloatError:
+		event.Error = &defs.ParseError{LineNumber: event.LineNumber, Line: event.Line, Err: defs.ErrSpecialFloat}
+	}
+	return true
+}
+
+// preprocess applies the LinePreprocessors to line.
+func (es *EventScanner) preprocess(line string) (string, bool) {
+	for _, fn := range es.pre {
+		var keep bool
+		if line, keep = fn(line); !keep {
+			return "", false
+		}
+	}
+	return line, true
+}
+
+// ignored returns true if line matches an ignore pattern.
+func (es *EventScanner) ignored(line string) bool {
+	for _, re := range es.ignore {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// release creates events for all held lines with time t and queues them,
+// with the timestamp line giving t as the one they follow, so written back
+// out they're timed the same.
+func (es *EventScanner) release(t time.Time) bool {
+	for _, p := range es.pending {
+		event, err := es.matcher.CreateEvent(p.line, t, p.i)
+		if err != nil {
+			es.error = err
+			return false
+		}
+		if !es.checkSpecial(&event) {
+			continue
+		}
+		q := queued{event: event, raw: p.raw}
+		if !t.IsZero() {
+			q.tsLine, q.rawTs = es.tsLine, es.rawTs
+		}
+		es.queue = append(es.queue, q)
+	}
+	es.pending = nil
+	return true
+}
+
+// interpolate spreads the times of events read after timestamp t0 evenly
+// between t0 and the following timestamp t1. Events with a time other than t0
+// are left as is.
+func interpolate(events []queued, t0, t1 time.Time) []queued {
+	if !t1.After(t0) {
+		return events
+	}
+	n := 0
+	for _, q := range events {
+		if q.event.Time.Equal(t0) {
+			n++
+		}
+	}
+	step := t1.Sub(t0) / time.Duration(n+1)
+	k := 0
+	for j := range events {
+		if events[j].event.Time.Equal(t0) {
+			k++
+			events[j].event.Time = t0.Add(step * time.Duration(k))
+		}
+	}
+	return events
+}
+
+// next makes the first queued event current.
+func (es *EventScanner) next() bool {
+	q := es.queue[0]
+	es.queue = es.queue[1:]
+	return es.emit(q)
+}
+
+// emit numbers the event of q and makes it current.
+func (es *EventScanner) emit(q queued) bool {
+	es.ev = q
+	event := q.event
+	es.seq++
+	event.Seq = es.seq
+	if event.Source == "" {
+		event.Source = es.source
+	}
+	es.event = event
+	if event.Error != nil {
+		for _, fn := range es.onError {
+			fn(event)
+		}
+	} else {
+		for _, fn := range es.onEvent[event.Name] {
+			fn(event)
+		}
+	}
+	return true
+}
+
+func (es *EventScanner) Event() defs.Event {
+	return es.event
+}
+
+// AcquireEvent returns a copy of the current event from the defs event pool.
+// Return it with defs.ReleaseEvent once it's no longer used.
+func (es *EventScanner) AcquireEvent() *defs.Event {
+	e := defs.AcquireEvent()
+	*e = es.event
+	return e
+}
+
+// TimestampLine returns the text of the timestamp line which preceded the
+// current event in the log, or an empty string if there was none.
+func (es *EventScanner) TimestampLine() string {
+	return es.ev.tsLine
+}
+
+// RawLine returns the line of the current event as read from the input,
+// before syslog parsing and preprocessing, without the newline. It's empty for
+// events with no line of their own, like restart.
+func (es *EventScanner) RawLine() string {
+	return es.ev.raw
+}
+
+// RawTimestampLine returns the timestamp line which preceded the current
+// event as read from the input, like RawLine.
+func (es *EventScanner) RawTimestampLine() string {
+	return es.ev.rawTs
+}
+
+// newLineScanner returns a scanner of the lines of r which, unlike
+// bufio.ScanLines, keeps any carriage return before the newline, so lines can
+// be written out exactly as read.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	return s
+}
+
+// Err returns any unrecoverable error encountered during event scanning.
+func (es *EventScanner) Err() error {
+	return es.error
+}
+
+// parseTimestamp converts a SeaFlow timestamp to a time.Time struct. Leap
+// seconds are clamped to the preceding second and reported with leap = true.
+func parseTimestamp(text string) (t time.Time, leap bool, err error) {
+	if t, err = seaflogtime.ParseSeaFlowTimestamp(text); err != nil {
+		return time.Time{}, false, err
+	}
+	return t, seaflogtime.IsLeapSecond(text), nil
+}
@@ -0,0 +1,651 @@
+package scanner_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+type eventTestData struct {
+	name  string
+	input string
+	want  defs.Event
+}
+
+func TestKnownGoodEventParsing(t *testing.T) {
+
+	tests := []eventTestData{}
+
+	for name, edef := range defs.EventDefs {
+		for _, eform := range edef.EventForms {
+			i := 1
+			for _, ex := range eform.Examples {
+				tests = append(tests, eventTestData{
+					name:  fmt.Sprintf("%s_%s_%d", name, eform.StartsWith, i),
+					input: ex.Text,
+					want:  ex.Parsed,
+				})
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(tt.input))
+			for scanner.Scan() {
+				got := scanner.Event()
+				eventsEqual(got, tt.want, t)
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("EventScanner error = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestUnknownEventParsing(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	tests := []eventTestData{}
+	tests = append(tests, eventTestData{
+		name:  "unknown event",
+		input: "2015-03-14T00-26-52+00-00\nnot a real event data line\n",
+		want: defs.Event{
+			Name:       "unhandled",
+			Type:       "text",
+			Value:      "not a real event data line",
+			Line:       "not a real event data line",
+			LineNumber: 2,
+			Time:       t0,
+			Error:      fmt.Errorf("unrecognized event"),
+		},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(tt.input))
+			for scanner.Scan() {
+				got := scanner.Event()
+				eventsEqual(got, tt.want, t)
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("EventScanner error = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestBadFloatParsing(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	tests := []eventTestData{}
+	tests = append(tests, eventTestData{
+		name:  "bad float",
+		input: "2015-03-14T00-26-52+00-00\nPMT1:1.a06\n",
+		want: defs.Event{
+			Name:       "PMT1",
+			Type:       "float",
+			Line:       "PMT1:1.a06",
+			LineNumber: 2,
+			Time:       t0,
+			Error:      fmt.Errorf("placeholder error"),
+		},
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(tt.input))
+			for scanner.Scan() {
+				got := scanner.Event()
+				eventsEqual(got, tt.want, t)
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("EventScanner error = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"no timestamp", "PMT1:1.05\n", defs.ErrNoTimestamp},
+		{"bad float", "2015-03-14T00-26-52+00-00\nPMT1:1.a06\n", defs.ErrBadFloat},
+		{"missing separator", "2015-03-14T00-26-52+00-00\nsep_test 1.05\n", defs.ErrMissingSeparator},
+		{"unrecognized", "2015-03-14T00-26-52+00-00\nfoo bar\n", defs.ErrUnrecognized},
+		{"bad enum", "2015-03-14T00-26-52+00-00\nenum_test: C\n", defs.ErrBadEnum},
+	}
+	defs.EventDefs["sep_test"] = defs.EventDef{
+		Name:       "sep_test",
+		Type:       "float",
+		EventForms: []defs.EventForm{{StartsWith: "sep_test", ValueAction: "as_float"}},
+	}
+	defer delete(defs.EventDefs, "sep_test")
+	defs.EventDefs["enum_test"] = defs.EventDef{
+		Name:       "enum_test",
+		Type:       "category",
+		Values:     []string{"A", "B"},
+		EventForms: []defs.EventForm{{StartsWith: "enum_test:", ValueAction: "as_enum"}},
+	}
+	defer delete(defs.EventDefs, "enum_test")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(tt.input))
+			var got error
+			for scanner.Scan() {
+				got = scanner.Event().Error
+			}
+			if !errors.Is(got, tt.want) {
+				t.Errorf("Event.Error %v; want %v", got, tt.want)
+			}
+			var perr *defs.ParseError
+			if !errors.As(got, &perr) {
+				t.Fatalf("Event.Error %T; want *defs.ParseError", got)
+			}
+			if perr.LineNumber != strings.Count(tt.input, "\n") {
+				t.Errorf("ParseError.LineNumber %v; want %v", perr.LineNumber, strings.Count(tt.input, "\n"))
+			}
+		})
+	}
+}
+
+func TestSubSecondTimestamps(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52.25+00:00")
+	input := "2015-03-14T00-26-52.250+00-00\nPMT1:1.05\n"
+	want := defs.Event{
+		Name:       "PMT1",
+		Type:       "float",
+		Value:      1.05,
+		Line:       "PMT1:1.05",
+		LineNumber: 2,
+		Time:       t0,
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		eventsEqual(scanner.Event(), want, t)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Errorf("EventScanner error = %v; want nil", err)
+	}
+
+}
+
+func TestTimeFromValue(t *testing.T) {
+	defs.EventDefs["test_fault"] = defs.EventDef{
+		Name: "test_fault",
+		Type: "text",
+		EventForms: []defs.EventForm{
+			{StartsWith: "Test fault", ValueAction: "as_identity", TimeFromValue: "15:04:05,01022006"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_fault")
+
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52-07:00")
+	t1, _ := time.Parse(time.RFC3339, "2015-03-20T21:08:00-07:00")
+	t2, _ := time.Parse(time.RFC3339, "2015-03-13T12:31:06-07:00")
+	tests := []eventTestData{
+		{
+			name:  "embedded time",
+			input: "2015-03-14T00-26-52-07-00\nTest fault, 21:08:00,03202015\n",
+			want: defs.Event{
+				Name:       "test_fault",
+				Type:       "text",
+				Value:      "Test fault, 21:08:00,03202015",
+				Line:       "Test fault, 21:08:00,03202015",
+				LineNumber: 2,
+				Time:       t1,
+			},
+		},
+		{
+			name:  "missing embedded time",
+			input: "2015-03-14T00-26-52-07-00\nTest fault, 21:08:00\n",
+			want: defs.Event{
+				Name:       "test_fault",
+				Type:       "text",
+				Value:      "Test fault, 21:08:00",
+				Line:       "Test fault, 21:08:00",
+				LineNumber: 2,
+				Time:       t0,
+				Error:      fmt.Errorf("placeholder error"),
+			},
+		},
+		{
+			name:  "pump fault",
+			input: "2015-03-14T00-26-52-07-00\nPump over 25 psi, check setting or nozzle clog, 12:31:06,03132015\n",
+			want: defs.Event{
+				Name:       "pump_fault",
+				Type:       "text",
+				Value:      "Pump over 25 psi, check setting or nozzle clog, 12:31:06,03132015",
+				Line:       "Pump over 25 psi, check setting or nozzle clog, 12:31:06,03132015",
+				LineNumber: 2,
+				Time:       t2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(tt.input))
+			for scanner.Scan() {
+				eventsEqual(scanner.Event(), tt.want, t)
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("EventScanner error = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestMissingSentinel(t *testing.T) {
+	defs.EventDefs["test_sentinel"] = defs.EventDef{
+		Name:    "test_sentinel",
+		Type:    "float",
+		Missing: []float64{-999},
+		EventForms: []defs.EventForm{
+			{StartsWith: "test sentinel:", ValueAction: "as_float"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_sentinel")
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\ntest sentinel:-999\n"))
+	for scanner.Scan() {
+		event := scanner.Event()
+		if event.Error != nil {
+			t.Errorf("Event.Error = %v; want nil", event.Error)
+		}
+		if event.Value != nil {
+			t.Errorf("Event.Value = %v; want nil", event.Value)
+		}
+	}
+}
+
+func eventsEqual(got, want defs.Event, t *testing.T) {
+	if got.Name != want.Name {
+		t.Errorf("Event.Name %v; want %v", got.Name, want.Name)
+	}
+	if got.Type != want.Type {
+		t.Errorf("Event.Type %v; want %v", got.Type, want.Type)
+	}
+	if got.Line != want.Line {
+		t.Errorf("Event.Line %v; want %v", got.Line, want.Line)
+	}
+	if got.Value != want.Value {
+		t.Errorf("Event.Value %v; want %v", got.Value, want.Value)
+	}
+	if got.LineNumber != want.LineNumber {
+		t.Errorf("Event.LineNumber %v; want %v", got.LineNumber, want.LineNumber)
+	}
+	if got.Error != nil && want.Error == nil {
+		t.Errorf("Event.Error %v; want %v", got.Error, want.Error)
+	}
+	if got.Error == nil && want.Error != nil {
+		// Don't care what error the test defines, just want an error
+		t.Errorf("Event.Error %v; want an error", got.Error)
+	}
+	if !got.Time.Equal(want.Time) {
+		t.Errorf("Event.Time %v; want %v", got.Time, want.Time)
+	}
+}
+
+func stringsEqual(got, want []string, t *testing.T) {
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v: len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got[i] %v; want[i] %v", got[i], want[i])
+		}
+	}
+}
+
+func TestUntimedEvents(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
+	tdef, _ := time.Parse(time.RFC3339, "2015-01-01T00:00:00+00:00")
+	input := "PMT1:1.05\nPMT2:2.05\n2015-03-14T00-26-52+00-00\nPMT3:3.05\n"
+	tests := []struct {
+		name   string
+		policy string
+		want   []int       // line numbers of events returned
+		times  []time.Time // times of events returned
+		errs   []bool      // whether each event has an error
+	}{
+		{"error", scanner.UntimedError, []int{1, 2, 4}, []time.Time{{}, {}, t0}, []bool{true, true, false}},
+		{"drop", scanner.UntimedDrop, []int{4}, []time.Time{t0}, []bool{false}},
+		{"backdate", scanner.UntimedBackdate, []int{1, 2, 4}, []time.Time{t0, t0, t0}, []bool{false, false, false}},
+		{"default", scanner.UntimedDefault, []int{1, 2, 4}, []time.Time{tdef, tdef, t0}, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(input))
+			if err := scanner.UntimedEvents(tt.policy, tdef); err != nil {
+				t.Fatalf("UntimedEvents() error = %v; want nil", err)
+			}
+			var got []defs.Event
+			for scanner.Scan() {
+				got = append(got, scanner.Event())
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("EventScanner error = %v; want nil", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d events; want %d", len(got), len(tt.want))
+			}
+			for i, event := range got {
+				if event.LineNumber != tt.want[i] {
+					t.Errorf("Event.LineNumber %v; want %v", event.LineNumber, tt.want[i])
+				}
+				if !event.Time.Equal(tt.times[i]) {
+					t.Errorf("Event.Time %v; want %v", event.Time, tt.times[i])
+				}
+				if (event.Error != nil) != tt.errs[i] {
+					t.Errorf("Event.Error %v; want error %v", event.Error, tt.errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUntimedEventsBackdateNoTimestamp(t *testing.T) {
+	es := scanner.NewEventScanner(strings.NewReader("PMT1:1.05\nPMT2:2.05\n"))
+	if err := es.UntimedEvents(scanner.UntimedBackdate, time.Time{}); err != nil {
+		t.Fatalf("UntimedEvents() error = %v; want nil", err)
+	}
+	n := 0
+	for es.Scan() {
+		n++
+		if !errors.Is(es.Event().Error, defs.ErrNoTimestamp) {
+			t.Errorf("Event.Error %v; want %v", es.Event().Error, defs.ErrNoTimestamp)
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d events; want 2", n)
+	}
+}
+
+func TestInterpolateTime(t *testing.T) {
+	input := "2015-03-14T00-00-00+00-00\nPMT1:1\nPMT1:2\nPMT1:3\n" +
+		"2015-03-14T00-01-00+00-00\nPMT1:4\n"
+	tests := []struct {
+		name    string
+		mode    string
+		epsilon time.Duration
+		want    []string
+	}{
+		{"off", scanner.InterpolateOff, 0, []string{
+			"2015-03-14T00:00:00Z", "2015-03-14T00:00:00Z", "2015-03-14T00:00:00Z", "2015-03-14T00:01:00Z",
+		}},
+		{"even", scanner.InterpolateEven, 0, []string{
+			"2015-03-14T00:00:15Z", "2015-03-14T00:00:30Z", "2015-03-14T00:00:45Z", "2015-03-14T00:01:00Z",
+		}},
+		{"epsilon", scanner.InterpolateEpsilon, time.Second, []string{
+			"2015-03-14T00:00:01Z", "2015-03-14T00:00:02Z", "2015-03-14T00:00:03Z", "2015-03-14T00:01:01Z",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(input))
+			if err := scanner.InterpolateTime(tt.mode, tt.epsilon); err != nil {
+				t.Fatalf("InterpolateTime() error = %v; want nil", err)
+			}
+			got := []string{}
+			for scanner.Scan() {
+				got = append(got, scanner.Event().Time.Format(time.RFC3339))
+			}
+			if err := scanner.Err(); err != nil {
+				t.Errorf("EventScanner error = %v; want nil", err)
+			}
+			stringsEqual(got, tt.want, t)
+		})
+	}
+}
+
+func TestTimestampLine(t *testing.T) {
+	input := "PMT1:1\n2015-03-14T00-00-00+00-00\nPMT1:2\n2015-03-14T00-01-00+00-00\nPMT1:3\n"
+	for _, mode := range []string{scanner.InterpolateOff, scanner.InterpolateEven} {
+		t.Run(mode, func(t *testing.T) {
+			es := scanner.NewEventScanner(strings.NewReader(input))
+			if err := es.InterpolateTime(mode, 0); err != nil {
+				t.Fatalf("InterpolateTime() error = %v; want nil", err)
+			}
+			got := []string{}
+			for es.Scan() {
+				got = append(got, es.TimestampLine())
+			}
+			stringsEqual(got, []string{"", "2015-03-14T00-00-00+00-00", "2015-03-14T00-01-00+00-00"}, t)
+		})
+	}
+}
+
+func TestRawLine(t *testing.T) {
+	input := "[fwd] 2015-03-14T00-00-00+00-00\r\n[fwd] PMT1:1\r\n" +
+		"[fwd] Software Version: 2.5.1\r\n[fwd] 2015-03-14T00-01-00+00-00\r\n[fwd] PMT1:2\r\n"
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	es.Preprocess(scanner.StripPrefix(regexp.MustCompile(`^\[fwd\] `)))
+	got := []string{}
+	for es.Scan() {
+		got = append(got, es.Event().Line, es.RawLine(), es.RawTimestampLine())
+	}
+	stringsEqual(got, []string{
+		"PMT1:1", "[fwd] PMT1:1\r", "[fwd] 2015-03-14T00-00-00+00-00\r",
+		"Software Version: 2.5.1", "", "[fwd] 2015-03-14T00-00-00+00-00\r",
+		"Software Version: 2.5.1", "[fwd] Software Version: 2.5.1\r", "[fwd] 2015-03-14T00-00-00+00-00\r",
+		"PMT1:2", "[fwd] PMT1:2\r", "[fwd] 2015-03-14T00-01-00+00-00\r",
+	}, t)
+}
+
+func TestRawLineBackdate(t *testing.T) {
+	input := "PMT1:1\n2015-03-14T00-00-00+00-00\nPMT1:2\n"
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	es.UntimedEvents(scanner.UntimedBackdate, time.Time{})
+	got := []string{}
+	for es.Scan() {
+		got = append(got, es.RawLine(), es.RawTimestampLine(), es.TimestampLine())
+	}
+	// Backdated lines follow the timestamp line which gave them their time
+	ts := "2015-03-14T00-00-00+00-00"
+	stringsEqual(got, []string{"PMT1:1", ts, ts, "PMT1:2", ts, ts}, t)
+}
+
+func TestCallbacks(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1\nPMT2:2\nPMT1:3\nnot a real event\n"
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	var pmt1 []interface{}
+	var errLines []int
+	es.OnEvent("PMT1", func(e defs.Event) { pmt1 = append(pmt1, e.Value) })
+	es.OnError(func(e defs.Event) { errLines = append(errLines, e.LineNumber) })
+	if err := es.Run(); err != nil {
+		t.Fatalf("Run() error = %v; want nil", err)
+	}
+	if len(pmt1) != 2 || pmt1[0] != 1.0 || pmt1[1] != 3.0 {
+		t.Errorf("PMT1 callback values %v; want [1 3]", pmt1)
+	}
+	if len(errLines) != 1 || errLines[0] != 5 {
+		t.Errorf("error callback lines %v; want [5]", errLines)
+	}
+}
+
+func TestIgnoreLines(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1\nDEBUG noisy line\nnot a real event\nPMT1:2\n"
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	es.IgnoreLines(regexp.MustCompile(`^DEBUG `), regexp.MustCompile(`^not a real`))
+	got := []int{}
+	for es.Scan() {
+		got = append(got, es.Event().LineNumber)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 5 {
+		t.Errorf("event lines %v; want [2 5]", got)
+	}
+}
+
+func TestPreprocess(t *testing.T) {
+	input := "Mar 14 00:26:52 seaflow740 labview[1234]: 2015-03-14T00-26-52+00-00\n" +
+		"Mar  4 00:26:53 seaflow740 labview: PMT1:1\n" +
+		"-- MARK --\n" +
+		"PMT1:2\n"
+	want := []string{"2 PMT1:1", "4 PMT1:2"}
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	es.Preprocess(
+		scanner.StripPrefix(scanner.SyslogPrefix),
+		func(line string) (string, bool) { return line, line != "-- MARK --" },
+	)
+	got := []string{}
+	for es.Scan() {
+		e := es.Event()
+		if e.Error != nil {
+			t.Errorf("line %d error = %v; want nil", e.LineNumber, e.Error)
+		}
+		got = append(got, fmt.Sprintf("%d %s", e.LineNumber, e.Line))
+	}
+	stringsEqual(got, want, t)
+}
+
+func TestRestart(t *testing.T) {
+	input := "Instrument Serial: 740\nCruise Name: HOT227\n" +
+		"2015-03-14T00-26-52+00-00\nPMT1:1\nPMT1:2\n" +
+		"Instrument Serial: 740\nCruise Name: HOT227\n" +
+		"2015-03-14T01-00-00+00-00\nPMT1:3\n"
+	want := []string{
+		"1 instrument_serial", "2 cruise_name", "4 PMT1", "5 PMT1",
+		"6 restart", "6 instrument_serial", "7 cruise_name", "9 PMT1",
+	}
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	es.UntimedEvents(scanner.UntimedBackdate, time.Time{})
+	es.MaxStaleLines(3)
+	got := []string{}
+	for es.Scan() {
+		got = append(got, fmt.Sprintf("%d %s", es.Event().LineNumber, es.Event().Name))
+	}
+	stringsEqual(got, want, t)
+	if a := es.TimeAnomalies(); len(a) != 0 {
+		t.Errorf("TimeAnomalies() = %v; want none across a restart", a)
+	}
+}
+
+func TestFileMarkers(t *testing.T) {
+	input := "#FILE: a/SFlog_740.txt\n2015-03-14T00-26-52+00-00\nPMT1:1\nPMT1:2\n" +
+		"#FILE: b/SFlog_751.txt\nPMT1:3\n2015-03-13T00-00-00+00-00\nPMT1:4\n"
+	want := []string{
+		"a/SFlog_740.txt 2 PMT1 2015-03-14T00:26:52Z",
+		"a/SFlog_740.txt 3 PMT1 2015-03-14T00:26:52Z",
+		"b/SFlog_751.txt 1 PMT1 2015-03-13T00:00:00Z", // backdated within its own file
+		"b/SFlog_751.txt 3 PMT1 2015-03-13T00:00:00Z",
+	}
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	es.SourceName("-")
+	es.FileMarkers(true)
+	es.UntimedEvents(scanner.UntimedBackdate, time.Time{})
+	es.InterpolateTime(scanner.InterpolateEven, 0)
+	got := []string{}
+	for es.Scan() {
+		e := es.Event()
+		got = append(got, fmt.Sprintf("%s %d %s %s", e.Source, e.LineNumber, e.Name, e.Time.UTC().Format(time.RFC3339)))
+	}
+	if err := es.Err(); err != nil {
+		t.Fatalf("EventScanner error = %v; want nil", err)
+	}
+	stringsEqual(got, want, t)
+	if a := es.TimeAnomalies(); len(a) != 0 {
+		t.Errorf("TimeAnomalies() = %v; want none between files", a)
+	}
+
+	// Off by default, markers are comment lines
+	es = scanner.NewEventScanner(strings.NewReader(input))
+	es.Scan()
+	if e := es.Event(); e.Source != "" || e.LineNumber != 1 {
+		t.Errorf("first event %+v without FileMarkers; want marker line 1 with no source", e)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	input := "Software Version: 2.5.1\nFirmware Version: 1.12\n" +
+		"2015-03-14T00-26-52+00-00\nPMT1:1\n" +
+		"Instrument Serial: 740\nSoftware Version: 2.6.0\n" +
+		"2015-03-14T01-00-00+00-00\nPMT1:3\n"
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	software, firmware := es.Versions()
+	if software != "" || firmware != "" {
+		t.Errorf("Versions() before Scan = %q, %q; want empty", software, firmware)
+	}
+	names := []string{}
+	for es.Scan() {
+		if es.Event().Error == nil {
+			names = append(names, es.Event().Name)
+		}
+		if es.Event().LineNumber == 4 {
+			if software, firmware = es.Versions(); software != "2.5.1" || firmware != "1.12" {
+				t.Errorf("Versions() at line 4 = %q, %q; want 2.5.1, 1.12", software, firmware)
+			}
+		}
+	}
+	if software, firmware = es.Versions(); software != "2.6.0" || firmware != "1.12" {
+		t.Errorf("Versions() = %q, %q; want 2.6.0, 1.12", software, firmware)
+	}
+	stringsEqual(names, []string{"PMT1", "restart", "instrument_serial", "software_version", "PMT1"}, t)
+}
+
+func TestVersionForms(t *testing.T) {
+	defs.EventDefs["test_flow"] = defs.EventDef{
+		Name: "test_flow",
+		Type: "float",
+		EventForms: []defs.EventForm{
+			{StartsWith: "flow:", ValueAction: "as_float", MaxVersion: "2"},
+			{StartsWith: "flow:", ValueAction: "as_float_extract", Pattern: "([0-9.]+) mL", MinVersion: "2"},
+		},
+	}
+	defer delete(defs.EventDefs, "test_flow")
+
+	input := "Software Version: 1.5\n2015-03-14T00-26-52+00-00\nflow: 0.25\n" +
+		"Software Version: 2.0\n2015-03-14T01-00-00+00-00\nflow: set to 0.5 mL/min\n"
+	es := scanner.NewEventScanner(strings.NewReader(input))
+	got := []string{}
+	for es.Scan() {
+		if e := es.Event(); e.Name == "test_flow" {
+			got = append(got, fmt.Sprintf("%v %v", e.Value, e.Error))
+		}
+	}
+	stringsEqual(got, []string{"0.25 <nil>", "0.5 <nil>"}, t)
+}
+
+func TestSpecialFloats(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.5e-3\nPMT1:inf\nPMT1:-Inf\nPMT1:nan\nPMT1:2E2\n"
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{scanner.SpecialFloatPass, []string{"2 0.0015 <nil>", "3 +Inf <nil>", "4 -Inf <nil>", "5 NaN <nil>", "6 200 <nil>"}},
+		{scanner.SpecialFloatDrop, []string{"2 0.0015 <nil>", "6 200 <nil>"}},
+		{scanner.SpecialFloatError, []string{"2 0.0015 <nil>", "3 +Inf special", "4 -Inf special", "5 NaN special", "6 200 <nil>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			es := scanner.NewEventScanner(strings.NewReader(input))
+			if err := es.SpecialFloats(tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for es.Scan() {
+				e := es.Event()
+				errText := "<nil>"
+				if errors.Is(e.Error, defs.ErrSpecialFloat) {
+					errText = "special"
+				} else if e.Error != nil {
+					errText = e.Error.Error()
+				}
+				got = append(got, fmt.Sprintf("%d %v %s", e.LineNumber, e.Value, errText))
+			}
+			stringsEqual(got, tt.want, t)
+		})
+	}
+	if err := scanner.NewEventScanner(strings.NewReader(input)).SpecialFloats("keep"); err == nil {
+		t.Error("SpecialFloats(\"keep\") error = nil; want an error")
+	}
+}
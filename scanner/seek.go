@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// SeekTime returns the byte offset of the last timestamp line before time t in
+// a log file of size bytes, found by binary search over timestamp lines.
+// Scanning from this offset sees every event at or after t as long as
+// timestamps increase through the file. Returns 0 if no timestamp line before
+// t is found.
+func SeekTime(r io.ReaderAt, size int64, t time.Time) (int64, error) {
+	var best int64
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		off, ts, ok, err := nextTimestamp(r, size, mid)
+		if err != nil {
+			return 0, err
+		}
+		if !ok || !ts.Before(t) {
+			hi = mid
+		} else {
+			best = off
+			lo = off + 1
+		}
+	}
+	return best, nil
+}
+
+// nextTimestamp returns the offset and time of the first timestamp line which
+// starts at or after pos.
+func nextTimestamp(r io.ReaderAt, size int64, pos int64) (off int64, t time.Time, ok bool, err error) {
+	br := bufio.NewReader(io.NewSectionReader(r, pos, size-pos))
+	off = pos
+	if pos > 0 {
+		// Skip to the start of the next line, unless already at one
+		var prev [1]byte
+		if _, err := r.ReadAt(prev[:], pos-1); err != nil {
+			return 0, time.Time{}, false, err
+		}
+		if prev[0] != '\n' {
+			skipped, err := br.ReadString('\n')
+			off += int64(len(skipped))
+			if err == io.EOF {
+				return 0, time.Time{}, false, nil
+			} else if err != nil {
+				return 0, time.Time{}, false, err
+			}
+		}
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			text := line
+			if text[len(text)-1] == '\n' {
+				text = text[:len(text)-1]
+			}
+			if len(text) > 0 && text[len(text)-1] == '\r' {
+				text = text[:len(text)-1]
+			}
+			if ts, _, perr := parseTimestamp(text); perr == nil {
+				return off, ts, true, nil
+			}
+			off += int64(len(line))
+		}
+		if err == io.EOF {
+			return 0, time.Time{}, false, nil
+		} else if err != nil {
+			return 0, time.Time{}, false, err
+		}
+	}
+}
+
+// ReadHeader returns the lines of a log file read from r before its first
+// timestamp line, usually the banner with the instrument serial and software
+// versions. Pass them to EventScanner.Header when scanning starts after a
+// seek.
+func ReadHeader(r io.Reader) ([]string, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lines := []string{}
+	for s.Scan() {
+		text := strings.TrimRight(s.Text(), "\r")
+		if _, _, err := parseTimestamp(text); err == nil {
+			break
+		}
+		lines = append(lines, text)
+	}
+	return lines, s.Err()
+}
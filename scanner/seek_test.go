@@ -0,0 +1,67 @@
+package scanner_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestSeekTime(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	var b strings.Builder
+	offsets := []int{} // offset of each timestamp line
+	for i := 0; i < 100; i++ {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%s\nPMT1:%d\nlaser: 1\n", t0.Add(time.Duration(i)*time.Minute).Format("2006-01-02T15-04-05+00-00"), i)
+	}
+	input := b.String()
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want int64
+	}{
+		{"before start", t0.Add(-time.Hour), 0},
+		{"first", t0, 0},
+		{"exact", t0.Add(50 * time.Minute), int64(offsets[49])},
+		{"between", t0.Add(50*time.Minute + time.Second), int64(offsets[50])},
+		{"after end", t0.Add(time.Hour * 3), int64(offsets[99])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := strings.NewReader(input)
+			got, err := scanner.SeekTime(r, r.Size(), tt.t)
+			if err != nil {
+				t.Fatalf("SeekTime() error = %v; want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("SeekTime() %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	input := "Instrument Serial: 740\nSoftware Version: 2.5.1\n" +
+		"2015-03-14T00-26-52+00-00\nPMT1:1\n" +
+		"2015-03-14T01-00-00+00-00\nPMT1:3\n"
+	header, err := scanner.ReadHeader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadHeader() error = %v; want nil", err)
+	}
+	stringsEqual(header, []string{"Instrument Serial: 740", "Software Version: 2.5.1"}, t)
+
+	// Scanning after a seek past the header still reads its versions
+	off := strings.Index(input, "2015-03-14T01")
+	es := scanner.NewEventScanner(strings.NewReader(input[off:]))
+	es.LineOffset(4)
+	es.Header(header)
+	for es.Scan() {
+	}
+	if software, _ := es.Versions(); software != "2.5.1" {
+		t.Errorf("Versions() software = %q; want 2.5.1", software)
+	}
+}
@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"regexp"
+	"time"
+)
+
+// SyslogPrefix matches the prefix syslog adds to forwarded lines, e.g.
+// "Mar 14 00:26:52 seaflow740 labview[1234]: ", with an optional priority,
+// e.g. "<13>".
+var SyslogPrefix = regexp.MustCompile(`^(<\d{1,3}>)?[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} \S+ [^\s:]+(\[\d+\])?: `)
+
+// rfc5424Expr matches RFC 5424 syslog lines, capturing the timestamp and
+// message, e.g. "<13>1 2015-03-14T00:26:52Z seaflow740 labview 1234 - - PMT1:1"
+var rfc5424Expr = regexp.MustCompile(`^<\d{1,3}>1 (\S+) \S+ \S+ \S+ \S+ (?:-|(?:\[(?:[^\]"]|"(?:[^"\\]|\\.)*")*\])+) ?(.*)$`)
+
+// rfc3164Expr matches BSD syslog lines, capturing the timestamp and message,
+// e.g. "<13>Mar 14 00:26:52 seaflow740 labview[1234]: PMT1:1"
+var rfc3164Expr = regexp.MustCompile(`^(?:<\d{1,3}>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) \S+ [^\s:]+(?:\[\d+\])?: ?(.*)$`)
+
+// syslogInput parses lines of logs captured through syslog.
+type syslogInput struct {
+	useTime bool
+	year    int
+}
+
+// SyslogInput turns on reading the log as syslog output, in RFC 3164 (BSD) or
+// RFC 5424 format. The syslog priority, timestamp, host, and tag are removed
+// from each line before anything else, including Preprocess functions.
+// Lines which aren't syslog lines, e.g. continuation lines, are read as is.
+//
+// If useTime is true, events with no time because no SeaFlow timestamp line
+// precedes them get the time of their syslog line instead. RFC 3164
+// timestamps have no year or time zone, they are read as UTC in year.
+func (es *EventScanner) SyslogInput(useTime bool, year int) {
+	es.syslog = &syslogInput{useTime: useTime, year: year}
+}
+
+// parse returns the message of a syslog line and its syslog time, or line and
+// a zero time if it isn't a syslog line.
+func (s *syslogInput) parse(line string) (string, time.Time) {
+	if m := rfc5424Expr.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse(time.RFC3339Nano, m[1])
+		if err != nil {
+			t = time.Time{} // "-" for no timestamp
+		}
+		return m[2], t
+	}
+	if m := rfc3164Expr.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse("Jan _2 15:04:05", m[1])
+		if err != nil {
+			return m[2], time.Time{}
+		}
+		return m[2], time.Date(s.year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	}
+	return line, time.Time{}
+}
@@ -0,0 +1,59 @@
+package scanner_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestSyslogInput(t *testing.T) {
+	input := "<13>Mar 14 00:20:00 seaflow740 labview[1234]: Instrument Serial: 740\n" +
+		"<13>1 2015-03-14T00:25:00.5Z seaflow740 labview 1234 - [meta x=\"]\"] Cruise Name: HOT227\n" +
+		"Mar 14 00:26:52 seaflow740 labview: 2015-03-14T00-26-52+00-00\n" +
+		"Mar 14 00:30:00 seaflow740 labview: PMT1:1\n" +
+		"continued line without a prefix\n"
+	tests := []struct {
+		name    string
+		useTime bool
+		want    []string
+	}{
+		{
+			"syslog times",
+			true,
+			[]string{
+				"1 instrument_serial 2015-03-14T00:20:00Z false",
+				"2 cruise_name 2015-03-14T00:25:00.5Z false",
+				"4 PMT1 2015-03-14T00:26:52Z false", // SeaFlow time
+				"5 unhandled 2015-03-14T00:26:52Z true",
+			},
+		},
+		{
+			"SeaFlow times only",
+			false,
+			[]string{
+				"1  0001-01-01T00:00:00Z true",
+				"2  0001-01-01T00:00:00Z true",
+				"4 PMT1 2015-03-14T00:26:52Z false",
+				"5 unhandled 2015-03-14T00:26:52Z true",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := scanner.NewEventScanner(strings.NewReader(input))
+			es.SyslogInput(tt.useTime, 2015)
+			got := []string{}
+			for es.Scan() {
+				e := es.Event()
+				got = append(got, fmt.Sprintf("%d %s %s %v", e.LineNumber, e.Name, e.Time.Format(time.RFC3339Nano), e.Error != nil))
+			}
+			if err := es.Err(); err != nil {
+				t.Fatalf("EventScanner error = %v; want nil", err)
+			}
+			stringsEqual(got, tt.want, t)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of timestamp anomalies detected by EventScanner.
+const (
+	// AnomalyBackward marks a timestamp line that is earlier than the
+	// preceding timestamp line, e.g. a repeated hour after a daylight saving
+	// time change that wasn't reflected in the UTC offset.
+	AnomalyBackward = "backward"
+	// AnomalyOffset marks a change of UTC offset where the local wall clock
+	// did not move with it, e.g. an instrument OS that relabeled its time zone
+	// without adjusting the clock.
+	AnomalyOffset = "offset_change"
+	// AnomalyLeapSecond marks a timestamp with a seconds field of 60. These
+	// can't be represented by time.Time and are clamped to second 59.
+	AnomalyLeapSecond = "leap_second"
+	// AnomalyStale marks a run of lines more than the maximum allowed number
+	// of lines after the last timestamp line, whose times may be stale.
+	AnomalyStale = "stale"
+)
+
+// TimeAnomaly describes a suspicious jump between consecutive timestamp lines
+// and the range of lines it affects.
+type TimeAnomaly struct {
+	Kind       string
+	StartLine  int           // line number of the offending timestamp line
+	EndLine    int           // last line number affected by the anomaly
+	From       time.Time     // preceding timestamp, as read
+	To         time.Time     // offending timestamp, as read
+	Correction time.Duration // shift applied to times when normalizing
+	open       bool
+	catchUp    time.Time // backward: raw time which closes the anomaly
+	offset     int       // offset_change: UTC offset which closes the anomaly
+	lastLine   int       // stale: line number of the last timestamp line
+}
+
+func (a TimeAnomaly) String() string {
+	lines := fmt.Sprintf("Line %d", a.StartLine)
+	if a.EndLine > a.StartLine {
+		lines = fmt.Sprintf("Lines %d-%d", a.StartLine, a.EndLine)
+	}
+	var msg string
+	switch a.Kind {
+	case AnomalyBackward:
+		msg = fmt.Sprintf("time went backward by %v from %s to %s", a.From.Sub(a.To), formatTime(a.From), formatTime(a.To))
+	case AnomalyOffset:
+		msg = fmt.Sprintf("UTC offset changed without a matching clock change from %s to %s", formatTime(a.From), formatTime(a.To))
+	case AnomalyLeapSecond:
+		msg = fmt.Sprintf("leap second clamped to %s", formatTime(a.To))
+	case AnomalyStale:
+		msg = fmt.Sprintf("stale time %s, no timestamp line for more than %d lines", formatTime(a.From), a.StartLine-a.lastLine-1)
+	default:
+		msg = fmt.Sprintf("%s time anomaly", a.Kind)
+	}
+	if a.Correction != 0 {
+		msg += fmt.Sprintf(", times shifted by %v", a.Correction)
+	}
+	return fmt.Sprintf("%s, %s", lines, msg)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// timeChecker tracks timestamp lines for impossible or suspicious jumps and
+// optionally normalizes them so event times never run backward.
+type timeChecker struct {
+	normalize  bool
+	maxStale   int           // lines after a timestamp line before times are stale, 0 for no limit
+	last       int           // line number of the previous timestamp line
+	prev       time.Time     // previous timestamp as read
+	max        time.Time     // latest timestamp as read
+	correction time.Duration // cumulative normalization shift
+	anomalies  []TimeAnomaly
+	restarted  bool // no timestamp line since a restart, times aren't stale
+}
+
+// check records any anomalies introduced by the timestamp line t at line
+// number i and returns the time to assign to subsequent events.
+func (tc *timeChecker) check(t time.Time, leap bool, i int) time.Time {
+	// Close anomalies resolved by this timestamp
+	_, offset := t.Zone()
+	for j := range tc.anomalies {
+		a := &tc.anomalies[j]
+		if !a.open {
+			continue
+		}
+		switch a.Kind {
+		case AnomalyBackward:
+			if !t.Before(a.catchUp) {
+				a.open = false
+			}
+		case AnomalyOffset:
+			if offset == a.offset {
+				a.open = false
+			}
+		case AnomalyStale:
+			a.open = false
+		}
+		if a.open {
+			a.EndLine = i
+		}
+	}
+
+	if leap {
+		tc.anomalies = append(tc.anomalies, TimeAnomaly{
+			Kind: AnomalyLeapSecond, StartLine: i, EndLine: i, From: tc.prev, To: t,
+		})
+	}
+
+	if !tc.prev.IsZero() {
+		_, prevOffset := tc.prev.Zone()
+		utcDelta := t.Sub(tc.prev)
+		if utcDelta < 0 {
+			a := TimeAnomaly{
+				Kind: AnomalyBackward, StartLine: i, EndLine: i, From: tc.prev, To: t,
+				open: true, catchUp: tc.max,
+			}
+			if tc.normalize {
+				a.Correction = -utcDelta
+				tc.correction += a.Correction
+			}
+			tc.anomalies = append(tc.anomalies, a)
+		} else if offset != prevOffset {
+			// A real DST transition moves the wall clock by the offset
+			// difference while UTC stays continuous. If the wall clock
+			// stayed within half the offset difference of where it was,
+			// the label changed, not the clock. A long gap across a real
+			// transition moves the wall clock further.
+			shift := time.Duration(offset-prevOffset) * time.Second
+			wallDelta := utcDelta + shift
+			if abs(wallDelta) < abs(shift)/2 {
+				a := TimeAnomaly{
+					Kind: AnomalyOffset, StartLine: i, EndLine: i, From: tc.prev, To: t,
+					open: true, offset: prevOffset,
+				}
+				if tc.normalize {
+					a.Correction = wallDelta - utcDelta
+					tc.correction += a.Correction
+				}
+				tc.anomalies = append(tc.anomalies, a)
+			}
+		}
+	}
+
+	tc.prev = t
+	tc.last = i
+	tc.restarted = false
+	if t.After(tc.max) {
+		tc.max = t
+	}
+	return t.Add(tc.correction)
+}
+
+// extend marks line i as affected by any unresolved anomalies.
+func (tc *timeChecker) extend(i int) {
+	stale := false
+	for j := range tc.anomalies {
+		if tc.anomalies[j].open {
+			tc.anomalies[j].EndLine = i
+			stale = stale || tc.anomalies[j].Kind == AnomalyStale
+		}
+	}
+	if !stale && !tc.restarted && tc.maxStale > 0 && tc.last > 0 && i-tc.last > tc.maxStale {
+		tc.anomalies = append(tc.anomalies, TimeAnomaly{
+			Kind: AnomalyStale, StartLine: i, EndLine: i, From: tc.prev, To: tc.prev,
+			open: true, lastLine: tc.last,
+		})
+	}
+}
+
+// restart ends stale time detection until the next timestamp line, after a
+// restart of the instrument software.
+func (tc *timeChecker) restart() {
+	for j := range tc.anomalies {
+		if tc.anomalies[j].Kind == AnomalyStale {
+			tc.anomalies[j].open = false
+		}
+	}
+	tc.restarted = true
+}
+
+// newFile starts checking another log file, with no previous timestamp to
+// compare with. Anomalies already recorded are kept.
+func (tc *timeChecker) newFile() {
+	for j := range tc.anomalies {
+		tc.anomalies[j].open = false
+	}
+	tc.last = 0
+	tc.prev = time.Time{}
+	tc.max = time.Time{}
+	tc.correction = 0
+	tc.restarted = false
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,131 @@
+package scanner_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+)
+
+func TestTimeAnomalies(t *testing.T) {
+	type anomalyTestData struct {
+		name      string
+		input     string
+		normalize bool
+		maxStale  int
+		want      []scanner.TimeAnomaly
+		wantTimes []string
+	}
+
+	tests := []anomalyTestData{
+		{
+			name:      "no anomalies",
+			input:     "2015-03-14T00-26-52+00-00\nPMT1:1\n2015-03-14T01-26-52+00-00\nPMT1:2\n",
+			want:      []scanner.TimeAnomaly{},
+			wantTimes: []string{"2015-03-14T00:26:52Z", "2015-03-14T01:26:52Z"},
+		},
+		{
+			name:      "legitimate DST change",
+			input:     "2015-11-01T01-59-00-07-00\nPMT1:1\n2015-11-01T01-00-00-08-00\nPMT1:2\n",
+			want:      []scanner.TimeAnomaly{},
+			wantTimes: []string{"2015-11-01T01:59:00-07:00", "2015-11-01T01:00:00-08:00"},
+		},
+		{
+			name:      "legitimate DST change after a long gap",
+			input:     "2015-11-01T00-30-00-07-00\nPMT1:1\n2015-11-01T02-00-00-08-00\nPMT1:2\n",
+			normalize: true,
+			want:      []scanner.TimeAnomaly{},
+			wantTimes: []string{"2015-11-01T00:30:00-07:00", "2015-11-01T02:00:00-08:00"},
+		},
+		{
+			name: "backward jump",
+			input: "2015-03-14T02-00-00+00-00\nPMT1:1\n" +
+				"2015-03-14T01-00-00+00-00\nPMT1:2\n" +
+				"2015-03-14T01-30-00+00-00\nPMT1:3\n" +
+				"2015-03-14T02-30-00+00-00\nPMT1:4\n",
+			want: []scanner.TimeAnomaly{
+				{Kind: scanner.AnomalyBackward, StartLine: 3, EndLine: 6},
+			},
+			wantTimes: []string{
+				"2015-03-14T02:00:00Z", "2015-03-14T01:00:00Z", "2015-03-14T01:30:00Z", "2015-03-14T02:30:00Z",
+			},
+		},
+		{
+			name: "backward jump normalized",
+			input: "2015-03-14T02-00-00+00-00\nPMT1:1\n" +
+				"2015-03-14T01-00-00+00-00\nPMT1:2\n" +
+				"2015-03-14T01-30-00+00-00\nPMT1:3\n",
+			normalize: true,
+			want: []scanner.TimeAnomaly{
+				{Kind: scanner.AnomalyBackward, StartLine: 3, EndLine: 6, Correction: time.Hour},
+			},
+			wantTimes: []string{"2015-03-14T02:00:00Z", "2015-03-14T02:00:00Z", "2015-03-14T02:30:00Z"},
+		},
+		{
+			name:      "offset relabeled without clock change normalized",
+			input:     "2015-11-01T01-59-00-07-00\nPMT1:1\n2015-11-01T02-00-00-08-00\nPMT1:2\n",
+			normalize: true,
+			want: []scanner.TimeAnomaly{
+				{Kind: scanner.AnomalyOffset, StartLine: 3, EndLine: 4, Correction: -time.Hour},
+			},
+			wantTimes: []string{"2015-11-01T01:59:00-07:00", "2015-11-01T01:00:00-08:00"},
+		},
+		{
+			name:  "leap second",
+			input: "2016-12-31T23-59-60+00-00\nPMT1:1\n",
+			want: []scanner.TimeAnomaly{
+				{Kind: scanner.AnomalyLeapSecond, StartLine: 1, EndLine: 1},
+			},
+			wantTimes: []string{"2016-12-31T23:59:59Z"},
+		},
+		{
+			name: "stale time",
+			input: "2015-03-14T00-26-52+00-00\nPMT1:1\nPMT1:2\nPMT1:3\nPMT1:4\n" +
+				"2015-03-14T00-27-52+00-00\nPMT1:5\nPMT1:6\n",
+			maxStale: 2,
+			want: []scanner.TimeAnomaly{
+				{Kind: scanner.AnomalyStale, StartLine: 4, EndLine: 5},
+			},
+			wantTimes: []string{
+				"2015-03-14T00:26:52Z", "2015-03-14T00:26:52Z", "2015-03-14T00:26:52Z", "2015-03-14T00:26:52Z",
+				"2015-03-14T00:27:52Z", "2015-03-14T00:27:52Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := scanner.NewEventScanner(strings.NewReader(tt.input))
+			scanner.NormalizeTime(tt.normalize)
+			scanner.MaxStaleLines(tt.maxStale)
+			gotTimes := []string{}
+			for scanner.Scan() {
+				gotTimes = append(gotTimes, scanner.Event().Time.Format(time.RFC3339))
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("EventScanner error = %v; want nil", err)
+			}
+			stringsEqual(gotTimes, tt.wantTimes, t)
+
+			got := scanner.TimeAnomalies()
+			if len(got) != len(tt.want) {
+				t.Fatalf("len(TimeAnomalies()) %v; want %v", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].Kind != tt.want[i].Kind {
+					t.Errorf("TimeAnomaly.Kind %v; want %v", got[i].Kind, tt.want[i].Kind)
+				}
+				if got[i].StartLine != tt.want[i].StartLine || got[i].EndLine != tt.want[i].EndLine {
+					t.Errorf(
+						"TimeAnomaly lines %d-%d; want %d-%d",
+						got[i].StartLine, got[i].EndLine, tt.want[i].StartLine, tt.want[i].EndLine,
+					)
+				}
+				if got[i].Correction != tt.want[i].Correction {
+					t.Errorf("TimeAnomaly.Correction %v; want %v", got[i].Correction, tt.want[i].Correction)
+				}
+			}
+		})
+	}
+}
@@ -1,241 +1,176 @@
 package seaflog_test
 
 import (
-	"fmt"
+	"bytes"
+	"os"
 	"strings"
 	"testing"
-	"time"
 
-	"github.com/seaflow-uw/seaflog"
+	"github.com/seaflow-uw/seaflog/v2"
 )
 
-type eventTestData struct {
-	name  string
-	input string
-	want  seaflog.Event
-}
-
-func TestKnownGoodEventParsing(t *testing.T) {
-
-	tests := []eventTestData{}
+// TestDeprecatedAPI checks the original single package API still works.
+func TestDeprecatedAPI(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.05\nnot a real event data line\n"
+	want := []string{
+		"2015-03-14T00:26:52+00:00\t1.05",
+		"2015-03-14T00:26:52+00:00\tNA",
+	}
 
-	for name, edef := range seaflog.EventDefs {
-		for _, eform := range edef.EventForms {
-			i := 1
-			for _, ex := range eform.Examples {
-				tests = append(tests, eventTestData{
-					name:  fmt.Sprintf("%s_%s_%d", name, eform.StartsWith, i),
-					input: ex.Text,
-					want:  ex.Parsed,
-				})
-			}
+	if _, ok := seaflog.EventDefs["PMT1"]; !ok {
+		t.Fatalf("EventDefs has no PMT1 definition")
+	}
+	w := seaflog.NewTsdataWriter("test", "test", "")
+	got := []string{}
+	scanner := seaflog.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		event := scanner.Event()
+		if !seaflog.TimeFilter(event, event.Time, event.Time) {
+			t.Errorf("TimeFilter() = false; want true")
+		}
+		if event.Name == "unhandled" {
+			event = seaflog.UnhandledToNote(event)
+		}
+		line, err := w.EventText(event)
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
 		}
+		got = append(got, strings.Join(strings.Split(line, "\t")[:2], "\t"))
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			scanner := seaflog.NewEventScanner(strings.NewReader(tt.input))
-			for scanner.Scan() {
-				got := scanner.Event()
-				eventsEqual(got, tt.want, t)
-			}
-			if err := scanner.Err(); err != nil {
-				t.Errorf("EventScanner error = %v; want nil", err)
-			}
-		})
+	if err := scanner.Err(); err != nil {
+		t.Errorf("EventScanner error = %v; want nil", err)
 	}
-}
-
-func TestUnknownEventParsing(t *testing.T) {
-	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
-	tests := []eventTestData{}
-	tests = append(tests, eventTestData{
-		name:  "unknown event",
-		input: "2015-03-14T00-26-52+00-00\nnot a real event data line\n",
-		want: seaflog.Event{
-			Name:       "unhandled",
-			Type:       "text",
-			Value:      "not a real event data line",
-			Line:       "not a real event data line",
-			LineNumber: 2,
-			Time:       t0,
-			Error:      fmt.Errorf("unrecognized event"),
-		},
-	})
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			scanner := seaflog.NewEventScanner(strings.NewReader(tt.input))
-			for scanner.Scan() {
-				got := scanner.Event()
-				eventsEqual(got, tt.want, t)
-			}
-			if err := scanner.Err(); err != nil {
-				t.Errorf("EventScanner error = %v; want nil", err)
-			}
-		})
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v; len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got[i] %v; want[i] %v", got[i], want[i])
+		}
 	}
 }
 
-func TestBadFloatParsing(t *testing.T) {
-	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
-	tests := []eventTestData{}
-	tests = append(tests, eventTestData{
-		name:  "bad float",
-		input: "2015-03-14T00-26-52+00-00\nPMT1:1.a06\n",
-		want: seaflog.Event{
-			Name:       "PMT1",
-			Type:       "float",
-			Line:       "PMT1:1.a06",
-			LineNumber: 2,
-			Time:       t0,
-			Error:      fmt.Errorf("placeholder error"),
+func TestReport(t *testing.T) {
+	m := seaflog.Message{
+		Level: "warning", File: "SFlog_740.txt", Line: 3, ErrorKind: "bad_float",
+		Message: "bad float value", Text: "PMT1:x",
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			seaflog.LogFormatText,
+			"-------------------------------------------------------------------------------\n" +
+				"Line 3, bad float value.\n  PMT1:x\n",
 		},
-	})
-
+		{
+			seaflog.LogFormatJSON,
+			`{"level":"warning","file":"SFlog_740.txt","line":3,"error_kind":"bad_float",` +
+				`"message":"bad float value","text":"PMT1:x"}` + "\n",
+		},
+	}
+	defer seaflog.Log.SetOutput(os.Stderr)
+	defer seaflog.SetLogFormat(seaflog.LogFormatText)
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			scanner := seaflog.NewEventScanner(strings.NewReader(tt.input))
-			for scanner.Scan() {
-				got := scanner.Event()
-				eventsEqual(got, tt.want, t)
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			seaflog.Log.SetOutput(&buf)
+			if err := seaflog.SetLogFormat(tt.format); err != nil {
+				t.Fatalf("SetLogFormat() error = %v; want nil", err)
 			}
-			if err := scanner.Err(); err != nil {
-				t.Errorf("EventScanner error = %v; want nil", err)
+			seaflog.Report(m)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Report() wrote %q; want %q", got, tt.want)
 			}
 		})
 	}
-}
-
-func TestUnhandledToNote(t *testing.T) {
-	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52+00:00")
-	input := seaflog.Event{
-		Name:       "unhandled",
-		Type:       "text",
-		Value:      "not a real event data line",
-		Line:       "not a real event data line",
-		LineNumber: 2,
-		Time:       t0,
-		Error:      fmt.Errorf("unrecognized event"),
-	}
-	want := seaflog.Event{
-		Name:       "note",
-		Type:       "text",
-		Value:      "not a real event data line",
-		Line:       "not a real event data line",
-		LineNumber: 2,
-		Time:       t0,
+	if err := seaflog.SetLogFormat("xml"); err == nil {
+		t.Errorf("SetLogFormat(\"xml\") error = nil; want error")
 	}
-
-	t.Run("unhandled to note", func(t *testing.T) {
-		got := seaflog.UnhandledToNote(input)
-		eventsEqual(got, want, t)
-	})
 }
 
-func TestTimeFilter(t *testing.T) {
-	stamps := []string{
-		"2015-03-14T00:00:00+00:00",
-		"2015-03-15T00:00:00+00:00",
-		"2015-03-16T00:00:00+00:00",
-		"2015-03-17T00:00:00+00:00",
-	}
-	events := make([]seaflog.Event, len(stamps))
-	for i := range stamps {
-		ti, _ := time.Parse(time.RFC3339, stamps[i])
-		events[i] = seaflog.Event{Time: ti}
-	}
-
-	type timeFilterTestData struct {
-		name     string
-		events   []seaflog.Event
-		earliest time.Time
-		latest   time.Time
-		want     []seaflog.Event
-	}
-
-	tests := []timeFilterTestData{
-		{
-			name:     "no filter",
-			events:   events,
-			earliest: time.Time{},
-			latest:   time.Time{},
-			want:     events,
-		},
-		{
-			name:     "only earliest",
-			events:   events,
-			earliest: events[1].Time,
-			latest:   time.Time{},
-			want:     events[1:],
-		},
+func TestReportFiltering(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbosity string
+		suppress  string
+		m         seaflog.Message
+		want      bool
+	}{
+		{"warning at default", seaflog.LevelWarning, "", seaflog.Message{Level: seaflog.LevelWarning}, true},
+		{"info at default", seaflog.LevelWarning, "", seaflog.Message{Level: seaflog.LevelInfo}, false},
+		{"info at info", seaflog.LevelInfo, "", seaflog.Message{Level: seaflog.LevelInfo}, true},
+		{"warning at error", seaflog.LevelError, "", seaflog.Message{Level: seaflog.LevelWarning}, false},
+		{"error at quiet", seaflog.VerbosityQuiet, "", seaflog.Message{Level: seaflog.LevelError}, false},
+		{"unknown level as warning", seaflog.LevelWarning, "", seaflog.Message{Level: "notice"}, true},
 		{
-			name:     "only latest",
-			events:   events,
-			earliest: time.Time{},
-			latest:   events[1].Time,
-			want:     events[:2],
+			"suppressed kind", seaflog.LevelInfo, "unrecognized",
+			seaflog.Message{Level: seaflog.LevelWarning, ErrorKind: "unrecognized"}, false,
 		},
 		{
-			name:     "both earliest and latest",
-			events:   events,
-			earliest: events[1].Time,
-			latest:   events[3].Time,
-			want:     events[1:4],
+			"other kind", seaflog.LevelInfo, "unrecognized",
+			seaflog.Message{Level: seaflog.LevelWarning, ErrorKind: "bad_float"}, true,
 		},
 	}
-
+	defer seaflog.Log.SetOutput(os.Stderr)
+	defer seaflog.SetVerbosity(seaflog.LevelWarning)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			want, got := []string{}, []string{}
-			for _, e := range tt.events {
-				if seaflog.TimeFilter(e, tt.earliest, tt.latest) {
-					got = append(got, e.Time.Format(time.RFC3339))
-				}
+			var buf bytes.Buffer
+			seaflog.Log.SetOutput(&buf)
+			if err := seaflog.SetVerbosity(tt.verbosity); err != nil {
+				t.Fatalf("SetVerbosity() error = %v; want nil", err)
 			}
-			for _, w := range tt.want {
-				want = append(want, w.Time.Format(time.RFC3339))
+			if tt.suppress != "" {
+				seaflog.Suppress(tt.suppress, true)
+				defer seaflog.Suppress(tt.suppress, false)
+			}
+			tt.m.Message = "test"
+			seaflog.Report(tt.m)
+			if got := buf.Len() > 0; got != tt.want {
+				t.Errorf("Report() wrote %v; want %v", got, tt.want)
 			}
-			stringsEqual(got, want, t)
 		})
 	}
-}
-
-func eventsEqual(got, want seaflog.Event, t *testing.T) {
-	if got.Name != want.Name {
-		t.Errorf("Event.Name %v; want %v", got.Name, want.Name)
-	}
-	if got.Type != want.Type {
-		t.Errorf("Event.Type %v; want %v", got.Type, want.Type)
-	}
-	if got.Line != want.Line {
-		t.Errorf("Event.Line %v; want %v", got.Line, want.Line)
-	}
-	if got.Value != want.Value {
-		t.Errorf("Event.Value %v; want %v", got.Value, want.Value)
-	}
-	if got.LineNumber != want.LineNumber {
-		t.Errorf("Event.LineNumber %v; want %v", got.LineNumber, want.LineNumber)
-	}
-	if got.Error != nil && want.Error == nil {
-		t.Errorf("Event.Error %v; want %v", got.Error, want.Error)
-	}
-	if got.Error == nil && want.Error != nil {
-		// Don't care what error the test defines, just want an error
-		t.Errorf("Event.Error %v; want an error", got.Error)
-	}
-	if !got.Time.Equal(want.Time) {
-		t.Errorf("Event.Time %v; want %v", got.Time, want.Time)
+	if err := seaflog.SetVerbosity("loud"); err == nil {
+		t.Errorf("SetVerbosity(\"loud\") error = nil; want error")
 	}
 }
 
-func stringsEqual(got, want []string, t *testing.T) {
+func TestLimitRepeats(t *testing.T) {
+	var buf bytes.Buffer
+	seaflog.Log.SetOutput(&buf)
+	defer seaflog.Log.SetOutput(os.Stderr)
+	if err := seaflog.SetLogFormat(seaflog.LogFormatJSON); err != nil {
+		t.Fatalf("SetLogFormat() error = %v; want nil", err)
+	}
+	defer seaflog.SetLogFormat(seaflog.LogFormatText)
+	seaflog.LimitRepeats(2, 0)
+	defer seaflog.LimitRepeats(0, 0)
+
+	m := seaflog.Message{Level: seaflog.LevelWarning, ErrorKind: "repeat_test", Message: "same"}
+	for i := 1; i <= 5; i++ {
+		m.Line = i
+		seaflog.Report(m)
+	}
+	seaflog.Report(seaflog.Message{Level: seaflog.LevelWarning, ErrorKind: "repeat_test", Message: "other"})
+	seaflog.FlushRepeats()
+	seaflog.FlushRepeats() // nothing new to summarize
+
+	want := []string{
+		`{"level":"warning","line":1,"error_kind":"repeat_test","message":"same"}`,
+		`{"level":"warning","line":2,"error_kind":"repeat_test","message":"same"}`,
+		`{"level":"warning","error_kind":"repeat_test","message":"other"}`,
+		`{"level":"warning","error_kind":"repeat_test","message":"same x3 (suppressed)","suppressed":3}`,
+	}
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
 	if len(got) != len(want) {
-		t.Fatalf("len(got) %v: len(want) %v", len(got), len(want))
+		t.Fatalf("Report() wrote %q; want %q", got, want)
 	}
 	for i := range got {
 		if got[i] != want[i] {
-			t.Fatalf("got[i] %v; want[i] %v", got[i], want[i])
+			t.Errorf("line %d = %s; want %s", i, got[i], want[i])
 		}
 	}
 }
@@ -0,0 +1,132 @@
+package seaflogtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2"
+)
+
+// Corpus case files. A case is a directory holding an input log, an optional
+// config, and the expected output.
+const (
+	InputFile    = "input.log"
+	ConfigFile   = "config.json"
+	ExpectedFile = "expected" // with the output format as extension
+)
+
+// UpdateEnv is the environment variable which, set to 1, makes RunCorpus
+// write expected outputs instead of comparing them, to add or update cases.
+const UpdateEnv = "SEAFLOGTEST_UPDATE"
+
+// Config configures a conversion, like seaflog's command line flags.
+type Config struct {
+	Filetype    string `json:"filetype"`
+	Project     string `json:"project"`
+	Description string `json:"description"`
+	Format      string `json:"format"` // tsdata or csv
+	TimeFormat  string `json:"time_format"`
+	NA          string `json:"na"` // csv only
+}
+
+// DefaultConfig returns the config of a corpus case without a config file.
+func DefaultConfig() Config {
+	return Config{Filetype: "SeaFlowV1InstrumentLog", Project: "test", Format: "tsdata"}
+}
+
+// Convert converts the log in r to w the way seaflog does without optional
+// flags: unrecognized events become notes and events with errors are dropped.
+func Convert(r io.Reader, w io.Writer, config Config) error {
+	c, err := seaflog.NewConverter(seaflog.ConverterConfig{
+		Filetype:    config.Filetype,
+		Project:     config.Project,
+		Description: config.Description,
+		Format:      config.Format,
+		TimeFormat:  config.TimeFormat,
+		NA:          config.NA,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.Convert(r, w)
+	return err
+}
+
+// RunCorpus runs every case directory in dir as a subtest named for the
+// directory, converting its input with its config and comparing the result to
+// its expected output. Cases can be added without writing Go code by creating
+// a directory with an input log and config, then running the tests once with
+// SEAFLOGTEST_UPDATE=1 and reviewing the expected output written.
+func RunCorpus(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := os.Getenv(UpdateEnv) == "1"
+	n := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		n++
+		caseDir := filepath.Join(dir, entry.Name())
+		t.Run(entry.Name(), func(t *testing.T) {
+			config, err := readConfig(caseDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			input, err := ioutil.ReadFile(filepath.Join(caseDir, InputFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			if err := Convert(bytes.NewReader(input), &got, config); err != nil {
+				t.Fatal(err)
+			}
+			expectedPath := filepath.Join(caseDir, ExpectedFile+"."+config.Format)
+			if update {
+				if err := ioutil.WriteFile(expectedPath, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatalf("%v, set %s=1 to create it", err, UpdateEnv)
+			}
+			if d := Diff(got.Bytes(), want); d != "" {
+				t.Errorf("output differs from %s, %s", expectedPath, d)
+			}
+		})
+	}
+	if n == 0 {
+		t.Fatalf("no corpus cases in %s", dir)
+	}
+}
+
+// readConfig reads the config of the case in dir, with defaults for missing
+// fields.
+func readConfig(dir string) (Config, error) {
+	config := DefaultConfig()
+	b, err := ioutil.ReadFile(filepath.Join(dir, ConfigFile))
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return config, fmt.Errorf("invalid %s, %v", filepath.Join(dir, ConfigFile), err)
+	}
+	if config.Format == "" {
+		config.Format = "tsdata"
+	}
+	return config, nil
+}
@@ -0,0 +1,49 @@
+// Package seaflogtest provides helpers for testing code which converts
+// SeaFlow instrument logs.
+package seaflogtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// Repro runs convert twice and fails t if the two outputs differ, to catch
+// nondeterminism such as output which depends on map iteration order.
+func Repro(t testing.TB, convert func(w io.Writer) error) {
+	t.Helper()
+	var first, second bytes.Buffer
+	if err := convert(&first); err != nil {
+		t.Fatalf("first conversion: %v", err)
+	}
+	if err := convert(&second); err != nil {
+		t.Fatalf("second conversion: %v", err)
+	}
+	if d := Diff(second.Bytes(), first.Bytes()); d != "" {
+		t.Errorf("conversion is not reproducible, %s", d)
+	}
+}
+
+// Diff describes the first line where got and want differ, or returns "" if
+// they are equal.
+func Diff(got, want []byte) string {
+	if bytes.Equal(got, want) {
+		return ""
+	}
+	gotLines := bytes.SplitAfter(got, []byte("\n"))
+	wantLines := bytes.SplitAfter(want, []byte("\n"))
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w []byte
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if !bytes.Equal(g, w) {
+			return fmt.Sprintf("line %d is %q; want %q", i+1, g, w)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package seaflogtest_test
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/seaflogtest"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestRepro(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.05\nnot a real event data line\n"
+	seaflogtest.Repro(t, func(w io.Writer) error {
+		tw := writer.NewTsdataWriter("test", "test", "")
+		fmt.Fprintln(w, tw.HeaderText())
+		es := scanner.NewEventScanner(strings.NewReader(input))
+		for es.Scan() {
+			line, err := tw.EventText(es.Event())
+			if err != nil {
+				continue
+			}
+			fmt.Fprintln(w, line)
+		}
+		return es.Err()
+	})
+	// Event definition names in sorted order, the fix for map ordering
+	seaflogtest.Repro(t, func(w io.Writer) error {
+		names := []string{}
+		for name := range defs.EventDefs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		_, err := fmt.Fprintln(w, strings.Join(names, "\t"))
+		return err
+	})
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+		diff string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"empty", "", "", ""},
+		{"changed line", "a\nc\n", "a\nb\n", `line 2 is "c\n"; want "b\n"`},
+		{"missing line", "a\n", "a\nb\n", `line 2 is ""; want "b\n"`},
+		{"extra line", "a\nb\n", "a\n", `line 2 is "b\n"; want ""`},
+		{"missing newline", "a", "a\n", `line 1 is "a"; want "a\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := seaflogtest.Diff([]byte(tt.got), []byte(tt.want)); got != tt.diff {
+				t.Errorf("Diff() = %q; want %q", got, tt.diff)
+			}
+		})
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	seaflogtest.RunCorpus(t, "testdata/corpus")
+}
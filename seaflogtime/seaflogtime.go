@@ -0,0 +1,125 @@
+// Package seaflogtime parses and formats the timestamps of SeaFlow instrument
+// log files, e.g. 2015-03-14T00-26-52+00-00, for tools which handle SeaFlow
+// files without parsing events.
+package seaflogtime
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNotTimestamp is returned for text which is not a SeaFlow timestamp.
+var ErrNotTimestamp = errors.New("not a timestamp line")
+
+// Match log file timestamp, e.g. "2015-03-14T00-26-52+00-00", optionally with
+// fractional seconds, e.g. "2015-03-14T00-26-52.250+00-00"
+var timeExpr = regexp.MustCompile(
+	`^(?P<date>\d{4}-\d{2}-\d{2})T(?P<h>\d{2})-(?P<m>\d{2})-(?P<s>\d{2})(?P<frac>\.\d+)?(?P<tzh>[+-]\d{2})-(?P<tzm>\d{2})$`,
+)
+
+// ParseSeaFlowTimestamp converts a SeaFlow timestamp to a time.Time. Leap
+// seconds, e.g. 2016-12-31T23-59-60+00-00, are clamped to the preceding
+// second, use IsLeapSecond to detect them.
+func ParseSeaFlowTimestamp(text string) (time.Time, error) {
+	m := timeExpr.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, ErrNotTimestamp
+	}
+	if IsLeapSecond(text) {
+		m[4] = "59"
+	}
+	t, err := time.Parse(time.RFC3339, fmt.Sprintf("%sT%s:%s:%s%s%s:%s", m[1], m[2], m[3], m[4], m[5], m[6], m[7]))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
+// IsLeapSecond reports whether text is a SeaFlow timestamp of a leap second.
+func IsLeapSecond(text string) bool {
+	m := timeExpr.FindStringSubmatch(text)
+	return m != nil && m[3] == "59" && m[4] == "60"
+}
+
+// FormatSeaFlowTimestamp formats t as a SeaFlow timestamp in t's time zone,
+// with fractional seconds only if t has them.
+func FormatSeaFlowTimestamp(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	frac := strings.TrimPrefix(t.Format(".999999999"), "0")
+	return fmt.Sprintf("%s%s%c%02d-%02d", t.Format("2006-01-02T15-04-05"), frac, sign, offset/3600, offset%3600/60)
+}
+
+// fileNameExpr matches the timestamp at the start of SeaFlow data file names.
+var fileNameExpr = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}(\.\d+)?[+-]\d{2}-\d{2}`)
+
+// ParseFileName parses the timestamp of a SeaFlow data file name, e.g.
+// 2014_185/2014-07-04T00-00-02+00-00.opp.gz, ignoring any directory and
+// extensions.
+func ParseFileName(name string) (time.Time, error) {
+	ts := fileNameExpr.FindString(path.Base(name))
+	if ts == "" {
+		return time.Time{}, ErrNotTimestamp
+	}
+	return ParseSeaFlowTimestamp(ts)
+}
+
+// ParseTime parses text as either a SeaFlow timestamp or an RFC3339
+// timestamp.
+func ParseTime(text string) (time.Time, error) {
+	if t, err := ParseSeaFlowTimestamp(text); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a SeaFlow or RFC3339 timestamp", text)
+	}
+	return t, nil
+}
+
+// Range is an inclusive time range. A zero Earliest or Latest leaves that end
+// unbounded.
+type Range struct {
+	Earliest time.Time
+	Latest   time.Time
+}
+
+// ParseRange parses the bounds of a Range with ParseTime, an empty string for
+// an unbounded end.
+func ParseRange(earliest string, latest string) (Range, error) {
+	var r Range
+	var err error
+	if earliest != "" {
+		if r.Earliest, err = ParseTime(earliest); err != nil {
+			return Range{}, err
+		}
+	}
+	if latest != "" {
+		if r.Latest, err = ParseTime(latest); err != nil {
+			return Range{}, err
+		}
+	}
+	if !r.Earliest.IsZero() && !r.Latest.IsZero() && r.Latest.Before(r.Earliest) {
+		return Range{}, fmt.Errorf("time range ends at %s before it starts at %s", latest, earliest)
+	}
+	return r, nil
+}
+
+// Contains reports whether t lies within r.
+func (r Range) Contains(t time.Time) bool {
+	return (r.Earliest.IsZero() || !t.Before(r.Earliest)) && (r.Latest.IsZero() || !t.After(r.Latest))
+}
+
+// Overlaps reports whether r and o share any time.
+func (r Range) Overlaps(o Range) bool {
+	return (r.Latest.IsZero() || o.Earliest.IsZero() || !o.Earliest.After(r.Latest)) &&
+		(r.Earliest.IsZero() || o.Latest.IsZero() || !o.Latest.Before(r.Earliest))
+}
@@ -0,0 +1,121 @@
+package seaflogtime_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/seaflogtime"
+)
+
+func TestParseSeaFlowTimestamp(t *testing.T) {
+	tests := []struct {
+		text     string
+		want     time.Time
+		wantLeap bool
+		wantErr  bool
+	}{
+		{"2015-03-14T00-26-52+00-00", time.Date(2015, 3, 14, 0, 26, 52, 0, time.UTC), false, false},
+		{"2015-03-14T00-26-52.250+00-00", time.Date(2015, 3, 14, 0, 26, 52, 250e6, time.UTC), false, false},
+		{"2015-03-14T09-56-52+09-30", time.Date(2015, 3, 14, 0, 26, 52, 0, time.UTC), false, false},
+		{"2016-12-31T23-59-60+00-00", time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC), true, false},
+		{"2015-03-14T00:26:52+00:00", time.Time{}, false, true},
+		{"2015-13-14T00-26-52+00-00", time.Time{}, false, true},
+		{"PMT1:1.05", time.Time{}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := seaflogtime.ParseSeaFlowTimestamp(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSeaFlowTimestamp() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseSeaFlowTimestamp() = %v; want %v", got, tt.want)
+			}
+			if leap := seaflogtime.IsLeapSecond(tt.text); leap != tt.wantLeap {
+				t.Errorf("IsLeapSecond() = %v; want %v", leap, tt.wantLeap)
+			}
+		})
+	}
+	if _, err := seaflogtime.ParseSeaFlowTimestamp("note"); !errors.Is(err, seaflogtime.ErrNotTimestamp) {
+		t.Errorf("ParseSeaFlowTimestamp() error = %v; want ErrNotTimestamp", err)
+	}
+}
+
+func TestParseFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2014-07-04T00-00-02+00-00", time.Date(2014, 7, 4, 0, 0, 2, 0, time.UTC), false},
+		{"2014_185/2014-07-04T00-00-02+00-00.opp.gz", time.Date(2014, 7, 4, 0, 0, 2, 0, time.UTC), false},
+		{"2014-07-04T00-00-02.5+00-00.1.evt", time.Date(2014, 7, 4, 0, 0, 2, 5e8, time.UTC), false},
+		{"2014_185/42.evt", time.Time{}, true},
+		{"x2014-07-04T00-00-02+00-00.gz", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seaflogtime.ParseFileName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFileName() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseFileName() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSeaFlowTimestamp(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{time.Date(2015, 3, 14, 0, 26, 52, 0, time.UTC), "2015-03-14T00-26-52+00-00"},
+		{time.Date(2015, 3, 14, 0, 26, 52, 250e6, time.UTC), "2015-03-14T00-26-52.25+00-00"},
+		{time.Date(2015, 3, 14, 0, 26, 52, 0, time.FixedZone("", -(9*3600+30*60))), "2015-03-14T00-26-52-09-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := seaflogtime.FormatSeaFlowTimestamp(tt.t)
+			if got != tt.want {
+				t.Fatalf("FormatSeaFlowTimestamp() = %v; want %v", got, tt.want)
+			}
+			back, err := seaflogtime.ParseSeaFlowTimestamp(got)
+			if err != nil || !back.Equal(tt.t) {
+				t.Errorf("ParseSeaFlowTimestamp(%q) = %v, %v; want %v", got, back, err, tt.t)
+			}
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	r, err := seaflogtime.ParseRange("2015-03-14T00-00-00+00-00", "2015-03-14T01:00:00Z")
+	if err != nil {
+		t.Fatalf("ParseRange() error = %v; want nil", err)
+	}
+	at := func(h, m int) time.Time { return time.Date(2015, 3, 14, h, m, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(0, 0), true}, {at(0, 30), true}, {at(1, 0), true}, {at(1, 1), false}, {at(0, 0).Add(-time.Second), false},
+	} {
+		if got := r.Contains(tt.t); got != tt.want {
+			t.Errorf("Contains(%v) = %v; want %v", tt.t, got, tt.want)
+		}
+	}
+	if !r.Overlaps(seaflogtime.Range{Earliest: at(1, 0)}) || r.Overlaps(seaflogtime.Range{Latest: at(0, 0).Add(-time.Second)}) {
+		t.Errorf("Overlaps() wrong for ranges touching the ends")
+	}
+	if !(seaflogtime.Range{}).Contains(at(5, 0)) {
+		t.Errorf("unbounded Range.Contains() = false; want true")
+	}
+	if _, err := seaflogtime.ParseRange("2015-03-14T01-00-00+00-00", "2015-03-14T00-00-00+00-00"); err == nil {
+		t.Errorf("ParseRange() of reversed range error = nil; want error")
+	}
+	if _, err := seaflogtime.ParseRange("yesterday", ""); err == nil {
+		t.Errorf("ParseRange() of bad time error = nil; want error")
+	}
+}
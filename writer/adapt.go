@@ -0,0 +1,103 @@
+package writer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Metadata returns a copy of the TSDATA metadata of t's columns, for programs
+// which build on the tsdata library.
+func (t TsdataWriter) Metadata() tsdata.Tsdata {
+	m := t.written()
+	m.Headers = append([]string(nil), m.Headers...)
+	m.Types = append([]string(nil), m.Types...)
+	m.Comments = append([]string(nil), m.Comments...)
+	m.Units = append([]string(nil), m.Units...)
+	return m
+}
+
+// EventFields returns the TSDATA fields of one Event, one per column of
+// Metadata with tsdata.NA for missing values, without joining them into a
+// line. Events with errors have no fields.
+func (t TsdataWriter) EventFields(event defs.Event) ([]string, error) {
+	if event.Error != nil {
+		return nil, nil
+	}
+	return t.eventFields(event, tsdata.NA)
+}
+
+// FieldsToEvents converts the fields of one TSDATA row with metadata m back
+// into events, one for each event column with a value. The time column must
+// be RFC3339. The seq, source, line, and instrument columns fill in the
+// matching Event fields, other columns which aren't events are ignored.
+func FieldsToEvents(m tsdata.Tsdata, fields []string) ([]defs.Event, error) {
+	if len(fields) != len(m.Headers) {
+		return nil, fmt.Errorf("row has %d fields, want %d", len(fields), len(m.Headers))
+	}
+	var base defs.Event
+	var err error
+	for i, name := range m.Headers {
+		text := fields[i]
+		if text == tsdata.NA {
+			continue
+		}
+		switch name {
+		case "time":
+			if base.Time, err = time.Parse(time.RFC3339Nano, text); err != nil {
+				return nil, fmt.Errorf("bad time %q, want RFC3339", text)
+			}
+		case "seq":
+			if base.Seq, err = strconv.Atoi(text); err != nil {
+				return nil, fmt.Errorf("bad seq %q", text)
+			}
+		case "line":
+			if base.LineNumber, err = strconv.Atoi(text); err != nil {
+				return nil, fmt.Errorf("bad line %q", text)
+			}
+		case "source":
+			base.Source = text
+		case "instrument":
+			base.Instrument = text
+		}
+	}
+
+	events := []defs.Event{}
+	for i, name := range m.Headers {
+		text := fields[i]
+		if i == 0 || text == tsdata.NA {
+			continue
+		}
+		edef, ok := defs.Lookup(name)
+		if !ok {
+			continue
+		}
+		event := base
+		event.Name = name
+		event.Type = edef.Type
+		switch m.Types[i] {
+		case "float":
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad float %q for column %s", text, name)
+			}
+			event.Value = f
+		case "boolean":
+			switch text {
+			case "TRUE":
+				event.Value = true
+			case "FALSE":
+				event.Value = false
+			default:
+				return nil, fmt.Errorf("bad boolean %q for column %s", text, name)
+			}
+		default:
+			event.Value = text
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
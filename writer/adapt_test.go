@@ -0,0 +1,60 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestFieldsToEvents(t *testing.T) {
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.05\nStream pressure locked.\nCruise Name: HOT227\n"
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.AddProvenanceColumns()
+	w.AddSeqColumn()
+	m := w.Metadata()
+	m.Headers[0] = "changed" // must not change the writer
+	if w.Metadata().Headers[0] != "time" {
+		t.Fatalf("Metadata() shares slices with the writer")
+	}
+	m = w.Metadata()
+
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	scanner.SourceName("SFlog_740.txt")
+	n := 0
+	for scanner.Scan() {
+		event := scanner.Event()
+		if event.Error != nil {
+			t.Fatalf("event error = %v; want nil", event.Error)
+		}
+		fields, err := w.EventFields(event)
+		if err != nil {
+			t.Fatalf("EventFields() error = %v; want nil", err)
+		}
+		line, _ := w.EventText(event)
+		if strings.Join(fields, "\t") != line {
+			t.Errorf("EventFields() = %q; want fields of %q", fields, line)
+		}
+		got, err := writer.FieldsToEvents(m, fields)
+		if err != nil {
+			t.Fatalf("FieldsToEvents() error = %v; want nil", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("len(FieldsToEvents()) = %v; want 1", len(got))
+		}
+		g := got[0]
+		if g.Name != event.Name || g.Type != event.Type || g.Value != event.Value || !g.Time.Equal(event.Time) ||
+			g.Seq != event.Seq || g.Source != event.Source || g.LineNumber != event.LineNumber {
+			t.Errorf("FieldsToEvents() = %+v; want %+v", g, event)
+		}
+		n++
+	}
+	if n != 4 { // including a restart before the banner line
+		t.Errorf("converted %d events; want 4", n)
+	}
+
+	if _, err := writer.FieldsToEvents(m, []string{"2015-03-14T00:26:52+00:00"}); err == nil {
+		t.Errorf("FieldsToEvents() of short row error = nil; want error")
+	}
+}
@@ -0,0 +1,64 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// CSVWriter writes events as CSV with the same columns as TsdataWriter, but
+// with a single header line of column names and a configurable missing value
+// token.
+type CSVWriter struct {
+	TsdataWriter
+	na string
+}
+
+// NewCSVWriter creates a new CSVWriter struct. Missing values are written as
+// empty fields until changed with SetNA.
+func NewCSVWriter(fileType string, project string, description string) CSVWriter {
+	return CSVWriter{TsdataWriter: NewTsdataWriter(fileType, project, description)}
+}
+
+// SetNA sets the token written for missing values, e.g. "", "NaN", or "NULL".
+func (w *CSVWriter) SetNA(na string) {
+	w.na = na
+}
+
+// SetTimeFormat sets the format of the time column, either one of the
+// TimeFormat* names or a custom Go time layout.
+func (w *CSVWriter) SetTimeFormat(format string) error {
+	if err := ValidateTimeFormat(format); err != nil {
+		return err
+	}
+	w.TsdataWriter.setTimeFormat(format)
+	return nil
+}
+
+// HeaderText returns a CSV header line of column names.
+func (w CSVWriter) HeaderText() string {
+	return csvLine(w.written().Headers)
+}
+
+// EventText returns a CSV line string for one Event
+func (w CSVWriter) EventText(event defs.Event) (string, error) {
+	if event.Error != nil {
+		return "", nil
+	}
+	outs, err := w.eventFields(event, w.na)
+	if err != nil {
+		return "", err
+	}
+	return csvLine(outs), nil
+}
+
+// csvLine encodes fields as one CSV line without a trailing newline.
+func csvLine(fields []string) string {
+	var b bytes.Buffer
+	cw := csv.NewWriter(&b)
+	_ = cw.Write(fields) // can't fail writing to a bytes.Buffer
+	cw.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,28 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestCSVWriter(t *testing.T) {
+	w := writer.NewCSVWriter("test", "test", "")
+	w.SetNA("NULL")
+	if header := w.HeaderText(); !strings.HasPrefix(header, "time,PMT1,PMT2,") {
+		t.Errorf("HeaderText() = %v; want prefix time,PMT1,PMT2,", header)
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nPMT1:1.05\n"))
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		if want := "2015-03-14T00:26:52+00:00,1.05,NULL,"; !strings.HasPrefix(line, want) {
+			t.Errorf("EventText() = %v; want prefix %v", line, want)
+		}
+	}
+}
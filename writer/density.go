@@ -0,0 +1,68 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+// Density formats.
+const (
+	DensityCSV  = "csv"
+	DensityJSON = "json"
+)
+
+// densityMatrix is the JSON form of event density, with one row of counts per
+// event name and one column per time bucket, as heatmap plotting libraries
+// expect.
+type densityMatrix struct {
+	Times  []string `json:"times"`
+	Events []string `json:"events"`
+	Counts [][]int  `json:"counts"`
+}
+
+// Density returns event density cells, as from pipeline.Density.Cells, in a
+// density format. DensityCSV has one time,event,count row per cell,
+// DensityJSON is a matrix of counts by event name and time. Times are RFC3339
+// bucket starts.
+func Density(format string, cells []pipeline.DensityCell) ([]byte, error) {
+	var b bytes.Buffer
+	switch format {
+	case DensityCSV:
+		w := csv.NewWriter(&b)
+		w.Write([]string{"time", "event", "count"})
+		for _, cell := range cells {
+			w.Write([]string{FormatTime(cell.Start, TimeFormatRFC3339), cell.Name, strconv.Itoa(cell.Count)})
+		}
+		w.Flush()
+		return b.Bytes(), w.Error()
+	case DensityJSON:
+		m := densityMatrix{Times: []string{}, Events: []string{}, Counts: [][]int{}}
+		row := make(map[string]int)
+		for _, cell := range cells {
+			if n := len(m.Times); n == 0 || m.Times[n-1] != FormatTime(cell.Start, TimeFormatRFC3339) {
+				m.Times = append(m.Times, FormatTime(cell.Start, TimeFormatRFC3339))
+			}
+			i, ok := row[cell.Name]
+			if !ok {
+				i = len(m.Events)
+				row[cell.Name] = i
+				m.Events = append(m.Events, cell.Name)
+				m.Counts = append(m.Counts, make([]int, len(m.Times)-1))
+			}
+			m.Counts[i] = append(m.Counts[i], cell.Count)
+		}
+		enc := json.NewEncoder(&b)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown density format %q", format)
+	}
+}
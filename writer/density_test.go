@@ -0,0 +1,71 @@
+package writer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestDensity(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	t1 := t0.Add(time.Hour)
+	cells := []pipeline.DensityCell{
+		{Start: t0, Name: "PMT1", Count: 2},
+		{Start: t0, Name: "error", Count: 0},
+		{Start: t1, Name: "PMT1", Count: 0},
+		{Start: t1, Name: "error", Count: 1},
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			writer.DensityCSV,
+			"time,event,count\n" +
+				"2015-03-14T00:00:00+00:00,PMT1,2\n" +
+				"2015-03-14T00:00:00+00:00,error,0\n" +
+				"2015-03-14T01:00:00+00:00,PMT1,0\n" +
+				"2015-03-14T01:00:00+00:00,error,1\n",
+		},
+		{
+			writer.DensityJSON,
+			`{
+  "times": [
+    "2015-03-14T00:00:00+00:00",
+    "2015-03-14T01:00:00+00:00"
+  ],
+  "events": [
+    "PMT1",
+    "error"
+  ],
+  "counts": [
+    [
+      2,
+      0
+    ],
+    [
+      0,
+      1
+    ]
+  ]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := writer.Density(tt.format, cells)
+			if err != nil {
+				t.Fatalf("Density() error = %v; want nil", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Density() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := writer.Density("png", cells); err == nil {
+		t.Errorf("Density(png) error = nil; want error")
+	}
+}
@@ -0,0 +1,45 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+// FileSettingsCSV returns a CSV table with one row per data file, with the
+// file name, file time in RFC3339, and a column for each of the settings
+// names. Missing settings are written as na.
+func FileSettingsCSV(rows []pipeline.FileSettings, names []string, na string) string {
+	var b strings.Builder
+	b.WriteString(csvLine(append([]string{"file", "time"}, names...)))
+	b.WriteString("\n")
+	for _, row := range rows {
+		fields := []string{row.File.Name, FormatTime(row.File.Time, TimeFormatRFC3339)}
+		for _, name := range names {
+			fields = append(fields, settingText(name, row.Values[name], na))
+		}
+		b.WriteString(csvLine(fields))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// settingText formats a setting value like the TSDATA value of its event.
+func settingText(name string, v interface{}, na string) string {
+	switch v := v.(type) {
+	case nil:
+		return na
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float64:
+		if edef, _ := defs.Lookup(name); edef.FloatFormat != "" {
+			return fmt.Sprintf(edef.FloatFormat, v)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,26 @@
+package writer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestFileSettingsCSV(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	rows := []pipeline.FileSettings{
+		{File: pipeline.DataFile{Name: "2015-03-14T00-00-00+00-00.evt", Time: t0}, Values: map[string]interface{}{}},
+		{
+			File:   pipeline.DataFile{Name: "a,b", Time: t0.Add(time.Minute)},
+			Values: map[string]interface{}{"PMT1": 1.1, "stream_pressure_locked": true},
+		},
+	}
+	want := "file,time,PMT1,stream_pressure_locked\n" +
+		"2015-03-14T00-00-00+00-00.evt,2015-03-14T00:00:00+00:00,NA,NA\n" +
+		"\"a,b\",2015-03-14T00:01:00+00:00,1.1,TRUE\n"
+	if got := writer.FileSettingsCSV(rows, []string{"PMT1", "stream_pressure_locked"}, "NA"); got != want {
+		t.Errorf("FileSettingsCSV() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ctberthiaume/tsdata"
+)
+
+// Fix kinds.
+const (
+	FixNA        = "na"        // missing value token replaced with NA
+	FixTime      = "time"      // time reformatted as RFC3339
+	FixBoolean   = "boolean"   // boolean value upper cased
+	FixDelimiter = "delimiter" // tabs in a text value replaced with spaces
+)
+
+// Fix is one change made to a line of a TSDATA file by FixLine.
+type Fix struct {
+	Kind   string
+	Column string
+	Old    string
+	New    string
+}
+
+func (f Fix) String() string {
+	return fmt.Sprintf("%s: %s %q -> %q", f.Column, f.Kind, f.Old, f.New)
+}
+
+// naTokens are missing value tokens written by other tools, or by seaflog
+// --format csv --na, in place of NA.
+var naTokens = map[string]bool{
+	"": true, "NaN": true, "nan": true, "NAN": true, "N/A": true, "n/a": true,
+	"na": true, "null": true, "NULL": true, "None": true, "none": true,
+}
+
+// fixLayouts are time layouts of malformed times, parsed in order. Times
+// without a time zone are read as UTC. Of time zone abbreviations only UTC and
+// GMT are matched, since time.Parse reads others, e.g. PST, as offset 0, so
+// those times are left for validation to report.
+var fixLayouts = []string{
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05 Z07:00",
+	"2006-01-02T15:04:05 Z0700",
+	"2006-01-02T15:04:05Z07",
+	"2006-01-02T15:04:05 UTC",
+	"2006-01-02T15:04:05 GMT",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05 Z0700",
+	"2006-01-02 15:04:05 UTC",
+	"2006-01-02 15:04:05 GMT",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// FixLine fixes common defects in a data line of a TSDATA file with metadata
+// m, as parsed by TsdataSpec.ParseHeader, e.g. from older or other tools. It
+// returns the fixed fields and every change made: missing value tokens other
+// than NA, times not formatted as RFC3339 with a numeric time zone, lower case
+// booleans, and text values containing the delimiter, which split them across
+// columns. Defects which can't be fixed are left for validation to report.
+func FixLine(m tsdata.Tsdata, line string) ([]string, []Fix) {
+	fields := strings.Split(line, tsdata.Delim)
+	fixes := []Fix{}
+	if extra := len(fields) - len(m.Headers); extra > 0 {
+		if joined, i, ok := joinText(m, fields, extra); ok {
+			fixes = append(fixes, Fix{
+				Kind:   FixDelimiter,
+				Column: m.Headers[i],
+				Old:    strings.Join(fields[i:i+extra+1], tsdata.Delim),
+				New:    joined[i],
+			})
+			fields = joined
+		}
+	}
+	if len(fields) != len(m.Headers) {
+		return fields, fixes
+	}
+	for i, v := range fields {
+		fixed := v
+		kind := ""
+		switch m.Types[i] {
+		case "text":
+			if v == "" {
+				fixed, kind = tsdata.NA, FixNA
+			}
+		case "time":
+			if naTokens[v] {
+				fixed, kind = tsdata.NA, FixNA
+			} else if t, ok := fixTime(v); ok {
+				fixed, kind = t, FixTime
+			}
+		case "boolean":
+			if naTokens[v] {
+				fixed, kind = tsdata.NA, FixNA
+			} else if u := strings.ToUpper(v); u == "TRUE" || u == "FALSE" {
+				fixed, kind = u, FixBoolean
+			}
+		default:
+			if naTokens[v] {
+				fixed, kind = tsdata.NA, FixNA
+			}
+		}
+		if fixed != v {
+			fixes = append(fixes, Fix{Kind: kind, Column: m.Headers[i], Old: v, New: fixed})
+			fields[i] = fixed
+		}
+	}
+	return fields, fixes
+}
+
+// fixTime returns v formatted as RFC3339 if it isn't already but is a time in
+// one of fixLayouts.
+func fixTime(v string) (string, bool) {
+	if _, err := time.Parse(time.RFC3339, v); err == nil {
+		return "", false
+	}
+	for _, layout := range fixLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return FormatTime(t, TimeFormatRFC3339Nano), true
+		}
+	}
+	return "", false
+}
+
+// joinText rejoins the fields of a line with extra fields, split at tabs in a
+// text value, into one text column, with the tabs replaced by spaces. Of the
+// text columns for which the line then has valid values in every column, it
+// picks the one joining the fewest NA fields, as most lines have a value in
+// only one column. It returns the joined fields and the index of the text
+// column.
+func joinText(m tsdata.Tsdata, fields []string, extra int) ([]string, int, bool) {
+	var best []string
+	bestIndex, bestNAs := 0, 0
+	for i, typ := range m.Types {
+		if typ != "text" {
+			continue
+		}
+		split := fields[i : i+extra+1]
+		joined := append([]string(nil), fields[:i]...)
+		joined = append(joined, strings.Join(split, " "))
+		joined = append(joined, fields[i+extra+1:]...)
+		if _, err := m.ValidateLine(strings.Join(joined, tsdata.Delim)); err != nil {
+			continue
+		}
+		nas := 0
+		for _, f := range split {
+			if f == tsdata.NA {
+				nas++
+			}
+		}
+		if best == nil || nas < bestNAs {
+			best, bestIndex, bestNAs = joined, i, nas
+		}
+	}
+	return best, bestIndex, best != nil
+}
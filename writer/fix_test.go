@@ -0,0 +1,63 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestFixLine(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	spec, _ := writer.LookupTsdataSpec(writer.DefaultTsdataVersion)
+	m, err := spec.ParseHeader(strings.Split(w.HeaderText(), "\n"))
+	if err != nil {
+		t.Fatalf("ParseHeader() error = %v; want nil", err)
+	}
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52Z")
+	lineOf := func(name string, value interface{}) string {
+		line, err := w.EventText(defs.Event{Name: name, Value: value, Time: t0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return line
+	}
+	pmt := lineOf("PMT1", 1.5)
+	note := lineOf("note", "hello tab")
+
+	tests := []struct {
+		name string
+		line string
+		want string
+		kind string
+	}{
+		{"na", strings.Replace(pmt, "\tNA", "\tNaN", 1), pmt, writer.FixNA},
+		{"empty", strings.Replace(pmt, "\tNA", "\t", 1), pmt, writer.FixNA},
+		{"no zone", strings.Replace(pmt, "2015-03-14T00:26:52+00:00", "2015-03-14 00:26:52", 1), pmt, writer.FixTime},
+		{"bad zone", strings.Replace(pmt, "+00:00", "+0000", 1), pmt, writer.FixTime},
+		{"utc abbreviation", strings.Replace(pmt, "+00:00", " UTC", 1), pmt, writer.FixTime},
+		{"other abbreviation", strings.Replace(pmt, "+00:00", " PST", 1), strings.Replace(pmt, "+00:00", " PST", 1), ""},
+		{"delimiter", strings.Replace(note, "hello tab", "hello\ttab", 1), note, writer.FixDelimiter},
+		{"good", pmt, pmt, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, fixes := writer.FixLine(*m, tt.line)
+			if got := strings.Join(fields, tsdata.Delim); got != tt.want {
+				t.Errorf("FixLine() = %q; want %q", got, tt.want)
+			}
+			if tt.kind == "" {
+				if len(fixes) != 0 {
+					t.Errorf("fixes = %v; want none", fixes)
+				}
+				return
+			}
+			if len(fixes) != 1 || fixes[0].Kind != tt.kind {
+				t.Errorf("fixes = %v; want one %s fix", fixes, tt.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package writer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+// IntervalsWriter writes Intervals in TSDATA file format, one interval per
+// line, for Gantt-style plots.
+type IntervalsWriter struct {
+	tsdata     tsdata.Tsdata
+	spec       TsdataSpec // nil for DefaultTsdataVersion
+	timeFormat string
+}
+
+// NewIntervalsWriter creates a new IntervalsWriter struct
+func NewIntervalsWriter(fileType string, project string, description string) IntervalsWriter {
+	w := IntervalsWriter{
+		tsdata: tsdata.Tsdata{
+			FileType:        fileType,
+			Project:         project,
+			FileDescription: description,
+			Headers:         []string{"time", "interval", "end", "duration"},
+			Types:           []string{"time", "text", "time", "float"},
+			Comments:        []string{"ISO8601 interval start timestamp", tsdata.NA, "ISO8601 interval end timestamp", tsdata.NA},
+			Units:           []string{tsdata.NA, tsdata.NA, tsdata.NA, "seconds"},
+		},
+		timeFormat: TimeFormatRFC3339,
+	}
+	if err := w.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// SetTimeFormat sets the format of the start and end columns,
+// TimeFormatRFC3339 or TimeFormatRFC3339Nano, the only formats TSDATA allows.
+func (w *IntervalsWriter) SetTimeFormat(format string) error {
+	if err := ValidateTsdataTimeFormat(format); err != nil {
+		return err
+	}
+	w.timeFormat = format
+	comment := strings.ReplaceAll(timeComment(format), tsdata.Delim, " ")
+	w.tsdata.Comments[0] = fmt.Sprintf("interval start, %s", comment)
+	w.tsdata.Comments[2] = fmt.Sprintf("interval end, %s", comment)
+	return nil
+}
+
+// SetSpec sets the TSDATA spec version the header is written for, by default
+// DefaultTsdataVersion.
+func (w *IntervalsWriter) SetSpec(spec TsdataSpec) {
+	w.spec = spec
+}
+
+// HeaderText returns a TSDATA header string
+func (w IntervalsWriter) HeaderText() string {
+	if w.spec != nil {
+		return w.spec.Header(w.tsdata)
+	}
+	return w.tsdata.Header()
+}
+
+// IntervalText returns a TSDATA line string for one Interval. Unfinished
+// intervals have NA end and duration.
+func (w IntervalsWriter) IntervalText(iv pipeline.Interval) string {
+	outs := []string{FormatTime(iv.Start, w.timeFormat), iv.Name, tsdata.NA, tsdata.NA}
+	if !iv.End.IsZero() {
+		outs[2] = FormatTime(iv.End, w.timeFormat)
+		outs[3] = strconv.FormatFloat(iv.Duration().Seconds(), 'f', -1, 64)
+	}
+	return strings.Join(outs, tsdata.Delim)
+}
@@ -0,0 +1,27 @@
+package writer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestIntervalText(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:00:00+00:00")
+	w := writer.NewIntervalsWriter("test", "test", "")
+	intervals := []pipeline.Interval{
+		{Name: "acquisition", Start: t0, End: t0.Add(20 * time.Minute)},
+		{Name: "acquisition", Start: t0.Add(30 * time.Minute)},
+	}
+	want := []string{
+		"2015-03-14T00:00:00+00:00\tacquisition\t2015-03-14T00:20:00+00:00\t1200",
+		"2015-03-14T00:30:00+00:00\tacquisition\tNA\tNA",
+	}
+	got := []string{}
+	for _, iv := range intervals {
+		got = append(got, w.IntervalText(iv))
+	}
+	stringsEqual(got, want, t)
+}
@@ -0,0 +1,144 @@
+package writer_test
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+// randomPayload returns a random valid value for a line of eform, to follow
+// its prefix, or false if lines of eform can't be generated.
+func randomPayload(r *rand.Rand, edef defs.EventDef, eform defs.EventForm) (string, bool) {
+	if eform.TimeFromValue != "" {
+		return "", false
+	}
+	pad := []string{"", " ", "  ", "\t"}
+	switch eform.ValueAction {
+	case "as_float":
+		var f float64
+		switch r.Intn(4) {
+		case 0:
+			f = float64(r.Intn(2000) - 1000)
+		case 1:
+			f = r.NormFloat64() * 100
+		case 2:
+			f = math.Float64frombits(r.Uint64())
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				f = 0
+			}
+		default:
+			f = r.Float64() * math.Pow(10, float64(r.Intn(40)-20))
+		}
+		if edef.Min != nil && f < *edef.Min {
+			f = *edef.Min
+		}
+		if edef.Max != nil && f > *edef.Max {
+			f = *edef.Max
+		}
+		for _, m := range edef.Missing {
+			if f == m {
+				f++
+			}
+		}
+		formats := []byte{'g', 'f', 'e'}
+		return pad[r.Intn(len(pad))] + strconv.FormatFloat(f, formats[r.Intn(len(formats))], -1, 64) + pad[r.Intn(len(pad))], true
+	case "as_text", "as_identity":
+		const chars = "abcXYZ019 .,:;-_/()\t#%é"
+		b := strings.Builder{}
+		for i := r.Intn(30); i > 0; i-- {
+			b.WriteString(string([]rune(chars)[r.Intn(len([]rune(chars)))]))
+		}
+		return b.String(), true
+	case "as_enum":
+		return pad[r.Intn(len(pad))] + edef.Values[r.Intn(len(edef.Values))] + pad[r.Intn(len(pad))], true
+	case "as_true", "as_false":
+		return "", true
+	}
+	return "", false
+}
+
+// lineFor returns a log line for edef holding the serialized value text, the
+// inverse of serialization.
+func lineFor(edef defs.EventDef, text string) string {
+	for _, eform := range edef.EventForms {
+		switch eform.ValueAction {
+		case "as_true":
+			if text == "TRUE" {
+				return eform.StartsWith
+			}
+		case "as_false":
+			if text == "FALSE" {
+				return eform.StartsWith
+			}
+		case "as_identity":
+			return text
+		default:
+			return eform.StartsWith + text
+		}
+	}
+	return ""
+}
+
+// TestRoundTrip checks random valid lines for every event definition parse as
+// that event, and that parse, serialize, parse is stable.
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	t0 := time.Date(2015, 3, 14, 0, 26, 52, 0, time.UTC)
+	w := writer.NewTsdataWriter("test", "test", "")
+	cols := map[string]int{}
+	for i, col := range w.Columns() {
+		cols[col.Name] = i
+	}
+	serialize := func(event defs.Event) string {
+		line, err := w.EventText(event)
+		if err != nil {
+			t.Fatalf("EventText(%q) error = %v", event.Line, err)
+		}
+		return strings.Split(line, "\t")[cols[event.Name]]
+	}
+
+	// Sorted so the random lines are the same every run
+	names := []string{}
+	for name := range defs.EventDefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		edef := defs.EventDefs[name]
+		for _, eform := range edef.EventForms {
+			for i := 0; i < 200; i++ {
+				payload, ok := randomPayload(r, edef, eform)
+				if !ok {
+					break
+				}
+				line := eform.StartsWith + payload
+				event, err := defs.CreateEvent(line, t0, 1)
+				if err != nil || event.Error != nil {
+					t.Fatalf("CreateEvent(%q) error %v, %v", line, err, event.Error)
+				}
+				if event.Name != edef.Name {
+					t.Fatalf("CreateEvent(%q) Name %v; want %v", line, event.Name, edef.Name)
+				}
+				text := serialize(event)
+				line2 := lineFor(edef, text)
+				event2, err := defs.CreateEvent(line2, t0, 1)
+				if err != nil || event2.Error != nil {
+					t.Fatalf("CreateEvent(%q) of serialized %q error %v, %v", line2, line, err, event2.Error)
+				}
+				if event2.Name != edef.Name {
+					t.Fatalf("CreateEvent(%q) of serialized %q Name %v; want %v", line2, line, event2.Name, edef.Name)
+				}
+				if text2 := serialize(event2); text2 != text {
+					t.Fatalf("serialized %q as %q, then %q as %q", line, text, line2, text2)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+)
+
+// Column describes one output column.
+type Column struct {
+	Name    string
+	Type    string // TSDATA type
+	Unit    string // empty if none
+	Comment string // empty if none
+	// Values lists the allowed values of a category column, from its event
+	// definition.
+	Values []string
+}
+
+// Columns returns the writer's output columns in order.
+func (t TsdataWriter) Columns() []Column {
+	m := t.written()
+	cols := make([]Column, len(m.Headers))
+	for i, name := range m.Headers {
+		cols[i] = Column{Name: name, Type: m.Types[i]}
+		if unit := m.Units[i]; unit != tsdata.NA {
+			cols[i].Unit = unit
+		}
+		if comment := m.Comments[i]; comment != tsdata.NA {
+			cols[i].Comment = comment
+		}
+		if edef, ok := defs.EventDefs[name]; ok && edef.Type == "category" {
+			cols[i].Values = edef.Values
+		}
+	}
+	return cols
+}
+
+// Schema formats.
+const (
+	SchemaJSONSchema = "jsonschema"
+	SchemaAvro       = "avro"
+)
+
+// Schema returns a machine-readable schema for output records with columns in
+// a schema format, either SchemaJSONSchema or SchemaAvro. Every column but
+// time may be null.
+func Schema(format string, name string, cols []Column) ([]byte, error) {
+	var schema interface{}
+	switch format {
+	case SchemaJSONSchema:
+		schema = jsonSchema(name, cols)
+	case SchemaAvro:
+		schema = avroSchema(name, cols)
+	default:
+		return nil, fmt.Errorf("unknown schema format %q", format)
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// orderedFields is a JSON object which keeps its keys in insertion order.
+type orderedFields struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedFields) set(key string, value interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o orderedFields) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+	for i, k := range o.keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b = append(append(append(b, kb...), ':'), vb...)
+	}
+	return append(b, '}'), nil
+}
+
+func jsonSchema(name string, cols []Column) interface{} {
+	props := orderedFields{}
+	for _, col := range cols {
+		prop := orderedFields{}
+		switch col.Type {
+		case "time":
+			prop.set("type", "string")
+			prop.set("format", "date-time")
+		case "float":
+			prop.set("type", []string{"number", "null"})
+		case "integer":
+			prop.set("type", []string{"integer", "null"})
+		case "boolean":
+			prop.set("type", []string{"boolean", "null"})
+		case "category":
+			prop.set("type", []string{"string", "null"})
+			enum := []interface{}{}
+			for _, v := range col.Values {
+				enum = append(enum, v)
+			}
+			prop.set("enum", append(enum, nil))
+		default:
+			prop.set("type", []string{"string", "null"})
+		}
+		if col.Comment != "" {
+			prop.set("description", col.Comment)
+		}
+		if col.Unit != "" {
+			prop.set("x-unit", col.Unit)
+		}
+		props.set(col.Name, prop)
+	}
+	schema := orderedFields{}
+	schema.set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	schema.set("title", name)
+	schema.set("type", "object")
+	schema.set("properties", props)
+	schema.set("required", []string{"time"})
+	schema.set("additionalProperties", false)
+	return schema
+}
+
+func avroSchema(name string, cols []Column) interface{} {
+	fields := make([]orderedFields, len(cols))
+	for i, col := range cols {
+		f := orderedFields{}
+		f.set("name", col.Name)
+		var typ string
+		switch col.Type {
+		case "time":
+			typ = "string"
+		case "float":
+			typ = "double"
+		case "integer":
+			typ = "long"
+		case "boolean":
+			typ = "boolean"
+		default:
+			typ = "string"
+		}
+		if col.Name == "time" {
+			f.set("type", typ)
+		} else {
+			f.set("type", []string{"null", typ})
+			f.set("default", nil)
+		}
+		doc := col.Comment
+		if col.Unit != "" {
+			if doc != "" {
+				doc += ", "
+			}
+			doc += "unit " + col.Unit
+		}
+		if doc != "" {
+			f.set("doc", doc)
+		}
+		fields[i] = f
+	}
+	schema := orderedFields{}
+	schema.set("type", "record")
+	schema.set("name", name)
+	schema.set("namespace", "seaflog")
+	schema.set("fields", fields)
+	return schema
+}
@@ -0,0 +1,62 @@
+package writer_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestSchema(t *testing.T) {
+	cols := writer.NewTsdataWriter("test", "test", "").Columns()
+
+	t.Run("jsonschema", func(t *testing.T) {
+		b, err := writer.Schema(writer.SchemaJSONSchema, "test", cols)
+		if err != nil {
+			t.Fatalf("Schema() error = %v; want nil", err)
+		}
+		var got struct {
+			Properties map[string]struct {
+				Type interface{}
+			}
+		}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v; want nil", err)
+		}
+		if len(got.Properties) != len(cols) {
+			t.Errorf("len(properties) = %v; want %v", len(got.Properties), len(cols))
+		}
+		if typ := got.Properties["time"].Type; typ != "string" {
+			t.Errorf("time type = %v; want string", typ)
+		}
+	})
+
+	t.Run("avro", func(t *testing.T) {
+		b, err := writer.Schema(writer.SchemaAvro, "test", cols)
+		if err != nil {
+			t.Fatalf("Schema() error = %v; want nil", err)
+		}
+		var got struct {
+			Type   string
+			Fields []struct {
+				Name string
+				Type interface{}
+			}
+		}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v; want nil", err)
+		}
+		if got.Type != "record" {
+			t.Errorf("type = %v; want record", got.Type)
+		}
+		if len(got.Fields) != len(cols) || got.Fields[1].Name != "PMT1" {
+			t.Errorf("fields = %v; want %v fields starting time, PMT1", got.Fields, len(cols))
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := writer.Schema("xml", "test", cols); err == nil {
+			t.Errorf("Schema() error = nil; want an error")
+		}
+	})
+}
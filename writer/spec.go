@@ -0,0 +1,135 @@
+package writer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ctberthiaume/tsdata"
+)
+
+// TsdataSpec is one version of the TSDATA file format specification, which
+// TsdataWriter and IntervalsWriter can target and ValidateTsdata can check
+// files against. Specs for new versions of the format can be added with
+// RegisterTsdataSpec.
+type TsdataSpec interface {
+	// Version returns the spec version, e.g. "1".
+	Version() string
+	// HeaderLines returns the number of lines in the header section.
+	HeaderLines() int
+	// Header returns the header section for a file with metadata m, without
+	// a trailing newline.
+	Header(m tsdata.Tsdata) string
+	// ParseHeader parses and checks the lines of a header section.
+	ParseHeader(lines []string) (*tsdata.Tsdata, error)
+	// ValidateLine checks one data line of a file with header m, as returned
+	// by ParseHeader.
+	ValidateLine(m *tsdata.Tsdata, line string) error
+}
+
+// DefaultTsdataVersion is the TSDATA spec version written unless another is
+// chosen.
+const DefaultTsdataVersion = "1"
+
+var (
+	specsMu sync.RWMutex
+	specs   = map[string]TsdataSpec{DefaultTsdataVersion: tsdataV1{}}
+)
+
+// RegisterTsdataSpec makes spec available by its version to LookupTsdataSpec,
+// replacing any spec already registered for the version.
+func RegisterTsdataSpec(spec TsdataSpec) {
+	specsMu.Lock()
+	defer specsMu.Unlock()
+	specs[spec.Version()] = spec
+}
+
+// LookupTsdataSpec returns the registered spec for version.
+func LookupTsdataSpec(version string) (TsdataSpec, error) {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+	spec, ok := specs[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown TSDATA spec version %q, want one of %s", version, strings.Join(tsdataVersions(), ", "))
+	}
+	return spec, nil
+}
+
+// tsdataVersions returns the registered spec versions in sorted order. The
+// caller must hold specsMu.
+func tsdataVersions() []string {
+	versions := make([]string, 0, len(specs))
+	for v := range specs {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// tsdataV1 is the original TSDATA spec, as implemented by the tsdata library:
+// a seven line header of file type, project, description, and column
+// comments, types, units, and names, then tab separated lines starting with an
+// RFC3339 time.
+type tsdataV1 struct{}
+
+func (tsdataV1) Version() string  { return "1" }
+func (tsdataV1) HeaderLines() int { return tsdata.HeaderSize }
+
+func (tsdataV1) Header(m tsdata.Tsdata) string {
+	return m.Header()
+}
+
+func (tsdataV1) ParseHeader(lines []string) (*tsdata.Tsdata, error) {
+	m := &tsdata.Tsdata{}
+	if err := m.ParseHeader(strings.Join(lines, "\n")); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (tsdataV1) ValidateLine(m *tsdata.Tsdata, line string) error {
+	_, err := m.ValidateLine(line)
+	return err
+}
+
+// SpecViolation is a line of a TSDATA file which doesn't conform to a spec.
+type SpecViolation struct {
+	Line int // line number, starting at 1
+	Err  error
+}
+
+func (v SpecViolation) String() string {
+	return fmt.Sprintf("line %d: %v", v.Line, v.Err)
+}
+
+// ValidateTsdata checks the TSDATA file read from r against spec, returning up
+// to max violations, 0 for no limit. A header which doesn't conform is the
+// only violation returned, as data lines can't be checked without it. err is
+// only set for errors reading r.
+func ValidateTsdata(r io.Reader, spec TsdataSpec, max int) (violations []SpecViolation, err error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	header := []string{}
+	for len(header) < spec.HeaderLines() && s.Scan() {
+		header = append(header, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	m, herr := spec.ParseHeader(header)
+	if herr != nil {
+		return []SpecViolation{{Line: 1, Err: fmt.Errorf("header, %v", herr)}}, nil
+	}
+	for i := len(header) + 1; s.Scan(); i++ {
+		if err := spec.ValidateLine(m, s.Text()); err != nil {
+			violations = append(violations, SpecViolation{Line: i, Err: err})
+			if max > 0 && len(violations) >= max {
+				break
+			}
+		}
+	}
+	return violations, s.Err()
+}
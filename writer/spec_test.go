@@ -0,0 +1,96 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+// upperSpec is a test spec which writes the file type in upper case.
+type upperSpec struct {
+	writer.TsdataSpec
+}
+
+func (upperSpec) Version() string { return "test-upper" }
+
+func (s upperSpec) Header(m tsdata.Tsdata) string {
+	m.FileType = strings.ToUpper(m.FileType)
+	return s.TsdataSpec.Header(m)
+}
+
+func TestValidateTsdata(t *testing.T) {
+	spec, err := writer.LookupTsdataSpec(writer.DefaultTsdataVersion)
+	if err != nil {
+		t.Fatalf("LookupTsdataSpec() error = %v; want nil", err)
+	}
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52Z")
+	event := defs.Event{Name: "PMT1", Value: 1.5, Time: t0}
+
+	for _, format := range []string{writer.TimeFormatRFC3339, writer.TimeFormatEpoch} {
+		t.Run(format, func(t *testing.T) {
+			w := writer.NewTsdataWriter("test", "test", "")
+			line, err := w.EventText(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// TsdataWriter only writes RFC3339, other tools may not
+			fields := strings.Split(line, tsdata.Delim)
+			fields[0] = writer.FormatTime(t0, format)
+			line = strings.Join(fields, tsdata.Delim)
+			file := w.HeaderText() + "\n" + line + "\n" + line + "\n"
+			got, err := writer.ValidateTsdata(strings.NewReader(file), spec, 1)
+			if err != nil {
+				t.Fatalf("ValidateTsdata() error = %v; want nil", err)
+			}
+			// Spec version 1 times are RFC3339
+			want := 0
+			if format == writer.TimeFormatEpoch {
+				want = 1
+			}
+			if len(got) != want {
+				t.Fatalf("ValidateTsdata() = %v; want %d violations", got, want)
+			}
+			if want > 0 && got[0].Line != tsdata.HeaderSize+1 {
+				t.Errorf("violation line = %v; want %v", got[0].Line, tsdata.HeaderSize+1)
+			}
+		})
+	}
+
+	t.Run("bad header", func(t *testing.T) {
+		got, err := writer.ValidateTsdata(strings.NewReader("test\ntest\n"), spec, 0)
+		if err != nil {
+			t.Fatalf("ValidateTsdata() error = %v; want nil", err)
+		}
+		if len(got) != 1 || got[0].Line != 1 {
+			t.Errorf("ValidateTsdata() = %v; want one header violation", got)
+		}
+	})
+}
+
+func TestTsdataSpecs(t *testing.T) {
+	if _, err := writer.LookupTsdataSpec("no-such-version"); err == nil {
+		t.Errorf("LookupTsdataSpec() error = nil; want an error")
+	}
+
+	v1, _ := writer.LookupTsdataSpec(writer.DefaultTsdataVersion)
+	writer.RegisterTsdataSpec(upperSpec{v1})
+	spec, err := writer.LookupTsdataSpec("test-upper")
+	if err != nil {
+		t.Fatalf("LookupTsdataSpec() error = %v; want nil", err)
+	}
+
+	w := writer.NewTsdataWriter("test", "test", "")
+	def := w.HeaderText()
+	w.SetSpec(v1)
+	if got := w.HeaderText(); got != def {
+		t.Errorf("spec version 1 HeaderText() = %q; want %q", got, def)
+	}
+	w.SetSpec(spec)
+	if got := w.HeaderText(); !strings.HasPrefix(got, "TEST\ntest\n") {
+		t.Errorf("HeaderText() = %q; want upper case file type", got)
+	}
+}
@@ -0,0 +1,154 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+// Timeline formats.
+const (
+	TimelineICal    = "ical"
+	TimelineMermaid = "mermaid"
+)
+
+// Timeline returns an activity timeline of intervals and point events, e.g.
+// faults, in a timeline format, either TimelineICal or TimelineMermaid.
+// Intervals which were never stopped end at end.
+func Timeline(format string, title string, intervals []pipeline.Interval, points []defs.Event, end time.Time) ([]byte, error) {
+	switch format {
+	case TimelineICal:
+		return icalTimeline(title, intervals, points, end), nil
+	case TimelineMermaid:
+		return mermaidTimeline(title, intervals, points, end), nil
+	default:
+		return nil, fmt.Errorf("unknown timeline format %q", format)
+	}
+}
+
+// icalTimeline returns an iCalendar (RFC 5545) calendar with one event per
+// interval or point event.
+func icalTimeline(title string, intervals []pipeline.Interval, points []defs.Event, end time.Time) []byte {
+	var b bytes.Buffer
+	line := func(format string, a ...interface{}) {
+		b.WriteString(icalFold(fmt.Sprintf(format, a...)))
+		b.WriteString("\r\n")
+	}
+	stamp := func(t time.Time) string {
+		return t.UTC().Format("20060102T150405Z")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//seaflow-uw//seaflog//EN")
+	line("X-WR-CALNAME:%s", icalEscape(title))
+	for _, iv := range intervals {
+		ivEnd := iv.End
+		if ivEnd.IsZero() {
+			ivEnd = end
+		}
+		line("BEGIN:VEVENT")
+		line("UID:%s-%d@seaflog", iv.Name, iv.StartLine)
+		line("DTSTAMP:%s", stamp(iv.Start))
+		line("DTSTART:%s", stamp(iv.Start))
+		if !ivEnd.IsZero() {
+			line("DTEND:%s", stamp(ivEnd))
+		}
+		line("SUMMARY:%s", icalEscape(iv.Name))
+		line("END:VEVENT")
+	}
+	for _, event := range points {
+		line("BEGIN:VEVENT")
+		line("UID:%s-%d@seaflog", event.Name, event.LineNumber)
+		line("DTSTAMP:%s", stamp(event.Time))
+		line("DTSTART:%s", stamp(event.Time))
+		line("SUMMARY:%s", icalEscape(event.Name))
+		line("DESCRIPTION:%s", icalEscape(event.Line))
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.Bytes()
+}
+
+// icalEscape escapes iCalendar TEXT values.
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icalFold folds a content line longer than 75 octets.
+func icalFold(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
+
+// mermaidTimeline returns a Mermaid Gantt chart with a section per interval
+// name and a faults section of milestones. Times are UTC.
+func mermaidTimeline(title string, intervals []pipeline.Interval, points []defs.Event, end time.Time) []byte {
+	const layout = "2006-01-02 15:04:05"
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "gantt\n")
+	fmt.Fprintf(&b, "    title %s (UTC)\n", mermaidText(title))
+	fmt.Fprintf(&b, "    dateFormat YYYY-MM-DD HH:mm:ss\n")
+	fmt.Fprintf(&b, "    axisFormat %%m-%%d %%H:%%M\n")
+	section := ""
+	for _, iv := range sectionOrder(intervals) {
+		if iv.Name != section {
+			section = iv.Name
+			fmt.Fprintf(&b, "    section %s\n", mermaidText(section))
+		}
+		ivEnd := iv.End
+		if ivEnd.IsZero() {
+			ivEnd = end
+		}
+		if ivEnd.IsZero() || ivEnd.Before(iv.Start) {
+			ivEnd = iv.Start
+		}
+		fmt.Fprintf(
+			&b, "    %s :%s, %s\n",
+			mermaidText(iv.Name), iv.Start.UTC().Format(layout), ivEnd.UTC().Format(layout),
+		)
+	}
+	if len(points) > 0 {
+		fmt.Fprintf(&b, "    section faults\n")
+		for _, event := range points {
+			fmt.Fprintf(&b, "    %s :milestone, %s, 0d\n", mermaidText(event.Name), event.Time.UTC().Format(layout))
+		}
+	}
+	return b.Bytes()
+}
+
+// mermaidText removes characters with meaning in Mermaid Gantt task lines.
+func mermaidText(s string) string {
+	return strings.NewReplacer(":", " ", "#", " ", ";", " ", "\n", " ").Replace(s)
+}
+
+// sectionOrder returns intervals grouped by name in order of first
+// appearance, keeping time order within each name.
+func sectionOrder(intervals []pipeline.Interval) []pipeline.Interval {
+	order := []string{}
+	byName := make(map[string][]pipeline.Interval)
+	for _, iv := range intervals {
+		if _, ok := byName[iv.Name]; !ok {
+			order = append(order, iv.Name)
+		}
+		byName[iv.Name] = append(byName[iv.Name], iv)
+	}
+	out := make([]pipeline.Interval, 0, len(intervals))
+	for _, name := range order {
+		out = append(out, byName[name]...)
+	}
+	return out
+}
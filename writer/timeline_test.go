@@ -0,0 +1,58 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestTimeline(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:30:00+00:00")
+	t1 := t0.Add(time.Hour)
+	intervals := []pipeline.Interval{
+		{Name: "acquisition", Start: t0, End: t1, StartLine: 9, EndLine: 16},
+		{Name: "laser_on", Start: t0, StartLine: 10},
+	}
+	points := []defs.Event{
+		{Name: "pump_fault", Time: t0.Add(time.Minute), Line: "Pump over 25 psi, check setting", LineNumber: 15},
+	}
+
+	ical, err := writer.Timeline(writer.TimelineICal, "HOT227", intervals, points, t1)
+	if err != nil {
+		t.Fatalf("Timeline() error = %v; want nil", err)
+	}
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20150314T003000Z\r\nDTEND:20150314T013000Z\r\nSUMMARY:acquisition\r\n",
+		"UID:laser_on-10@seaflog\r\n",
+		"DESCRIPTION:Pump over 25 psi\\, check setting\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(string(ical), want) {
+			t.Errorf("ical timeline missing %q", want)
+		}
+	}
+
+	mermaid, err := writer.Timeline(writer.TimelineMermaid, "HOT227", intervals, points, t1)
+	if err != nil {
+		t.Fatalf("Timeline() error = %v; want nil", err)
+	}
+	for _, want := range []string{
+		"gantt\n",
+		"    section acquisition\n    acquisition :2015-03-14 00:30:00, 2015-03-14 01:30:00\n",
+		"    laser_on :2015-03-14 00:30:00, 2015-03-14 01:30:00\n",
+		"    pump_fault :milestone, 2015-03-14 00:31:00, 0d\n",
+	} {
+		if !strings.Contains(string(mermaid), want) {
+			t.Errorf("mermaid timeline missing %q", want)
+		}
+	}
+
+	if _, err := writer.Timeline("pdf", "HOT227", intervals, points, t1); err == nil {
+		t.Errorf("Timeline() error = nil; want an error")
+	}
+}
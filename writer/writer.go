@@ -0,0 +1,557 @@
+// Package writer serializes events in TSDATA and related text formats.
+package writer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctberthiaume/tsdata"
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+)
+
+// Output timestamp formats. Any other format string is treated as a custom
+// Go time layout.
+const (
+	TimeFormatRFC3339     = "rfc3339"     // RFC3339 with milliseconds if present and numeric time zone, the default
+	TimeFormatRFC3339Nano = "rfc3339nano" // RFC3339 with nanoseconds and numeric time zone
+	TimeFormatEpoch       = "epoch"       // seconds since the Unix epoch, with milliseconds if present
+	TimeFormatEpochMillis = "epochms"     // milliseconds since the Unix epoch
+)
+
+// ValidateTimeFormat returns an error if format is not a known time format
+// name or a usable custom layout.
+func ValidateTimeFormat(format string) error {
+	switch format {
+	case TimeFormatRFC3339, TimeFormatRFC3339Nano, TimeFormatEpoch, TimeFormatEpochMillis:
+		return nil
+	}
+	// A layout without any reference time elements formats every time the
+	// same way.
+	t0, t1 := time.Unix(0, 0).UTC(), time.Unix(1234567890, 123456789).UTC()
+	if format == "" || t0.Format(format) == t1.Format(format) {
+		return fmt.Errorf("invalid time format %q", format)
+	}
+	return nil
+}
+
+// ValidateTsdataTimeFormat returns an error if format can't be written to
+// TSDATA files, whose time columns must be RFC3339. Other formats are only for
+// CSV.
+func ValidateTsdataTimeFormat(format string) error {
+	switch format {
+	case TimeFormatRFC3339, TimeFormatRFC3339Nano:
+		return nil
+	}
+	return fmt.Errorf("TSDATA times must be %s or %s, not %q", TimeFormatRFC3339, TimeFormatRFC3339Nano, format)
+}
+
+// FormatTime formats t according to a time format name or custom layout.
+func FormatTime(t time.Time, format string) string {
+	switch format {
+	case TimeFormatRFC3339, "":
+		// Fractional seconds are only shown when present, so whole second
+		// times look the same as they always have.
+		return t.Format("2006-01-02T15:04:05.999-07:00")
+	case TimeFormatRFC3339Nano:
+		return t.Format("2006-01-02T15:04:05.999999999-07:00")
+	case TimeFormatEpoch:
+		secs := strconv.FormatInt(t.Unix(), 10)
+		if ms := t.Nanosecond() / int(time.Millisecond); ms != 0 {
+			return secs + strings.TrimRight(fmt.Sprintf(".%03d", ms), "0")
+		}
+		return secs
+	case TimeFormatEpochMillis:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	default:
+		return t.Format(format)
+	}
+}
+
+// timeComment describes the time column for a time format.
+func timeComment(format string) string {
+	switch format {
+	case TimeFormatRFC3339, TimeFormatRFC3339Nano, "":
+		return "ISO8601 timestamp"
+	case TimeFormatEpoch:
+		return "seconds since 1970-01-01T00:00:00Z"
+	case TimeFormatEpochMillis:
+		return "milliseconds since 1970-01-01T00:00:00Z"
+	default:
+		return fmt.Sprintf("timestamp with layout %s", format)
+	}
+}
+
+// TsdataWriter provides tools to write SeaFlow log files in TSDATA file format
+type TsdataWriter struct {
+	tsdata     tsdata.Tsdata
+	spec       TsdataSpec      // nil for DefaultTsdataVersion
+	coli       map[string]int  // column index by column name
+	keep       []int           // indexes of columns written, nil for all
+	added      map[string]bool // event columns of definitions not in defs.EventDefs
+	timeFormat string
+	counters   *pipeline.Counters
+	qc         *pipeline.QC
+	computer   *pipeline.Computer
+	units      *pipeline.UnitConverter
+	seq        bool // write Event.Seq in a seq column
+	provenance bool // write Event.Source and Event.LineNumber columns
+	instrument bool // write Event.Instrument in an instrument column
+	// Float formats, global and by event name. Event definition float formats
+	// fall between these in precedence.
+	floatFormat  string
+	floatFormats map[string]string
+}
+
+// NewTsdataWriter creates a new TsdataWriter struct
+func NewTsdataWriter(fileType string, project string, description string) TsdataWriter {
+	t := TsdataWriter{
+		tsdata: tsdata.Tsdata{
+			FileType:        fileType,
+			Project:         project,
+			FileDescription: description,
+		},
+		timeFormat: TimeFormatRFC3339,
+	}
+	// Get event names in unique, sorted order
+	keys := make([]string, len(defs.EventDefs))
+	i := 0
+	for name := range defs.EventDefs {
+		keys[i] = name
+		i++
+	}
+	sort.Strings(keys)
+	// Prepend "time"
+	columns := make([]string, len(keys)+1)
+	columns[0] = "time"
+	for i, k := range keys {
+		columns[i+1] = k
+	}
+	// Populate header fields
+	t.tsdata.Headers = columns
+	t.tsdata.Types = make([]string, len(columns))
+	t.tsdata.Comments = make([]string, len(columns))
+	t.tsdata.Units = make([]string, len(columns))
+
+	t.coli = make(map[string]int) // column indexes by name
+	for i, column := range columns {
+		if i == 0 {
+			t.tsdata.Comments[i] = "ISO8601 timestamp"
+			t.tsdata.Types[i] = "time"
+			t.tsdata.Units[i] = tsdata.NA
+			t.coli["time"] = i
+		} else {
+			t.tsdata.Comments[i] = tsdata.NA
+			edef, ok := defs.EventDefs[column]
+			if !ok {
+				panic(fmt.Errorf("Event definition for %v not found", column))
+			}
+			t.tsdata.Types[i] = edef.Type
+			t.tsdata.Units[i] = tsdata.NA
+			if edef.Unit != "" {
+				t.tsdata.Units[i] = edef.Unit
+			}
+			t.coli[column] = i
+		}
+	}
+
+	// Final check that everything looks good
+	err := t.tsdata.ValidateMetadata()
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// SetTimeFormat sets the format of the time column, TimeFormatRFC3339 or
+// TimeFormatRFC3339Nano, the only formats TSDATA allows.
+func (t *TsdataWriter) SetTimeFormat(format string) error {
+	if err := ValidateTsdataTimeFormat(format); err != nil {
+		return err
+	}
+	t.setTimeFormat(format)
+	return nil
+}
+
+// setTimeFormat sets the format of the time column without checking it.
+func (t *TsdataWriter) setTimeFormat(format string) {
+	t.timeFormat = format
+	t.tsdata.Comments[t.coli["time"]] = strings.ReplaceAll(timeComment(format), tsdata.Delim, " ")
+}
+
+// SetCounters adds delta and cumulative columns for counter events, filled in
+// from c as events are serialized. Because these columns depend on previous
+// events, EventText must then be called for every event in log order.
+func (t *TsdataWriter) SetCounters(c *pipeline.Counters) {
+	t.counters = c
+	for _, name := range c.Names() {
+		t.addColumn(name+"_delta", "float", "change since previous "+name)
+		t.addColumn(name+"_cumulative", "float", name+" corrected for resets and rollovers")
+	}
+	if err := t.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+}
+
+// SetQC adds <event>_qc columns of QARTOD flags for events with quality
+// control rules, filled in from q as events are serialized. Because flags
+// depend on previous events, EventText must then be called for every event in
+// log order.
+func (t *TsdataWriter) SetQC(q *pipeline.QC) {
+	t.qc = q
+	for _, name := range q.Names() {
+		t.addColumn(name+"_qc", "integer", "QARTOD flag for "+name+", 1 pass, 3 suspect, 4 fail, 9 missing")
+	}
+	if err := t.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+}
+
+// SetComputed adds a float column for each computed column of c, filled in
+// on every line from the latest event values. Because these columns depend on
+// previous events, EventText must then be called for every event in log order.
+func (t *TsdataWriter) SetComputed(c *pipeline.Computer) error {
+	for _, col := range c.Columns() {
+		if _, ok := t.coli[col.Name]; ok {
+			return fmt.Errorf("computed column %q duplicates an existing column", col.Name)
+		}
+		t.addColumn(col.Name, "float", strings.ReplaceAll("computed as "+col.Expr, tsdata.Delim, " "))
+	}
+	t.computer = c
+	if err := t.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
+// AddSeqColumn adds an integer seq column filled in from Event.Seq, so the
+// original order of events which share a time can be recovered.
+func (t *TsdataWriter) AddSeqColumn() {
+	if t.seq {
+		return
+	}
+	t.seq = true
+	t.addColumn("seq", "integer", "event sequence number in log order")
+	if err := t.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+}
+
+// AddEventColumn adds a column for event name, which must be an extra
+// channel of an indexed event definition, e.g. PMT9 for PMT{n}, since other
+// events already have columns. Add columns before writing the header.
+func (t *TsdataWriter) AddEventColumn(name string) error {
+	if _, ok := t.coli[name]; ok {
+		return fmt.Errorf("column %q already exists", name)
+	}
+	edef, ok := defs.Lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown event %q", name)
+	}
+	return t.addEventColumn(edef)
+}
+
+// AddEventDefColumn adds a column for events of edef, a definition not in
+// defs.EventDefs, e.g. one discovered in the log. Add columns before writing
+// the header.
+func (t *TsdataWriter) AddEventDefColumn(edef defs.EventDef) error {
+	if _, ok := t.coli[edef.Name]; ok {
+		return fmt.Errorf("column %q already exists", edef.Name)
+	}
+	if t.added == nil {
+		t.added = make(map[string]bool)
+	}
+	t.added[edef.Name] = true
+	return t.addEventColumn(edef)
+}
+
+// addEventColumn adds a column for events of edef.
+func (t *TsdataWriter) addEventColumn(edef defs.EventDef) error {
+	t.addColumn(edef.Name, edef.Type, tsdata.NA)
+	if edef.Unit != "" {
+		unit := edef.Unit
+		if t.units != nil {
+			unit = t.units.Target(unit)
+		}
+		t.tsdata.Units[len(t.tsdata.Units)-1] = unit
+	}
+	return t.tsdata.ValidateMetadata()
+}
+
+// AddProvenanceColumns adds source and line columns filled in from
+// Event.Source and Event.LineNumber, so events can be traced back to their log
+// file line after files are merged.
+func (t *TsdataWriter) AddProvenanceColumns() {
+	if t.provenance {
+		return
+	}
+	t.provenance = true
+	t.addColumn("source", "text", "source log file")
+	t.addColumn("line", "integer", "line number in source log file")
+	if err := t.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+}
+
+// AddInstrumentColumn adds an instrument column filled in from
+// Event.Instrument.
+func (t *TsdataWriter) AddInstrumentColumn() {
+	if t.instrument {
+		return
+	}
+	t.instrument = true
+	t.addColumn("instrument", "text", "instrument ID")
+	if err := t.tsdata.ValidateMetadata(); err != nil {
+		panic(err)
+	}
+}
+
+// ValidateFloatFormat returns an error if format is not a fmt format for a
+// single float64 value.
+func ValidateFloatFormat(format string) error {
+	out := fmt.Sprintf(format, 1.5)
+	if strings.Contains(out, "%!") || out == format {
+		return fmt.Errorf("invalid float format %q", format)
+	}
+	return nil
+}
+
+// SetFloatFormat sets a fmt format, e.g. "%.4g", for all float columns without
+// a more specific format. The default is "%v".
+func (t *TsdataWriter) SetFloatFormat(format string) error {
+	if err := ValidateFloatFormat(format); err != nil {
+		return err
+	}
+	t.floatFormat = format
+	return nil
+}
+
+// SetEventFloatFormat sets a fmt format for float values of one event,
+// overriding the event definition's float format and the global float format.
+func (t *TsdataWriter) SetEventFloatFormat(name string, format string) error {
+	if _, ok := defs.EventDefs[name]; !ok && !t.added[name] {
+		return fmt.Errorf("unknown event %q", name)
+	}
+	if err := ValidateFloatFormat(format); err != nil {
+		return err
+	}
+	if t.floatFormats == nil {
+		t.floatFormats = make(map[string]string)
+	}
+	t.floatFormats[name] = format
+	return nil
+}
+
+// floatText formats a float value of event name.
+func (t TsdataWriter) floatText(name string, v interface{}) string {
+	if _, ok := v.(float64); ok {
+		if format, ok := t.floatFormats[name]; ok {
+			return fmt.Sprintf(format, v)
+		}
+		if edef, _ := defs.Lookup(name); edef.FloatFormat != "" {
+			return fmt.Sprintf(edef.FloatFormat, v)
+		}
+		if t.floatFormat != "" {
+			return fmt.Sprintf(t.floatFormat, v)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// SetUnits converts float values to the units of uc and updates header units
+// to match.
+func (t *TsdataWriter) SetUnits(uc *pipeline.UnitConverter) {
+	t.units = uc
+	for i, name := range t.tsdata.Headers {
+		if edef, ok := defs.Lookup(name); ok && edef.Unit != "" {
+			t.tsdata.Units[i] = uc.Target(edef.Unit)
+		}
+	}
+}
+
+// addColumn adds a new column at the end of the header.
+func (t *TsdataWriter) addColumn(name string, typ string, comment string) {
+	t.coli[name] = len(t.tsdata.Headers)
+	t.tsdata.Headers = append(t.tsdata.Headers, name)
+	t.tsdata.Types = append(t.tsdata.Types, typ)
+	t.tsdata.Comments = append(t.tsdata.Comments, comment)
+	t.tsdata.Units = append(t.tsdata.Units, tsdata.NA)
+}
+
+// SetSpec sets the TSDATA spec version the header is written for, by default
+// DefaultTsdataVersion.
+func (t *TsdataWriter) SetSpec(spec TsdataSpec) {
+	t.spec = spec
+}
+
+// KeepEventColumns leaves the columns of events not in names, and their
+// counter and QC columns, out of the output, e.g. events which never occur in
+// the time range converted. Other columns, e.g. time, seq, and computed
+// columns, are always written. If no event columns would be left all columns
+// are written. Call after adding columns, before writing the header.
+func (t *TsdataWriter) KeepEventColumns(names map[string]bool) {
+	keep := []int{}
+	events := 0
+	for i, name := range t.tsdata.Headers {
+		event := t.columnEvent(name)
+		if event == "" || names[event] {
+			keep = append(keep, i)
+			if event != "" {
+				events++
+			}
+		}
+	}
+	if events > 0 {
+		t.keep = keep
+	}
+}
+
+// columnEvent returns the event whose values fill column name, or "" if it
+// isn't an event, counter, or QC column.
+func (t TsdataWriter) columnEvent(name string) string {
+	suffixes := []string{""}
+	if t.counters != nil {
+		suffixes = append(suffixes, "_delta", "_cumulative")
+	}
+	if t.qc != nil {
+		suffixes = append(suffixes, "_qc")
+	}
+	for _, suffix := range suffixes {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		event := strings.TrimSuffix(name, suffix)
+		if _, ok := defs.Lookup(event); ok || t.added[event] {
+			return event
+		}
+	}
+	return ""
+}
+
+// written returns the metadata of the columns written, sharing slices with
+// t.tsdata if all are.
+func (t TsdataWriter) written() tsdata.Tsdata {
+	m := t.tsdata
+	if t.keep != nil {
+		m.Headers = pickFields(t.tsdata.Headers, t.keep)
+		m.Types = pickFields(t.tsdata.Types, t.keep)
+		m.Comments = pickFields(t.tsdata.Comments, t.keep)
+		m.Units = pickFields(t.tsdata.Units, t.keep)
+	}
+	return m
+}
+
+// pickFields returns the fields at indexes.
+func pickFields(fields []string, indexes []int) []string {
+	picked := make([]string, len(indexes))
+	for i, j := range indexes {
+		picked[i] = fields[j]
+	}
+	return picked
+}
+
+// HeaderText returns a TSDATA header string
+func (t TsdataWriter) HeaderText() string {
+	m := t.written()
+	if t.spec != nil {
+		return t.spec.Header(m)
+	}
+	return m.Header()
+}
+
+// EventText returns a TSDATA event line string for one Event
+func (t TsdataWriter) EventText(event defs.Event) (string, error) {
+	if event.Error != nil {
+		return "", nil
+	}
+	outs, err := t.eventFields(event, tsdata.NA)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(outs, tsdata.Delim), nil
+}
+
+// eventFields returns output fields for one Event, with na for missing values.
+func (t TsdataWriter) eventFields(event defs.Event, na string) ([]string, error) {
+	outs := make([]string, len(t.tsdata.Headers))
+	outs[0] = FormatTime(event.Time, t.timeFormat)
+	for i := 1; i < len(outs); i++ {
+		outs[i] = na
+	}
+
+	// QC thresholds are in the units values are logged in
+	logged := event
+	if t.units != nil {
+		var err error
+		if event, err = t.units.Convert(event); err != nil {
+			return nil, fmt.Errorf("unit conversion failed for column %q, line %d, %v", event.Name, event.LineNumber, err)
+		}
+	}
+
+	if t.seq {
+		outs[t.coli["seq"]] = strconv.Itoa(event.Seq)
+	}
+	if t.provenance {
+		if event.Source != "" {
+			outs[t.coli["source"]] = strings.ReplaceAll(event.Source, tsdata.Delim, " ")
+		}
+		outs[t.coli["line"]] = strconv.Itoa(event.LineNumber)
+	}
+	if t.instrument && event.Instrument != "" {
+		outs[t.coli["instrument"]] = strings.ReplaceAll(event.Instrument, tsdata.Delim, " ")
+	}
+
+	if i, ok := t.coli[event.Name]; ok {
+		if event.Value == nil {
+			// Missing value, leave as na
+		} else if t.tsdata.Types[i] == "boolean" {
+			boolVal, ok := event.Value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("bad boolean value for column %q %q, line %d", event.Name, i, event.LineNumber)
+			}
+			if boolVal {
+				outs[i] = "TRUE"
+			} else {
+				outs[i] = "FALSE"
+			}
+		} else if t.tsdata.Types[i] == "text" || t.tsdata.Types[i] == "category" {
+			// Replace tsdata.Delim with spaces
+			outs[i] = strings.ReplaceAll(fmt.Sprintf("%v", event.Value), tsdata.Delim, " ")
+		} else {
+			outs[i] = t.floatText(event.Name, event.Value)
+		}
+		if t.counters != nil {
+			if cv, ok := t.counters.Update(event); ok {
+				if !cv.First {
+					outs[t.coli[event.Name+"_delta"]] = t.floatText(event.Name, cv.Delta)
+				}
+				outs[t.coli[event.Name+"_cumulative"]] = t.floatText(event.Name, cv.Cumulative)
+			}
+		}
+		if t.qc != nil {
+			if flag, ok := t.qc.Check(logged); ok {
+				outs[t.coli[event.Name+"_qc"]] = strconv.Itoa(flag)
+			}
+		}
+		if t.computer != nil {
+			names := t.computer.Names()
+			for j, v := range t.computer.Update(event) {
+				if !math.IsNaN(v) {
+					outs[t.coli[names[j]]] = t.floatText(names[j], v)
+				}
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("TSDATA column index for event named '%s' not found", event.Name)
+	}
+
+	if t.keep != nil {
+		return pickFields(outs, t.keep), nil
+	}
+	return outs, nil
+}
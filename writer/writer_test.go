@@ -0,0 +1,449 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seaflow-uw/seaflog/v2/defs"
+	"github.com/seaflow-uw/seaflog/v2/pipeline"
+	"github.com/seaflow-uw/seaflog/v2/scanner"
+	"github.com/seaflow-uw/seaflog/v2/writer"
+)
+
+func TestFormatTime(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339Nano, "2015-03-14T00:26:52.123456789-07:00")
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{writer.TimeFormatRFC3339, "2015-03-14T00:26:52.123-07:00"},
+		{writer.TimeFormatRFC3339Nano, "2015-03-14T00:26:52.123456789-07:00"},
+		{writer.TimeFormatEpoch, "1426318012.123"},
+		{writer.TimeFormatEpochMillis, "1426318012123"},
+		{"2006/01/02 15:04", "2015/03/14 00:26"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := writer.ValidateTimeFormat(tt.format); err != nil {
+				t.Fatalf("ValidateTimeFormat(%q) = %v; want nil", tt.format, err)
+			}
+			if got := writer.FormatTime(t0, tt.format); got != tt.want {
+				t.Errorf("FormatTime(%q) = %v; want %v", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("bad layout", func(t *testing.T) {
+		if err := writer.ValidateTimeFormat("not a layout"); err == nil {
+			t.Errorf("ValidateTimeFormat() = nil; want an error")
+		}
+	})
+}
+
+func TestSetTimeFormat(t *testing.T) {
+	for _, format := range []string{writer.TimeFormatRFC3339, writer.TimeFormatRFC3339Nano, writer.TimeFormatEpoch, writer.TimeFormatEpochMillis, "2006/01/02 15:04"} {
+		t.Run(format, func(t *testing.T) {
+			// Only RFC3339 is valid TSDATA, CSV can use any format
+			wantErr := format != writer.TimeFormatRFC3339 && format != writer.TimeFormatRFC3339Nano
+			tw := writer.NewTsdataWriter("test", "test", "")
+			if err := tw.SetTimeFormat(format); (err != nil) != wantErr {
+				t.Errorf("TsdataWriter.SetTimeFormat() error = %v; want error %v", err, wantErr)
+			}
+			iw := writer.NewIntervalsWriter("test", "test", "")
+			if err := iw.SetTimeFormat(format); (err != nil) != wantErr {
+				t.Errorf("IntervalsWriter.SetTimeFormat() error = %v; want error %v", err, wantErr)
+			}
+			cw := writer.NewCSVWriter("test", "test", "")
+			if err := cw.SetTimeFormat(format); err != nil {
+				t.Errorf("CSVWriter.SetTimeFormat() error = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestFormatSubSecondTime(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52.25+00:00")
+	for format, want := range map[string]string{
+		writer.TimeFormatRFC3339:     "2015-03-14T00:26:52.25+00:00",
+		writer.TimeFormatEpoch:       "1426292812.25",
+		writer.TimeFormatEpochMillis: "1426292812250",
+	} {
+		if got := writer.FormatTime(t0, format); got != want {
+			t.Errorf("FormatTime(%q) = %v; want %v", format, got, want)
+		}
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	if err := w.SetFloatFormat("%.1f"); err != nil {
+		t.Fatalf("SetFloatFormat() error = %v; want nil", err)
+	}
+	if err := w.SetEventFloatFormat("PMT2", "%.3e"); err != nil {
+		t.Fatalf("SetEventFloatFormat() error = %v; want nil", err)
+	}
+	if err := w.SetFloatFormat("%d"); err == nil {
+		t.Errorf("SetFloatFormat(%q) error = nil; want an error", "%d")
+	}
+	if err := w.SetEventFloatFormat("not_an_event", "%.1f"); err == nil {
+		t.Errorf("SetEventFloatFormat() error = nil; want an error")
+	}
+
+	input := "2015-03-14T00-26-52+00-00\nPMT1:1.05123\nPMT2:1.05123\n"
+	want := []string{"1.1", "1.051e+00"}
+	got := []string{}
+	scanner := scanner.NewEventScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		for _, field := range strings.Split(line, "\t")[1:] {
+			if field != "NA" {
+				got = append(got, field)
+			}
+		}
+	}
+	stringsEqual(got, want, t)
+}
+
+func TestCounterColumns(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.SetCounters(pipeline.NewCounters())
+	header := w.HeaderText()
+	columns := strings.Split(header[strings.LastIndex(header, "\n")+1:], "\t")
+	last := columns[len(columns)-2:]
+	stringsEqual(last, []string{"syringe_pump_injection_delta", "syringe_pump_injection_cumulative"}, t)
+}
+
+func TestQCColumns(t *testing.T) {
+	hi := 1.0
+	defs.EventDefs["test_qc"] = defs.EventDef{
+		Name:       "test_qc",
+		Type:       "float",
+		QC:         &defs.QCDef{Max: &hi},
+		EventForms: []defs.EventForm{{StartsWith: "test qc:", ValueAction: "as_float"}},
+	}
+	defer delete(defs.EventDefs, "test_qc")
+
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.SetQC(pipeline.NewQC())
+	header := w.HeaderText()
+	columns := strings.Split(header[strings.LastIndex(header, "\n")+1:], "\t")
+	if columns[len(columns)-1] != "test_qc_qc" {
+		t.Fatalf("last column %v; want test_qc_qc", columns[len(columns)-1])
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\ntest qc:0.5\ntest qc:2\nPMT1:1\n"))
+	got := []string{}
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		fields := strings.Split(line, "\t")
+		got = append(got, fields[len(fields)-1])
+	}
+	stringsEqual(got, []string{"1", "4", "NA"}, t)
+}
+
+func TestQCColumnsUnits(t *testing.T) {
+	hi := 1.0
+	defs.EventDefs["test_qc"] = defs.EventDef{
+		Name:       "test_qc",
+		Type:       "float",
+		Unit:       "V",
+		QC:         &defs.QCDef{Max: &hi},
+		EventForms: []defs.EventForm{{StartsWith: "test qc:", ValueAction: "as_float"}},
+	}
+	defer delete(defs.EventDefs, "test_qc")
+
+	uc, err := pipeline.NewUnitConverter("mV")
+	if err != nil {
+		t.Fatalf("NewUnitConverter() error = %v; want nil", err)
+	}
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.SetUnits(uc)
+	w.SetQC(pipeline.NewQC())
+
+	header := w.HeaderText()
+	columns := strings.Split(header[strings.LastIndex(header, "\n")+1:], "\t")
+	col := -1
+	for i, name := range columns {
+		if name == "test_qc" {
+			col = i
+		}
+	}
+	if col == -1 {
+		t.Fatalf("HeaderText() has no test_qc column")
+	}
+
+	// Thresholds apply to values as logged, 0.5 V, not 500 mV
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\ntest qc:0.5\ntest qc:2\n"))
+	got := []string{}
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		fields := strings.Split(line, "\t")
+		got = append(got, fields[col], fields[len(fields)-1])
+	}
+	stringsEqual(got, []string{"500", "1", "2000", "4"}, t)
+}
+
+func TestComputedColumns(t *testing.T) {
+	col, err := pipeline.ParseComputed("pmt_ratio=PMT1/PMT2")
+	if err != nil {
+		t.Fatalf("ParseComputed() error = %v; want nil", err)
+	}
+	w := writer.NewTsdataWriter("test", "test", "")
+	if err := w.SetComputed(pipeline.NewComputer([]pipeline.Computed{col})); err != nil {
+		t.Fatalf("SetComputed() error = %v; want nil", err)
+	}
+	if err := w.SetComputed(pipeline.NewComputer([]pipeline.Computed{col})); err == nil {
+		t.Errorf("SetComputed() of duplicate column error = nil; want error")
+	}
+	header := w.HeaderText()
+	columns := strings.Split(header[strings.LastIndex(header, "\n")+1:], "\t")
+	if columns[len(columns)-1] != "pmt_ratio" {
+		t.Fatalf("last column %v; want pmt_ratio", columns[len(columns)-1])
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nPMT1:1\nPMT2:4\nSyringe pump injection:5\n"))
+	got := []string{}
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		fields := strings.Split(line, "\t")
+		got = append(got, fields[len(fields)-1])
+	}
+	stringsEqual(got, []string{"NA", "0.25", "0.25"}, t)
+}
+
+func TestSeqColumn(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.AddSeqColumn()
+	header := w.HeaderText()
+	columns := strings.Split(header[strings.LastIndex(header, "\n")+1:], "\t")
+	if columns[len(columns)-1] != "seq" {
+		t.Fatalf("last column %v; want seq", columns[len(columns)-1])
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nPMT1:1\nPMT1:2\n"))
+	got := []string{}
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		fields := strings.Split(line, "\t")
+		got = append(got, fields[len(fields)-1])
+	}
+	stringsEqual(got, []string{"1", "2"}, t)
+}
+
+func TestProvenanceColumns(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.AddProvenanceColumns()
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nPMT1:1\n"))
+	scanner.SourceName("SFlog_740.txt")
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		fields := strings.Split(line, "\t")
+		stringsEqual(fields[len(fields)-2:], []string{"SFlog_740.txt", "2"}, t)
+	}
+}
+
+func TestUnitConverter(t *testing.T) {
+	if _, err := pipeline.NewUnitConverter("mV,V"); err == nil {
+		t.Errorf("NewUnitConverter() error = nil; want an error")
+	}
+
+	uc, err := pipeline.NewUnitConverter("mV")
+	if err != nil {
+		t.Fatalf("NewUnitConverter() error = %v; want nil", err)
+	}
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.SetUnits(uc)
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nPMT1:1.05\n"))
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		if fields := strings.Split(line, "\t"); fields[1] != "1050" {
+			t.Errorf("PMT1 = %v; want 1050", fields[1])
+		}
+	}
+	if !strings.Contains(w.HeaderText(), "\tmV\t") {
+		t.Errorf("HeaderText() has no mV unit")
+	}
+}
+
+func stringsEqual(got, want []string, t *testing.T) {
+	if len(got) != len(want) {
+		t.Fatalf("len(got) %v: len(want) %v", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got[i] %v; want[i] %v", got[i], want[i])
+		}
+	}
+}
+
+func TestCategoryColumn(t *testing.T) {
+	defs.EventDefs["enum_test"] = defs.EventDef{
+		Name:       "enum_test",
+		Type:       "category",
+		Values:     []string{"A", "B"},
+		EventForms: []defs.EventForm{{StartsWith: "enum_test:", ValueAction: "as_enum"}},
+	}
+	defer delete(defs.EventDefs, "enum_test")
+	w := writer.NewTsdataWriter("test", "test", "")
+	var col writer.Column
+	for _, c := range w.Columns() {
+		if c.Name == "enum_test" {
+			col = c
+		}
+	}
+	if col.Type != "category" || len(col.Values) != 2 {
+		t.Fatalf("enum_test column %+v; want category with values [A B]", col)
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nenum_test: B\n"))
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		if !strings.Contains(line, "\tB") {
+			t.Errorf("EventText() %q; want category value B", line)
+		}
+	}
+}
+
+func TestAddEventColumn(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	if err := w.AddEventColumn("PMT9"); err != nil {
+		t.Fatalf("AddEventColumn(PMT9) error = %v; want nil", err)
+	}
+	if err := w.AddEventColumn("PMT1"); err == nil {
+		t.Errorf("AddEventColumn(PMT1) error = nil; want an error for an existing column")
+	}
+	if err := w.AddEventColumn("bogus"); err == nil {
+		t.Errorf("AddEventColumn(bogus) error = nil; want an error for an unknown event")
+	}
+	cols := w.Columns()
+	if last := cols[len(cols)-1]; last.Name != "PMT9" || last.Type != "float" || last.Unit != "V" {
+		t.Errorf("last column %+v; want PMT9 float V", last)
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nPMT9:1.5\n"))
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		if !strings.HasSuffix(line, "\t1.5") {
+			t.Errorf("EventText() %q; want PMT9 value 1.5 in the last column", line)
+		}
+	}
+}
+
+func TestAddEventDefColumn(t *testing.T) {
+	edef := defs.EventDef{Name: "sheath_temp", Type: "float"}
+	w := writer.NewTsdataWriter("test", "test", "")
+	if err := w.AddEventDefColumn(edef); err != nil {
+		t.Fatalf("AddEventDefColumn() error = %v; want nil", err)
+	}
+	if err := w.AddEventDefColumn(edef); err == nil {
+		t.Errorf("AddEventDefColumn() error = nil; want an error for an existing column")
+	}
+	if err := w.SetEventFloatFormat("sheath_temp", "%.2f"); err != nil {
+		t.Errorf("SetEventFloatFormat() error = %v; want nil", err)
+	}
+	cols := w.Columns()
+	if last := cols[len(cols)-1]; last.Name != "sheath_temp" || last.Type != "float" {
+		t.Errorf("last column %+v; want sheath_temp float", last)
+	}
+
+	scanner := scanner.NewEventScanner(strings.NewReader("2015-03-14T00-26-52+00-00\nSheath Temp: 21.5\n"))
+	scanner.AddDefs(defs.EventDef{
+		Name: "sheath_temp", Type: "float",
+		EventForms: []defs.EventForm{{StartsWith: "Sheath Temp:", ValueAction: "as_float"}},
+	})
+	for scanner.Scan() {
+		line, err := w.EventText(scanner.Event())
+		if err != nil {
+			t.Fatalf("EventText() error = %v; want nil", err)
+		}
+		if !strings.HasSuffix(line, "\t21.50") {
+			t.Errorf("EventText() %q; want sheath_temp value 21.50 in the last column", line)
+		}
+	}
+}
+
+func TestKeepEventColumns(t *testing.T) {
+	w := writer.NewTsdataWriter("test", "test", "")
+	w.SetCounters(pipeline.NewCounters())
+	w.AddSeqColumn()
+	w.KeepEventColumns(map[string]bool{"PMT1": true, "syringe_pump_injection": true})
+
+	names := []string{}
+	for _, col := range w.Columns() {
+		names = append(names, col.Name)
+	}
+	want := "time,PMT1,syringe_pump_injection,syringe_pump_injection_delta,syringe_pump_injection_cumulative,seq"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("Columns() = %v; want %v", got, want)
+	}
+	header := strings.Split(w.HeaderText(), "\n")
+	if got := header[len(header)-1]; got != strings.ReplaceAll(want, ",", "\t") {
+		t.Errorf("HeaderText() column names = %q; want %q", got, want)
+	}
+
+	t0, _ := time.Parse(time.RFC3339, "2015-03-14T00:26:52Z")
+	line, err := w.EventText(defs.Event{Name: "PMT1", Value: 1.5, Time: t0, Seq: 3})
+	if err != nil {
+		t.Fatalf("EventText() error = %v; want nil", err)
+	}
+	if want := "2015-03-14T00:26:52+00:00\t1.5\tNA\tNA\tNA\t3"; line != want {
+		t.Errorf("EventText() = %q; want %q", line, want)
+	}
+
+	t.Run("discovered", func(t *testing.T) {
+		w := writer.NewTsdataWriter("test", "test", "")
+		for _, name := range []string{"mode", "sheath_temp"} {
+			if err := w.AddEventDefColumn(defs.EventDef{Name: name, Type: "text"}); err != nil {
+				t.Fatalf("AddEventDefColumn() error = %v; want nil", err)
+			}
+		}
+		w.KeepEventColumns(map[string]bool{"PMT1": true, "mode": true})
+		names := []string{}
+		for _, col := range w.Columns() {
+			names = append(names, col.Name)
+		}
+		if got := strings.Join(names, ","); got != "time,PMT1,mode" {
+			t.Errorf("Columns() = %v; want time,PMT1,mode", got)
+		}
+	})
+
+	t.Run("none used", func(t *testing.T) {
+		w := writer.NewTsdataWriter("test", "test", "")
+		all := len(w.Columns())
+		w.KeepEventColumns(map[string]bool{})
+		if got := len(w.Columns()); got != all {
+			t.Errorf("len(Columns()) = %v; want all %v columns", got, all)
+		}
+	})
+}